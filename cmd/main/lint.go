@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"twitchspam/internal/app/infrastructure/config"
+)
+
+// lint проверяет конфиг без запуска бота: twitchspam lint [-format text|json] [каталог].
+// Код возврата 1 - найдены ошибки, 2 - неверные аргументы.
+func lint(args []string) int {
+	fset := flag.NewFlagSet("lint", flag.ContinueOnError)
+	format := fset.String("format", "text", "output format: text or json")
+	if err := fset.Parse(args); err != nil {
+		return 2
+	}
+
+	dir := "configs"
+	if fset.NArg() > 0 {
+		dir = fset.Arg(0)
+	}
+
+	issues := config.Lint(dir)
+
+	var errs, warnings int
+	for _, issue := range issues {
+		if issue.Severity == config.SeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Errors   int            `json:"errors"`
+			Warnings int            `json:"warnings"`
+			Issues   []config.Issue `json:"issues"`
+		}{errs, warnings, append([]config.Issue{}, issues...)}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	case "text":
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		fmt.Printf("%s: %d error(s), %d warning(s)\n", dir, errs, warnings)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		return 2
+	}
+
+	if errs > 0 {
+		return 1
+	}
+	return 0
+}
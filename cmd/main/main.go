@@ -2,10 +2,15 @@ package main
 
 import (
 	"log"
+	"os"
 	"twitchspam/internal/pkg/app"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "lint" {
+		os.Exit(lint(os.Args[2:]))
+	}
+
 	if err := app.New(); err != nil {
 		log.Fatal(err)
 	}
@@ -8,10 +8,18 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strings"
+	"twitchspam/internal/app/ports"
 	"twitchspam/pkg/logger"
 )
 
+const hasteHost = "haste.potat.app"
+
+// hasteKey - ключ документа Haste, допускается расширение (abcdef.json).
+var hasteKey = regexp.MustCompile(`^[A-Za-z0-9]+(?:\.[A-Za-z0-9]+)?$`)
+
 type FileServer struct {
 	log    logger.Logger
 	client *http.Client
@@ -27,7 +35,7 @@ func New(log logger.Logger, client *http.Client) *FileServer {
 func (fs *FileServer) UploadToHaste(text string) (string, error) {
 	fs.log.Debug("Starting file upload to Haste", slog.Int("text_length", len(text)))
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "https://haste.potat.app/documents", strings.NewReader(text))
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "https://"+hasteHost+"/documents", strings.NewReader(text))
 	if err != nil {
 		fs.log.Error("Failed to create HTTP request", err)
 		return "", err
@@ -73,7 +81,74 @@ func (fs *FileServer) UploadToHaste(text string) (string, error) {
 }
 
 func (fs *FileServer) GetURL(key string) string {
-	url := "https://haste.potat.app/raw/" + key
+	url := "https://" + hasteHost + "/raw/" + key
 	fs.log.Trace("Generated Haste file URL", slog.String("url", url))
 	return url
 }
+
+// Download загружает документ Haste по ключу или ссылке на него. Другие адреса не принимаются,
+// чтобы через команду нельзя было обратиться к произвольным хостам.
+func (fs *FileServer) Download(keyOrURL string) (string, error) {
+	const maxSize = 1 << 20
+
+	key, err := parseHasteKey(keyOrURL)
+	if err != nil {
+		fs.log.Warn("Rejected download source", slog.String("source", keyOrURL))
+		return "", err
+	}
+
+	url := fs.GetURL(key)
+	fs.log.Debug("Starting file download", slog.String("url", url))
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		fs.log.Error("Failed to create HTTP request", err)
+		return "", err
+	}
+
+	// редирект мог бы увести запрос с хоста Haste
+	client := *fs.client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	resp, err := client.Do(req)
+	if err != nil {
+		fs.log.Error("HTTP request for download failed", err)
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		fs.log.Error("Unexpected HTTP status during download", nil, slog.Int("status_code", resp.StatusCode), slog.String("url", url))
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		fs.log.Error("Failed to read downloaded file", err)
+		return "", err
+	}
+
+	if len(raw) > maxSize {
+		return "", errors.New("file is too large")
+	}
+	return string(raw), nil
+}
+
+// parseHasteKey достаёт ключ документа из ключа или ссылки вида https://haste.potat.app/<ключ> и /raw/<ключ>.
+func parseHasteKey(keyOrURL string) (string, error) {
+	keyOrURL = strings.TrimSpace(keyOrURL)
+	if hasteKey.MatchString(keyOrURL) {
+		return keyOrURL, nil
+	}
+
+	u, err := url.Parse(keyOrURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.User != nil || u.Host != hasteHost || u.RawQuery != "" || u.Fragment != "" {
+		return "", ports.ErrNotHaste
+	}
+
+	key := strings.TrimPrefix(strings.TrimPrefix(u.Path, "/"), "raw/")
+	if !hasteKey.MatchString(key) {
+		return "", ports.ErrNotHaste
+	}
+	return key, nil
+}
@@ -0,0 +1,85 @@
+package file_server_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"twitchspam/internal/app/adapters/file_server"
+	"twitchspam/internal/app/ports"
+	"twitchspam/pkg/logger"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// newTestFileServer создаёт файловый сервер во временном каталоге, чтобы журнал логгера не попадал в исходники.
+func newTestFileServer(t *testing.T, client *http.Client) *file_server.FileServer {
+	t.Chdir(t.TempDir())
+	return file_server.New(logger.New(), client)
+}
+
+func TestDownload_OnlyHaste(t *testing.T) {
+	var requested []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requested = append(requested, r.URL.String())
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}")), Request: r}, nil
+	})}
+	fs := newTestFileServer(t, client)
+
+	accepted := map[string]string{
+		"abcdef":                             "https://haste.potat.app/raw/abcdef",
+		" abcdef.json ":                      "https://haste.potat.app/raw/abcdef.json",
+		"https://haste.potat.app/abcdef":     "https://haste.potat.app/raw/abcdef",
+		"https://haste.potat.app/raw/abcdef": "https://haste.potat.app/raw/abcdef",
+		"http://haste.potat.app/abcdef.json": "https://haste.potat.app/raw/abcdef.json",
+	}
+	for input, want := range accepted {
+		requested = nil
+		data, err := fs.Download(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, "{}", data, input)
+		assert.Equal(t, []string{want}, requested, input)
+	}
+
+	rejected := []string{
+		"http://169.254.169.254/latest/meta-data",
+		"https://localhost:8080/abcdef",
+		"https://haste.potat.app.evil.com/abcdef",
+		"https://user@haste.potat.app/abcdef",
+		"https://haste.potat.app:8443/abcdef",
+		"https://haste.potat.app/documents/../admin",
+		"https://haste.potat.app/abcdef?next=http://localhost",
+		"file:///etc/passwd",
+		"../etc/passwd",
+		"",
+	}
+	for _, input := range rejected {
+		requested = nil
+		_, err := fs.Download(input)
+		assert.ErrorIs(t, err, ports.ErrNotHaste, input)
+		assert.Empty(t, requested, input)
+	}
+}
+
+func TestDownload_NoRedirects(t *testing.T) {
+	var requested []string
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requested = append(requested, r.URL.String())
+		return &http.Response{
+			StatusCode: http.StatusFound,
+			Header:     http.Header{"Location": []string{"http://127.0.0.1/secret"}},
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    r,
+		}, nil
+	})}
+	fs := newTestFileServer(t, client)
+
+	_, err := fs.Download("abcdef")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"https://haste.potat.app/raw/abcdef"}, requested)
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"twitchspam/internal/app/ports"
+)
+
+// CurrentStatsHandler - GET /api/channels/:name/stats/current.
+func (h *Handlers) CurrentStatsHandler(c *gin.Context) {
+	st, ok := h.channelStream(c)
+	if !ok {
+		return
+	}
+
+	summary, ok := st.Stats().CurrentSummary()
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no stream stats"})
+		return
+	}
+
+	h.respond(c, summary, streamSummaryHeader, [][]string{streamSummaryRow(summary)})
+}
+
+// StatsHistoryHandler - GET /api/channels/:name/stats/history?from=2006-01-02&to=2006-01-02.
+// Обе даты включительно, по местному времени.
+func (h *Handlers) StatsHistoryHandler(c *gin.Context) {
+	st, ok := h.channelStream(c)
+	if !ok {
+		return
+	}
+
+	var from, to time.Time
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation(time.DateOnly, v, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation(time.DateOnly, v, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+
+	summaries := st.Stats().HistorySummaries(from, to)
+	rows := make([][]string, 0, len(summaries))
+	for _, s := range summaries {
+		rows = append(rows, streamSummaryRow(s))
+	}
+
+	h.respond(c, summaries, streamSummaryHeader, rows)
+}
+
+// ViewersHandler - GET /api/channels/:name/stats/viewers?stream=<id>|current.
+// Временной ряд онлайна стрима; метрики отдаются только в JSON.
+func (h *Handlers) ViewersHandler(c *gin.Context) {
+	st, ok := h.channelStream(c)
+	if !ok {
+		return
+	}
+
+	points, metrics, ok := st.Stats().ViewerSeries(c.DefaultQuery("stream", "current"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+		return
+	}
+
+	rows := make([][]string, 0, len(points))
+	for _, p := range points {
+		rows = append(rows, []string{formatTime(p.Time), itoa(p.Min), itoa(p.Max), fmt.Sprintf("%.0f", p.Avg), itoa(p.Samples)})
+	}
+
+	h.respond(c, gin.H{"points": points, "metrics": metrics}, []string{"time", "min", "max", "avg", "samples"}, rows)
+}
+
+// UserHandler - GET /api/channels/:name/users/:login.
+func (h *Handlers) UserHandler(c *gin.Context) {
+	st, ok := h.channelStream(c)
+	if !ok {
+		return
+	}
+
+	summary, ok := st.Stats().ChatterSummary(c.Param("login"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	p, m := summary.Punishments, summary.ModActions
+	h.respond(c, summary, []string{
+		"login", "stream_messages", "messages", "streams", "first_seen", "last_seen",
+		"punished_deletes", "punished_timeouts", "punished_warns", "punished_bans",
+		"mod_deletes", "mod_timeouts", "mod_warns", "mod_bans",
+	}, [][]string{{
+		summary.Login, itoa(summary.StreamMessages), itoa(summary.Messages), itoa(summary.Streams),
+		formatTime(summary.FirstSeen), formatTime(summary.LastSeen),
+		itoa(p.Deletes), itoa(p.Timeouts), itoa(p.Warns), itoa(p.Bans),
+		itoa(m.Deletes), itoa(m.Timeouts), itoa(m.Warns), itoa(m.Bans),
+	}})
+}
+
+// ActionsHandler - GET /api/channels/:name/actions?period=stream|<дни>d|all.
+// JSON содержит итоги по модераторам и отдельные действия, CSV - только отдельные действия.
+func (h *Handlers) ActionsHandler(c *gin.Context) {
+	st, ok := h.channelStream(c)
+	if !ok {
+		return
+	}
+
+	var days int
+	switch period := strings.ToLower(c.DefaultQuery("period", "stream")); {
+	case period == "stream":
+	case period == "all":
+		days = -1
+	case strings.HasSuffix(period, "d"):
+		n, err := strconv.Atoi(strings.TrimSuffix(period, "d"))
+		if err != nil || n < 1 || n > 366 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "period must be from 1d to 366d"})
+			return
+		}
+		days = n
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period must be stream, <days>d or all"})
+		return
+	}
+
+	log := st.Stats().ModerationLog(days)
+	rows := make([][]string, 0, len(log))
+	for _, a := range log {
+		rows = append(rows, []string{formatTime(a.Time), a.Moderator, a.Action, a.Target})
+	}
+
+	h.respond(c, gin.H{"moderators": st.Stats().ModeratorActions(days), "actions": log}, []string{"time", "moderator", "action", "target"}, rows)
+}
+
+func (h *Handlers) channelStream(c *gin.Context) (ports.StreamPort, bool) {
+	st, ok := h.streams[strings.ToLower(c.Param("name"))]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "channel not found"})
+		return nil, false
+	}
+	return st, true
+}
+
+// respond отдаёт data в JSON, а при ?format=csv или Accept: text/csv - таблицу header + rows.
+func (h *Handlers) respond(c *gin.Context, data any, header []string, rows [][]string) {
+	if c.Query("format") != "csv" && !strings.Contains(c.GetHeader("Accept"), "text/csv") {
+		c.JSON(http.StatusOK, data)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write(header); err != nil {
+		h.log.Error("Failed to write CSV response", err, slog.String("path", c.FullPath()))
+		return
+	}
+	if err := w.WriteAll(rows); err != nil {
+		h.log.Error("Failed to write CSV response", err, slog.String("path", c.FullPath()))
+	}
+}
+
+var streamSummaryHeader = []string{
+	"stream_id", "start", "end", "duration_sec", "max_viewers", "avg_viewers", "messages", "chatters",
+	"deletes", "timeouts", "warns", "bans", "categories",
+	"peak_time", "avg_first_hour", "avg_after_first_hour", "retention",
+}
+
+func streamSummaryRow(s ports.StreamSummary) []string {
+	categories := make([]string, 0, len(s.Categories))
+	for _, cat := range s.Categories {
+		categories = append(categories, cat.Name)
+	}
+
+	return []string{
+		s.StreamID, formatTime(s.Start), formatTime(s.End), strconv.FormatInt(s.Duration, 10),
+		itoa(s.MaxViewers), fmt.Sprintf("%.0f", s.AvgViewers), itoa(s.Messages), itoa(s.Chatters),
+		itoa(s.Actions.Deletes), itoa(s.Actions.Timeouts), itoa(s.Actions.Warns), itoa(s.Actions.Bans),
+		strings.Join(categories, "; "),
+		formatTime(s.Viewers.PeakTime), fmt.Sprintf("%.0f", s.Viewers.AvgFirstHour), fmt.Sprintf("%.0f", s.Viewers.AvgAfterFirstHour),
+		strconv.FormatFloat(s.Viewers.Retention, 'f', 2, 64),
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func itoa(n int) string {
+	return strconv.Itoa(n)
+}
@@ -96,9 +96,7 @@ func (h *Handlers) CallbackHandler(c *gin.Context) {
 		slog.String("email", user.Email),
 	)
 
-	if err := h.manager.Update(func(cfg *config.Config) {
-		cfg.UsersTokens[user.ID] = token
-	}); err != nil {
+	if err := h.manager.SetUserTokens(user.ID, token); err != nil {
 		h.log.Error("Failed to update user tokens in configuration", err,
 			slog.String("user_id", user.ID),
 			slog.Time("token_obtained_at", token.ObtainedAt),
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/ports"
 	"twitchspam/pkg/logger"
 )
 
@@ -15,10 +16,11 @@ type Handlers struct {
 	log     logger.Logger
 	manager *config.Manager
 	client  *http.Client
+	streams map[string]ports.StreamPort
 	state   string
 }
 
-func New(log logger.Logger, manager *config.Manager, client *http.Client) (*Handlers, error) {
+func New(log logger.Logger, manager *config.Manager, client *http.Client, streams map[string]ports.StreamPort) (*Handlers, error) {
 	s, err := generateSecureRandomString(52)
 	if err != nil {
 		log.Error("Failed to generate secure random string", err)
@@ -29,6 +31,7 @@ func New(log logger.Logger, manager *config.Manager, client *http.Client) (*Hand
 		log:     log,
 		manager: manager,
 		client:  client,
+		streams: streams,
 		state:   s,
 	}, nil
 }
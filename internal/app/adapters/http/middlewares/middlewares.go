@@ -1,7 +1,39 @@
 package middlewares
 
-type Middlewares struct{}
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"twitchspam/internal/app/infrastructure/config"
+)
 
-func New() *Middlewares {
-	return &Middlewares{}
+type Middlewares struct {
+	manager *config.Manager
+}
+
+func New(manager *config.Manager) *Middlewares {
+	return &Middlewares{manager: manager}
+}
+
+// APIAuth пропускает запросы с токеном приложения: "Authorization: Bearer <token>"
+// или basic auth admin:<token>, как у /metrics. Токен читается из текущего конфига.
+func (m *Middlewares) APIAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := m.manager.Get().App.AuthToken
+
+		var provided string
+		if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			provided = strings.TrimSpace(bearer)
+		} else if user, password, ok := c.Request.BasicAuth(); ok && user == "admin" {
+			provided = password
+		}
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Basic realm="Authorization Required"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.Next()
+	}
 }
@@ -8,6 +8,7 @@ import (
 	"twitchspam/internal/app/adapters/http/handlers"
 	"twitchspam/internal/app/adapters/http/middlewares"
 	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/ports"
 	"twitchspam/pkg/logger"
 )
 
@@ -20,8 +21,8 @@ type Router struct {
 	manager *config.Manager
 }
 
-func NewRouter(log logger.Logger, manager *config.Manager, client *http.Client) (*Router, error) {
-	h, err := handlers.New(log, manager, client)
+func NewRouter(log logger.Logger, manager *config.Manager, client *http.Client, streams map[string]ports.StreamPort) (*Router, error) {
+	h, err := handlers.New(log, manager, client, streams)
 	if err != nil {
 		return nil, err
 	}
@@ -29,7 +30,7 @@ func NewRouter(log logger.Logger, manager *config.Manager, client *http.Client)
 	r := &Router{
 		router:      gin.Default(),
 		handlers:    h,
-		middlewares: middlewares.New(),
+		middlewares: middlewares.New(manager),
 		log:         log,
 		manager:     manager,
 	}
@@ -44,10 +45,22 @@ func NewRouter(log logger.Logger, manager *config.Manager, client *http.Client)
 		"admin": cfg.App.AuthToken,
 	}), gin.WrapH(promhttp.Handler()))
 
+	api := r.router.Group("/api/channels/:name", r.middlewares.APIAuth())
+	api.GET("/stats/current", r.handlers.CurrentStatsHandler)
+	api.GET("/stats/history", r.handlers.StatsHistoryHandler)
+	api.GET("/stats/viewers", r.handlers.ViewersHandler)
+	api.GET("/users/:login", r.handlers.UserHandler)
+	api.GET("/actions", r.handlers.ActionsHandler)
+
 	r.router.GET("/", r.handlers.IndexHandler)
 	return r, nil
 }
 
+// Handler возвращает обработчик всех маршрутов, например для httptest.
+func (r *Router) Handler() http.Handler {
+	return r.router
+}
+
 func (r *Router) Run() error {
 	return r.router.Run(":80")
 }
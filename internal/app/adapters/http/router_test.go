@@ -0,0 +1,194 @@
+package http_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	apphttp "twitchspam/internal/app/adapters/http"
+	"twitchspam/internal/app/domain/stream"
+	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/infrastructure/storage"
+	"twitchspam/internal/app/ports"
+	"twitchspam/pkg/logger"
+)
+
+const testConfig = `{"app": {"oauth": "oauth-token", "client_id": "client-id", "username": "bot", "user_id": "1", "auth_token": "auth-token"}}`
+
+// newTestRouter создаёт роутер с одним каналом test, в котором идёт стрим с действиями модераторов.
+func newTestRouter(t *testing.T) http.Handler {
+	t.Chdir(t.TempDir())
+	gin.SetMode(gin.TestMode)
+
+	require.NoError(t, os.MkdirAll("configs", 0750))
+	require.NoError(t, os.WriteFile(filepath.Join("configs", "config.json"), []byte(testConfig), 0644))
+
+	m, err := config.New()
+	require.NoError(t, err)
+
+	st := stream.NewStream("test", nil,
+		storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0),
+		storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0),
+		storage.NewCache[map[string]*stream.ChatterStats](0, 0, false, false, "", 0),
+	)
+	stats := st.Stats()
+	stats.Reset()
+	stats.SetStartTime(time.Now().Add(-time.Hour))
+	stats.SetStreamID("42")
+	stats.AddMessage("user1")
+	stats.AddBan("mod1")
+	stats.AddPunishment("mod1", "spammer", "ban")
+	stats.AddTimeout("bot")
+	stats.AddPunishment("bot", "user1", "timeout")
+
+	r, err := apphttp.NewRouter(logger.New(), m, nil, map[string]ports.StreamPort{"test": st})
+	require.NoError(t, err)
+	return r.Handler()
+}
+
+func get(h http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func TestAPI_Auth(t *testing.T) {
+	h := newTestRouter(t)
+
+	basic := func(user, password string) http.Header {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(user, password)
+		return req.Header
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		status int
+	}{
+		{name: "no token", status: http.StatusUnauthorized},
+		{name: "wrong bearer", header: bearer("wrong"), status: http.StatusUnauthorized},
+		{name: "wrong basic user", header: basic("user", "auth-token"), status: http.StatusUnauthorized},
+		{name: "wrong basic password", header: basic("admin", "wrong"), status: http.StatusUnauthorized},
+		{name: "bearer", header: bearer("auth-token"), status: http.StatusOK},
+		{name: "basic", header: basic("admin", "auth-token"), status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(h, "/api/channels/test/stats/current", tt.header)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusUnauthorized {
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+				assert.JSONEq(t, `{"error": "unauthorized"}`, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPI_Format(t *testing.T) {
+	h := newTestRouter(t)
+
+	w := get(h, "/api/channels/test/stats/current", bearer("auth-token"))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+	var summary ports.StreamSummary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, "42", summary.StreamID)
+	assert.Equal(t, 1, summary.Messages)
+
+	// CSV выбирается параметром format или заголовком Accept
+	accept := bearer("auth-token")
+	accept.Set("Accept", "text/csv")
+	for _, w := range []*httptest.ResponseRecorder{
+		get(h, "/api/channels/test/stats/current?format=csv", bearer("auth-token")),
+		get(h, "/api/channels/test/stats/current", accept),
+	} {
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, []string{"stream_id", "start", "end"}, records[0][:3])
+		assert.Equal(t, "42", records[1][0])
+	}
+}
+
+func TestAPI_Actions(t *testing.T) {
+	h := newTestRouter(t)
+
+	w := get(h, "/api/channels/test/actions", bearer("auth-token"))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Moderators []ports.ModeratorActions `json:"moderators"`
+		Actions    []ports.ModerationAction `json:"actions"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Moderators, 2)
+	assert.Equal(t, "bot", resp.Moderators[0].Login)
+	assert.Equal(t, 1, resp.Moderators[0].Actions.Timeouts)
+
+	// отдельные действия: кто, что, над кем и когда
+	require.Len(t, resp.Actions, 2)
+	assert.Equal(t, "mod1", resp.Actions[0].Moderator)
+	assert.Equal(t, "ban", resp.Actions[0].Action)
+	assert.Equal(t, "spammer", resp.Actions[0].Target)
+	assert.False(t, resp.Actions[0].Time.IsZero())
+
+	w = get(h, "/api/channels/test/actions?period=7d&format=csv", bearer("auth-token"))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"time", "moderator", "action", "target"}, records[0])
+	assert.Equal(t, []string{"bot", "timeout", "user1"}, records[2][1:])
+}
+
+func TestAPI_BadRequest(t *testing.T) {
+	h := newTestRouter(t)
+
+	tests := []struct {
+		target string
+		status int
+		error  string
+	}{
+		{target: "/api/channels/test/stats/history?from=2025-13-01", status: http.StatusBadRequest, error: "invalid from date"},
+		{target: "/api/channels/test/stats/history?to=yesterday", status: http.StatusBadRequest, error: "invalid to date"},
+		{target: "/api/channels/test/actions?period=week", status: http.StatusBadRequest, error: "period must be stream"},
+		{target: "/api/channels/test/actions?period=0d", status: http.StatusBadRequest, error: "period must be from 1d"},
+		{target: "/api/channels/test/actions?period=400d", status: http.StatusBadRequest, error: "period must be from 1d"},
+		{target: "/api/channels/test/actions?period=xd", status: http.StatusBadRequest, error: "period must be from 1d"},
+		{target: "/api/channels/other/actions", status: http.StatusNotFound, error: "channel not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := get(h, tt.target, bearer("auth-token"))
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Body.String(), tt.error)
+		})
+	}
+
+	// корректный период истории
+	assert.Equal(t, http.StatusOK, get(h, "/api/channels/test/stats/history?from=2025-01-01&to=2025-01-31", bearer("auth-token")).Code)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 	"twitchspam/internal/app/domain/message"
@@ -110,19 +111,23 @@ var (
 )
 
 type Admin struct {
-	log      logger.Logger
-	manager  *config.Manager
-	stream   ports.StreamPort
-	trusts   ports.TrustsPort
-	fs       ports.FileServerPort
-	api      ports.APIPort
-	template ports.TemplatePort
-	timers   ports.TimersPort
-	messages ports.StorePort[storage.Message]
+	log        logger.Logger
+	manager    *config.Manager
+	stream     ports.StreamPort
+	trusts     ports.TrustsPort
+	fs         ports.FileServerPort
+	api        ports.APIPort
+	template   ports.TemplatePort
+	timers     ports.TimersPort
+	messages   ports.StorePort[storage.Message]
+	categories ports.CachePort[Category]
+	chatLog    ports.ChatLogPort
 
 	poll        *ports.Poll
 	predictions *ports.Predictions
 
+	nukeReporter *NukeReporter
+
 	root ports.Command
 }
 
@@ -132,7 +137,7 @@ type CompositeCommand struct {
 	cursor      int
 }
 
-func New(log logger.Logger, manager *config.Manager, stream ports.StreamPort, trusts ports.TrustsPort, api ports.APIPort, template ports.TemplatePort, fs ports.FileServerPort, timers ports.TimersPort, messages ports.StorePort[storage.Message]) *Admin {
+func New(log logger.Logger, manager *config.Manager, stream ports.StreamPort, trusts ports.TrustsPort, api ports.APIPort, template ports.TemplatePort, fs ports.FileServerPort, timers ports.TimersPort, messages ports.StorePort[storage.Message], cacheCategories ports.CachePort[Category], chatLog ports.ChatLogPort) *Admin {
 	a := &Admin{
 		log:         log,
 		manager:     manager,
@@ -143,9 +148,13 @@ func New(log logger.Logger, manager *config.Manager, stream ports.StreamPort, tr
 		fs:          fs,
 		timers:      timers,
 		messages:    messages,
+		categories:  cacheCategories,
+		chatLog:     chatLog,
 		poll:        &ports.Poll{},
 		predictions: &ports.Predictions{},
 	}
+	a.nukeReporter = &NukeReporter{log: log, api: api, stream: stream, fs: fs}
+	a.template.Nuke().OnFinish(a.nukeReporter.Finish)
 	a.root = a.buildCommandTree()
 
 	return a
@@ -181,12 +190,12 @@ func (a *Admin) FindMessages(msg *message.ChatMessage) *ports.AnswerType {
 		return notFoundCmd
 	}
 
-	if !a.checkPermissions(msg.Chatter.UserID, strings.ToLower(words[1]), msg.Chatter.IsMod, msg.Chatter.IsBroadcaster) {
+	if !a.checkPermissions(msg.Chatter, strings.ToLower(words[1])) {
 		return &ports.AnswerType{Text: []string{"у вас нет прав на выполнение этой команды!"}, IsReply: true}
 	}
 
 	var result *ports.AnswerType
-	if err := a.manager.Update(func(cfg *config.Config) {
+	if err := a.manager.UpdateBy(msg.Broadcaster.Login, msg.Chatter.Username, msg.Message.Text.Text(), func(cfg *config.Config) {
 		a.log.Info("Admin command executed",
 			slog.String("user", msg.Chatter.Username),
 			slog.String("message", msg.Message.Text.Text()),
@@ -197,7 +206,17 @@ func (a *Admin) FindMessages(msg *message.ChatMessage) *ports.AnswerType {
 		return unknownError
 	}
 
-	return result
+	if result == nil {
+		return nil
+	}
+
+	// ответы могут быть общими переменными, поэтому не изменяем их на месте
+	redacted := *result
+	redacted.Text = make([]string, len(result.Text))
+	for i, text := range result.Text {
+		redacted.Text[i] = a.manager.Redact(text)
+	}
+	return &redacted
 }
 
 func (c *CompositeCommand) Execute(cfg *config.Config, channel string, msg *message.ChatMessage) *ports.AnswerType {
@@ -222,15 +241,19 @@ func (c *CompositeCommand) Execute(cfg *config.Config, channel string, msg *mess
 
 func (a *Admin) buildCommandTree() ports.Command {
 	timer := &AddTimer{
-		Cfg:    a.manager.Get(),
-		Timers: a.timers,
-		Stream: a.stream,
-		Api:    a.api,
+		Manager: a.manager,
+		Timers:  a.timers,
+		Stream:  a.stream,
+		Api:     a.api,
 	}
 
+	nuke := &Nuke{re: regexp.MustCompile(`(?i)^!am nuke(?:\s+#(\S+))?(?:\s+(\S+))?(?:\s+(\S+))?(?:\s+(\S+))?\s+(.+)$`),
+		reWords: regexp.MustCompile(`(?i)r'(.*?)'|r"(.*?)"|'(.*?)'|"(.*?)"|([^,'"\s]+)`),
+		log:     a.log, api: a.api, template: a.template, stream: a.stream, messages: a.messages}
+
 	return &CompositeCommand{
 		subcommands: map[string]ports.Command{
-			"auth": &Auth{log: a.log, stream: a.stream, api: a.api},
+			"auth": &Auth{log: a.log, manager: a.manager, stream: a.stream, api: a.api},
 			"ping": &Ping{},
 			"main": &CompositeCommand{
 				subcommands: map[string]ports.Command{
@@ -243,7 +266,31 @@ func (a *Admin) buildCommandTree() ports.Command {
 			"say":    &Say{re: regexp.MustCompile(`(?i)^!am\s+say\s+(.+)$`)},
 			"spam":   &Spam{re: regexp.MustCompile(`(?i)^!am\s+spam\s+(.+)\s+(.+)$`)},
 			"reset":  &Reset{manager: a.manager},
-			"game":   &Game{re: regexp.MustCompile(`(?i)^!am\s+game\s+(.+)$`), stream: a.stream},
+			"config": &CompositeCommand{
+				subcommands: map[string]ports.Command{
+					"history":  &ConfigHistory{manager: a.manager, fs: a.fs},
+					"rollback": &ConfigRollback{re: regexp.MustCompile(`(?i)^!am\s+config\s+rollback\s+(\d+)$`), log: a.log, manager: a.manager, template: a.template, trusts: a.trusts, timer: timer},
+				},
+				cursor: 2,
+			},
+			"copy": &Copy{re: regexp.MustCompile(`(?i)^!am\s+copy\s+(\S+)\s+from\s+(\S+)(?:\s+(merge|replace))?$`), log: a.log, manager: a.manager, fs: a.fs, template: a.template, trusts: a.trusts, timer: timer},
+			"profile": &CompositeCommand{
+				subcommands: map[string]ports.Command{
+					"list": &ProfileList{},
+					"use":  &ProfileUse{re: regexp.MustCompile(`(?i)^!am\s+profile\s+use\s+(\S+)$`), template: a.template, trusts: a.trusts, timer: timer},
+					"save": &ProfileSave{re: regexp.MustCompile(`(?i)^!am\s+profile\s+save\s+(\S+)$`)},
+					"del":  &ProfileDel{re: regexp.MustCompile(`(?i)^!am\s+profile\s+del\s+(\S+)$`)},
+					"auto": &ProfileAuto{re: regexp.MustCompile(`(?i)^!am\s+profile\s+auto\s+(online|offline|cat)\s+(\S+)(?:\s+(.+))?$`)},
+				},
+				defaultCmd: &ProfileList{},
+				cursor:     2,
+			},
+			"modstats": &ModStats{re: regexp.MustCompile(`(?i)^!am\s+modstats(?:\s+(\S+))?$`), stream: a.stream},
+			"logs":     &ChatLogs{re: regexp.MustCompile(`(?i)^!am\s+logs\s+(\S+)(?:\s+(\d+))?$`), log: a.log, chatLog: a.chatLog, fs: a.fs},
+			"grep":     &ChatGrep{re: regexp.MustCompile(`(?i)^!am\s+grep\s+(.+)$`), log: a.log, chatLog: a.chatLog, fs: a.fs},
+			"export":   &Export{re: regexp.MustCompile(`(?i)^!am\s+export(?:\s+(\S+))?$`), fs: a.fs},
+			"import":   &Import{re: regexp.MustCompile(`(?i)^!am\s+import\s+(\S+)(?:\s+(\S+))?(?:\s+(merge|replace))?$`), log: a.log, manager: a.manager, fs: a.fs, template: a.template, trusts: a.trusts, timer: timer},
+			"game":     &Game{re: regexp.MustCompile(`(?i)^!am\s+game\s+(.+)$`), stream: a.stream},
 			"as": &CompositeCommand{
 				subcommands: map[string]ports.Command{
 					"on":      &OnOffAntispam{enabled: true, typeSpam: "default", template: a.template},
@@ -265,13 +312,15 @@ func (a *Admin) buildCommandTree() ports.Command {
 			"mg":   &MinGapAntispam{re: regexp.MustCompile(`(?i)^!am\s+mg\s+(.+)$`), template: a.template, messages: a.messages, typeSpam: "default"},
 			"nuke": &CompositeCommand{
 				subcommands: map[string]ports.Command{
-					"stop": &NukeStop{template: a.template},
-					"re":   &ReNuke{template: a.template},
+					"stop":   &NukeStop{re: regexp.MustCompile(`(?i)^!am\s+nuke\s+stop(?:\s+(\S+))?$`), template: a.template},
+					"re":     &ReNuke{template: a.template},
+					"list":   &NukeList{template: a.template},
+					"undo":   &NukeUndo{re: regexp.MustCompile(`(?i)^!am\s+nuke\s+undo(?:\s+(.+))?$`), log: a.log, api: a.api, template: a.template, stream: a.stream},
+					"report": &NukeReport{re: regexp.MustCompile(`(?i)^!am\s+nuke\s+report(?:\s+(\S+))?$`), template: a.template, reporter: a.nukeReporter},
+					"like":   &NukeLike{re: regexp.MustCompile(`(?i)^!am\s+nuke\s+like(?:\s+#(\S+))?(?:\s+(\S+))?(?:\s+(\S+))?(?:\s+(\S+))?$`), nuke: nuke},
 				},
-				defaultCmd: &Nuke{re: regexp.MustCompile(`(?i)^!am nuke(?:\s+(\S+))?(?:\s+(\S+))?(?:\s+(\S+))?\s+(.+)$`),
-					reWords: regexp.MustCompile(`(?i)r'(.*?)'|r"(.*?)"|'(.*?)'|"(.*?)"|([^,'"\s]+)`),
-					log:     a.log, api: a.api, template: a.template, stream: a.stream, messages: a.messages},
-				cursor: 2,
+				defaultCmd: nuke,
+				cursor:     2,
 			},
 			"mod": &CompositeCommand{
 				subcommands: map[string]ports.Command{
@@ -416,7 +465,7 @@ func (a *Admin) buildCommandTree() ports.Command {
 				cursor: 2,
 			},
 			"cat": &SetCategory{re: regexp.MustCompile(`(?i)^!am\s+cat\s*(.*)$`), log: a.log, stream: a.stream, api: a.api,
-				cacheCategories: storage.NewCache[Category](0, 0, true, true, "cache/categories.json", 0)},
+				cacheCategories: a.categories},
 			"title": &SetTitle{re: regexp.MustCompile(`(?i)^!am\s+title\s+(.+)$`), log: a.log, stream: a.stream, api: a.api},
 			"pred": &CompositeCommand{
 				subcommands: map[string]ports.Command{
@@ -466,20 +515,25 @@ func (a *Admin) buildCommandTree() ports.Command {
 	}
 }
 
-func (a *Admin) checkPermissions(user string, cmd string, isMod, isBroadcaster bool) bool {
-	if isMod || isBroadcaster {
+func (a *Admin) checkPermissions(chatter message.Chatter, cmd string) bool {
+	// копирование затрагивает настройки другого канала, поэтому модераторам недоступно
+	if cmd == "copy" {
+		return chatter.IsBroadcaster || slices.Contains(a.manager.Get().App.Admins, strings.ToLower(chatter.Login))
+	}
+
+	if chatter.IsMod || chatter.IsBroadcaster {
 		return true
 	}
 
 	switch cmd {
 	case "nuke":
-		return a.trusts.HasScope(user, trusts.ScopeNuke)
+		return a.trusts.HasScope(chatter.UserID, trusts.ScopeNuke)
 	case "poll":
-		return a.trusts.HasScope(user, trusts.ScopePolls)
+		return a.trusts.HasScope(chatter.UserID, trusts.ScopePolls)
 	case "pred":
-		return a.trusts.HasScope(user, trusts.ScopePredictions)
+		return a.trusts.HasScope(chatter.UserID, trusts.ScopePredictions)
 	case "ban", "unban", "warn", "unwarn", "timeout", "untimeout":
-		return a.trusts.HasScope(user, trusts.ScopeModActions)
+		return a.trusts.HasScope(chatter.UserID, trusts.ScopeModActions)
 	default:
 		return false
 	}
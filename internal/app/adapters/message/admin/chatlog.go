@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"twitchspam/internal/app/domain/message"
+	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/ports"
+	"twitchspam/pkg/logger"
+)
+
+const (
+	chatLogDefault  = 50
+	chatLogMaxLines = 1000
+	chatGrepLimit   = 500
+)
+
+type ChatLogs struct {
+	re      *regexp.Regexp
+	log     logger.Logger
+	chatLog ports.ChatLogPort
+	fs      ports.FileServerPort
+}
+
+func (c *ChatLogs) Execute(_ *config.Config, _ string, msg *message.ChatMessage) *ports.AnswerType {
+	matches := c.re.FindStringSubmatch(msg.Message.Text.Text()) // !am logs <юзер> [кол-во]
+	if len(matches) != 3 {
+		return nonParametr
+	}
+
+	n := chatLogDefault
+	if strings.TrimSpace(matches[2]) != "" {
+		val, err := strconv.Atoi(strings.TrimSpace(matches[2]))
+		if err != nil || val < 1 || val > chatLogMaxLines {
+			return &ports.AnswerType{
+				Text:    []string{fmt.Sprintf("количество сообщений должно быть от 1 до %d!", chatLogMaxLines)},
+				IsReply: true,
+			}
+		}
+		n = val
+	}
+
+	entries, err := c.chatLog.User(strings.TrimSpace(matches[1]), n)
+	if err != nil {
+		c.log.Error("Failed to read chat log", err, slog.String("user", matches[1]))
+		return unknownError
+	}
+	return uploadChatLog(c.log, c.fs, entries)
+}
+
+type ChatGrep struct {
+	re      *regexp.Regexp
+	log     logger.Logger
+	chatLog ports.ChatLogPort
+	fs      ports.FileServerPort
+}
+
+func (c *ChatGrep) Execute(_ *config.Config, _ string, msg *message.ChatMessage) *ports.AnswerType {
+	matches := c.re.FindStringSubmatch(msg.Message.Text.Text()) // !am grep <текст>
+	if len(matches) != 2 || strings.TrimSpace(matches[1]) == "" {
+		return nonParametr
+	}
+
+	entries, err := c.chatLog.Grep(matches[1], chatGrepLimit)
+	if err != nil {
+		c.log.Error("Failed to search chat log", err, slog.String("query", matches[1]))
+		return unknownError
+	}
+	return uploadChatLog(c.log, c.fs, entries)
+}
+
+// uploadChatLog выгружает найденные сообщения на haste в хронологическом порядке.
+func uploadChatLog(log logger.Logger, fs ports.FileServerPort, entries []ports.ChatLogEntry) *ports.AnswerType {
+	if len(entries) == 0 {
+		return &ports.AnswerType{
+			Text:    []string{"сообщения не найдены!"},
+			IsReply: true,
+		}
+	}
+
+	var sb strings.Builder
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		fmt.Fprintf(&sb, "%s %s: %s\n", e.Time.In(time.Local).Format(time.DateTime), e.Username, e.Text)
+	}
+
+	key, err := fs.UploadToHaste(sb.String())
+	if err != nil {
+		log.Error("Failed to upload chat log", err)
+		return unknownError
+	}
+
+	return &ports.AnswerType{
+		Text:    []string{fs.GetURL(key)},
+		IsReply: true,
+	}
+}
@@ -221,21 +221,35 @@ func (c *SetCommandTimer) Execute(cfg *config.Config, channel string, msg *messa
 }
 
 type AddTimer struct {
-	Cfg    *config.Config
-	Timers ports.TimersPort
-	Stream ports.StreamPort
-	Api    ports.APIPort
+	Manager *config.Manager
+	Timers  ports.TimersPort
+	Stream  ports.StreamPort
+	Api     ports.APIPort
 }
 
+// StartAll запускает таймеры всех команд, у которых они настроены.
+func (a *AddTimer) StartAll(commands map[string]*config.Commands) {
+	for cmd, data := range commands {
+		if data.Timer == nil {
+			continue
+		}
+
+		a.AddTimer(cmd, data)
+	}
+}
+
+// AddTimer запускает таймер команды; при срабатывании команда берётся из текущего конфига,
+// поэтому изменения текста и настроек таймера применяются без перезапуска.
 func (a *AddTimer) AddTimer(key string, cmd *config.Commands) {
 	a.Timers.AddTimer(key, cmd.Timer.Interval, true, map[string]any{
-		"text":  cmd.Text,
-		"timer": cmd.Timer,
+		"command": key,
 	}, func(args map[string]any) {
-		timer := args["timer"].(*config.Timers)
-		if !timer.Enabled {
+		cfg := a.Manager.Get()
+		cmd, ok := cfg.Channels[a.Stream.ChannelName()].Commands[args["command"].(string)]
+		if !ok || cmd.Timer == nil || !cmd.Timer.Enabled {
 			return
 		}
+		timer := cmd.Timer
 
 		mode := config.OnlineMode
 		if timer.Options != nil && timer.Options.Mode != nil {
@@ -248,10 +262,10 @@ func (a *AddTimer) AddTimer(key string, cmd *config.Commands) {
 
 		msg := &ports.AnswerType{}
 		for range timer.Count {
-			msg.Text = append(msg.Text, args["text"].(string))
+			msg.Text = append(msg.Text, cmd.Text)
 		}
 
-		if _, ok := a.Cfg.UsersTokens[a.Stream.ChannelID()]; ok && timer.Options != nil && timer.Options.IsAnnounce != nil && *timer.Options.IsAnnounce {
+		if _, ok := cfg.UsersTokens[a.Stream.ChannelID()]; ok && timer.Options != nil && timer.Options.IsAnnounce != nil && *timer.Options.IsAnnounce {
 			a.Api.SendChatAnnouncements(a.Stream.ChannelID(), msg, *timer.Options.ColorAnnounce)
 			return
 		}
@@ -0,0 +1,113 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"twitchspam/internal/app/domain/message"
+	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/ports"
+	"twitchspam/pkg/logger"
+)
+
+// ApplyConfig переносит конфиг канала в шаблоны, права и таймеры.
+func ApplyConfig(cfg *config.Config, template ports.TemplatePort, trusts ports.TrustsPort, timer *AddTimer) {
+	channel := cfg.Channels[timer.Stream.ChannelName()]
+
+	template.Aliases().Update(channel.Aliases, channel.AliasGroups, cfg.GlobalAliases)
+	template.Mword().Update(channel.Mword, channel.MwordGroup)
+	template.Banwords().Update(cfg.Banwords)
+	trusts.Reset(channel.Roles, cfg.GlobalRoles, channel.Trusts)
+
+	timer.Timers.RemoveAll()
+	timer.StartAll(channel.Commands)
+}
+
+type ConfigHistory struct {
+	manager *config.Manager
+	fs      ports.FileServerPort
+}
+
+func (c *ConfigHistory) Execute(_ *config.Config, channel string, _ *message.ChatMessage) *ports.AnswerType {
+	// !am config history
+	snapshots, err := c.manager.History(channel)
+	if err != nil {
+		return unknownError
+	}
+
+	if len(snapshots) == 0 {
+		return &ports.AnswerType{
+			Text:    []string{"история изменений пуста!"},
+			IsReply: true,
+		}
+	}
+
+	var sb strings.Builder
+	for i, s := range snapshots {
+		author := s.Author
+		if author == "" {
+			author = "-"
+		}
+
+		fmt.Fprintf(&sb, "#%d [%s] %s: %s\n", i+1, s.Time.Format(time.DateTime), author, s.Command)
+		for _, line := range s.Diff {
+			fmt.Fprintf(&sb, "  %s\n", line)
+		}
+		sb.WriteString("\n")
+	}
+
+	key, err := c.fs.UploadToHaste(sb.String())
+	if err != nil {
+		return unknownError
+	}
+
+	return &ports.AnswerType{
+		Text:    []string{c.fs.GetURL(key)},
+		IsReply: true,
+	}
+}
+
+type ConfigRollback struct {
+	re       *regexp.Regexp
+	log      logger.Logger
+	manager  *config.Manager
+	template ports.TemplatePort
+	trusts   ports.TrustsPort
+	timer    *AddTimer
+}
+
+func (c *ConfigRollback) Execute(cfg *config.Config, channel string, msg *message.ChatMessage) *ports.AnswerType {
+	matches := c.re.FindStringSubmatch(msg.Message.Text.Text()) // !am config rollback <номер версии>
+	if len(matches) != 2 {
+		return nonParametr
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(matches[1]))
+	if err != nil || n < 1 {
+		return nonParametr
+	}
+
+	snapshot, err := c.manager.Snapshot(channel, n)
+	if err != nil {
+		return &ports.AnswerType{
+			Text:    []string{"версия не найдена!"},
+			IsReply: true,
+		}
+	}
+
+	// идентификатор и имя канала не откатываются
+	current := cfg.Channels[channel]
+	snapshot.ID, snapshot.Name = current.ID, current.Name
+	*current = *snapshot
+
+	ApplyConfig(cfg, c.template, c.trusts, c.timer)
+	c.log.Info("Config rolled back", slog.Int("version", n), slog.String("user", msg.Chatter.Username))
+
+	return &ports.AnswerType{
+		Text:    []string{fmt.Sprintf("конфиг откачен к версии #%d!", n)},
+		IsReply: true,
+	}
+}
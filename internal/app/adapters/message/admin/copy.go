@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"twitchspam/internal/app/domain/message"
+	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/ports"
+	"twitchspam/pkg/logger"
+)
+
+type Copy struct {
+	re       *regexp.Regexp
+	log      logger.Logger
+	manager  *config.Manager
+	fs       ports.FileServerPort
+	template ports.TemplatePort
+	trusts   ports.TrustsPort
+	timer    *AddTimer
+}
+
+func (c *Copy) Execute(cfg *config.Config, channel string, msg *message.ChatMessage) *ports.AnswerType {
+	matches := c.re.FindStringSubmatch(msg.Message.Text.Text()) // !am copy <раздел> from <канал> <merge/replace?>
+	if len(matches) != 4 {
+		return nonParametr
+	}
+
+	sections, err := config.ParseSections(matches[1])
+	if err != nil {
+		return unknownSection
+	}
+	merge := !strings.EqualFold(matches[3], "replace")
+
+	from := strings.ToLower(strings.TrimPrefix(matches[2], "@"))
+	src, ok := cfg.Channels[from]
+	if !ok || from == channel {
+		return &ports.AnswerType{
+			Text:    []string{"канал не найден среди каналов бота!"},
+			IsReply: true,
+		}
+	}
+
+	// копируем через бандл, чтобы каналы не делили между собой наказания, команды и группы
+	data, err := config.ExportBundle(src, sections)
+	if err != nil {
+		return unknownError
+	}
+
+	bundle, err := config.ParseBundle(data)
+	if err != nil {
+		return unknownError
+	}
+
+	ch, diff, counts, answer := previewBundle(c.manager, cfg, channel, bundle, sections, merge)
+	if answer != nil {
+		return answer
+	}
+	*cfg.Channels[channel] = *ch
+	ApplyConfig(cfg, c.template, c.trusts, c.timer)
+
+	c.log.Info("Config copied",
+		slog.String("from", from),
+		slog.String("to", channel),
+		slog.String("mode", bundleMode(merge)),
+		slog.Int("added", counts.Added),
+		slog.Int("overwritten", counts.Overwritten),
+		slog.Int("removed", counts.Removed),
+	)
+	return &ports.AnswerType{
+		Text:    []string{fmt.Sprintf("скопировано из %s (%s) • %s", from, bundleMode(merge), describeDiff(c.fs, diff, counts))},
+		IsReply: true,
+	}
+}
@@ -0,0 +1,243 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"twitchspam/internal/app/domain/message"
+	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/ports"
+	"twitchspam/pkg/logger"
+)
+
+var unknownSection = &ports.AnswerType{
+	Text:    []string{fmt.Sprintf("неизвестный раздел! доступные: %s, all", strings.Join(config.BundleSections, ", "))},
+	IsReply: true,
+}
+
+type Export struct {
+	re *regexp.Regexp
+	fs ports.FileServerPort
+}
+
+func (e *Export) Execute(cfg *config.Config, channel string, msg *message.ChatMessage) *ports.AnswerType {
+	matches := e.re.FindStringSubmatch(msg.Message.Text.Text()) // !am export <раздел?>
+	if len(matches) != 2 {
+		return nonParametr
+	}
+
+	sections, err := config.ParseSections(matches[1])
+	if err != nil {
+		return unknownSection
+	}
+
+	data, err := config.ExportBundle(cfg.Channels[channel], sections)
+	if err != nil {
+		return unknownError
+	}
+
+	key, err := e.fs.UploadToHaste(string(data))
+	if err != nil {
+		return unknownError
+	}
+
+	return &ports.AnswerType{
+		Text:    []string{e.fs.GetURL(key)},
+		IsReply: true,
+	}
+}
+
+// importConfirmTTL - сколько ждёт подтверждения загруженный, но ещё не применённый импорт.
+const importConfirmTTL = 2 * time.Minute
+
+type Import struct {
+	re       *regexp.Regexp
+	log      logger.Logger
+	manager  *config.Manager
+	fs       ports.FileServerPort
+	template ports.TemplatePort
+	trusts   ports.TrustsPort
+	timer    *AddTimer
+
+	mu      sync.Mutex
+	pending map[string]*pendingImport // ключ - канал
+}
+
+// pendingImport - импорт, который показан модератору и ждёт !am import confirm.
+type pendingImport struct {
+	author   string
+	bundle   *config.Bundle
+	sections []string
+	merge    bool
+	expires  time.Time
+}
+
+func (i *Import) Execute(cfg *config.Config, channel string, msg *message.ChatMessage) *ports.AnswerType {
+	matches := i.re.FindStringSubmatch(msg.Message.Text.Text()) // !am import <ссылка или ключ> <раздел?> <merge/replace?> или !am import confirm
+	if len(matches) != 4 {
+		return nonParametr
+	}
+
+	if strings.EqualFold(matches[1], "confirm") && matches[2] == "" && matches[3] == "" {
+		return i.confirm(cfg, channel, msg)
+	}
+
+	section, mode := matches[2], matches[3]
+	if mode == "" && (strings.EqualFold(section, "merge") || strings.EqualFold(section, "replace")) {
+		section, mode = "", section
+	}
+
+	sections, err := config.ParseSections(section)
+	if err != nil {
+		return unknownSection
+	}
+	merge := !strings.EqualFold(mode, "replace")
+
+	raw, err := i.fs.Download(matches[1])
+	if err != nil {
+		text := "не удалось загрузить файл!"
+		if errors.Is(err, ports.ErrNotHaste) {
+			text = "укажите ключ или ссылку на haste.potat.app!"
+		}
+		return &ports.AnswerType{
+			Text:    []string{text},
+			IsReply: true,
+		}
+	}
+
+	bundle, err := config.ParseBundle([]byte(raw))
+	if err != nil {
+		i.log.Warn("Invalid import bundle", slog.String("error", err.Error()))
+		return &ports.AnswerType{
+			Text:    []string{"неверный формат файла!"},
+			IsReply: true,
+		}
+	}
+
+	// бандл только проверяется на копии канала, конфиг меняется после подтверждения
+	_, diff, counts, answer := previewBundle(i.manager, cfg, channel, bundle, sections, merge)
+	if answer != nil {
+		return answer
+	}
+
+	i.mu.Lock()
+	if i.pending == nil {
+		i.pending = make(map[string]*pendingImport)
+	}
+	i.pending[channel] = &pendingImport{
+		author:   msg.Chatter.Login,
+		bundle:   bundle,
+		sections: sections,
+		merge:    merge,
+		expires:  time.Now().Add(importConfirmTTL),
+	}
+	i.mu.Unlock()
+
+	return &ports.AnswerType{
+		Text: []string{fmt.Sprintf("импорт из %s (%s) • %s • для применения в течение %d мин.: !am import confirm",
+			bundle.Channel, bundleMode(merge), describeDiff(i.fs, diff, counts), int(importConfirmTTL.Minutes()))},
+		IsReply: true,
+	}
+}
+
+// confirm применяет импорт, загруженный тем же пользователем. Изменения пересчитываются
+// относительно текущего конфига, так как он мог измениться после предпросмотра.
+func (i *Import) confirm(cfg *config.Config, channel string, msg *message.ChatMessage) *ports.AnswerType {
+	i.mu.Lock()
+	p, ok := i.pending[channel]
+	if ok && p.author == msg.Chatter.Login {
+		delete(i.pending, channel)
+	}
+	i.mu.Unlock()
+
+	if !ok || p.author != msg.Chatter.Login || time.Now().After(p.expires) {
+		return &ports.AnswerType{
+			Text:    []string{"нет импорта, ожидающего подтверждения!"},
+			IsReply: true,
+		}
+	}
+
+	ch, diff, counts, answer := previewBundle(i.manager, cfg, channel, p.bundle, p.sections, p.merge)
+	if answer != nil {
+		return answer
+	}
+	*cfg.Channels[channel] = *ch
+	ApplyConfig(cfg, i.template, i.trusts, i.timer)
+
+	i.log.Info("Config imported",
+		slog.String("from", p.bundle.Channel),
+		slog.String("mode", bundleMode(p.merge)),
+		slog.Int("added", counts.Added),
+		slog.Int("overwritten", counts.Overwritten),
+		slog.Int("removed", counts.Removed),
+	)
+	return &ports.AnswerType{
+		Text:    []string{fmt.Sprintf("импортировано из %s (%s) • %s", p.bundle.Channel, bundleMode(p.merge), describeDiff(i.fs, diff, counts))},
+		IsReply: true,
+	}
+}
+
+// previewBundle применяет бандл к копии канала и возвращает её вместе со списком отличий от текущего канала
+// и количеством изменённых элементов.
+func previewBundle(manager *config.Manager, cfg *config.Config, channel string, bundle *config.Bundle, sections []string, merge bool) (*config.Channel, []string, config.ChangeCounts, *ports.AnswerType) {
+	ch, err := config.CloneChannel(cfg.Channels[channel])
+	if err != nil {
+		return nil, nil, config.ChangeCounts{}, unknownError
+	}
+
+	if summary := config.ApplyBundle(ch, bundle, sections, merge); len(summary) == 0 {
+		return nil, nil, config.ChangeCounts{}, &ports.AnswerType{
+			Text:    []string{"нет данных в выбранных разделах!"},
+			IsReply: true,
+		}
+	}
+
+	if err := manager.ValidateChannel(cfg, ch); err != nil {
+		return nil, nil, config.ChangeCounts{}, &ports.AnswerType{
+			Text:    []string{"изменения отклонены: " + err.Error()},
+			IsReply: true,
+		}
+	}
+
+	diff, err := config.DiffChannels(cfg.Channels[channel], ch)
+	if err != nil {
+		return nil, nil, config.ChangeCounts{}, unknownError
+	}
+
+	if len(diff) == 0 {
+		return nil, nil, config.ChangeCounts{}, &ports.AnswerType{
+			Text:    []string{"изменений нет!"},
+			IsReply: true,
+		}
+	}
+	return ch, diff, config.CountChanges(cfg.Channels[channel], ch, sections), nil
+}
+
+// describeDiff - количество изменений и ссылка на полный список; без файлового сервера - первые строки списка.
+func describeDiff(fs ports.FileServerPort, diff []string, c config.ChangeCounts) string {
+	const maxLines = 3
+
+	text := fmt.Sprintf("добавлено: %d, перезаписано: %d, удалено: %d", c.Added, c.Overwritten, c.Removed)
+
+	if key, err := fs.UploadToHaste(strings.Join(diff, "\n")); err == nil {
+		return text + " • изменения: " + fs.GetURL(key)
+	}
+
+	lines := diff[:min(len(diff), maxLines)]
+	if len(diff) > maxLines {
+		lines = append(slices.Clone(lines), "...")
+	}
+	return text + " • " + strings.Join(lines, " • ")
+}
+
+func bundleMode(merge bool) string {
+	if merge {
+		return "merge"
+	}
+	return "replace"
+}
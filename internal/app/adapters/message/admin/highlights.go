@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/ports"
+	"twitchspam/pkg/logger"
+)
+
+const (
+	highlightsLabel = "auto: highlight" // подпись автоматических маркеров в отчёте по стриму
+	highlightsTop   = 10
+)
+
+// Highlights отмечает всплески активности чата и после стрима публикует топ моментов.
+// Моменты хранятся в статистике стрима, а не в маркерах конфига, поэтому не создают версий в истории конфига.
+type Highlights struct {
+	log     logger.Logger
+	manager *config.Manager
+	stream  ports.StreamPort
+	api     ports.APIPort
+	fs      ports.FileServerPort
+}
+
+func NewHighlights(log logger.Logger, manager *config.Manager, stream ports.StreamPort, api ports.APIPort, fs ports.FileServerPort) *Highlights {
+	return &Highlights{
+		log:     log,
+		manager: manager,
+		stream:  stream,
+		api:     api,
+		fs:      fs,
+	}
+}
+
+// OnSpike логирует всплеск; сам момент уже сохранён в статистике стрима.
+func (h *Highlights) OnSpike(moment ports.ActivityMoment) {
+	if !h.manager.Get().Channels[h.stream.ChannelName()].Highlights.Enabled {
+		return
+	}
+
+	h.log.Info("Chat activity spike", slog.String("kind", moment.Kind), slog.Int("messages", moment.Messages), slog.Int("emotes", moment.Emotes), slog.Float64("baseline", moment.Baseline))
+}
+
+// Finish после окончания стрима публикует ссылку на топ моментов с таймкодами VOD.
+// Вызывается после сохранения времени окончания, когда проверена и последняя минута стрима.
+func (h *Highlights) Finish() {
+	if !h.manager.Get().Channels[h.stream.ChannelName()].Highlights.Enabled {
+		return
+	}
+
+	moments := h.stream.Stats().TopMoments(highlightsTop)
+	if len(moments) == 0 {
+		return
+	}
+
+	markers := make([]*config.Markers, 0, len(moments))
+	for _, m := range moments {
+		markers = append(markers, &config.Markers{StreamID: m.StreamID})
+	}
+
+	vods, err := h.api.GetUrlVOD(h.stream.ChannelID(), markers)
+	if err != nil {
+		h.log.Warn("VOD not found for highlights", slog.String("error", err.Error()))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "топ моментов стрима %s:\n", moments[0].Time.Format("02.01.2006"))
+	for i, m := range moments {
+		timecode := fmt.Sprintf("%02dh%02dm%02ds",
+			int(m.Timecode.Hours()),
+			int(m.Timecode.Minutes())%60,
+			int(m.Timecode.Seconds())%60,
+		)
+
+		link := "вод не найден, таймкод " + timecode
+		if vod, ok := vods[m.StreamID]; ok {
+			link = fmt.Sprintf("%s?t=%s", vod, timecode)
+		}
+
+		what := fmt.Sprintf("%d сообщений в минуту (обычно %.0f)", m.Messages, m.Baseline)
+		if m.Kind == "emotes" {
+			what = fmt.Sprintf("%d эмоутов в минуту (обычно %.0f)", m.Emotes, m.Baseline)
+		}
+		fmt.Fprintf(&sb, "#%d %s - %s - %s\n", i+1, m.Time.Format("15:04"), what, link)
+	}
+
+	key, err := h.fs.UploadToHaste(sb.String())
+	if err != nil {
+		h.log.Error("Failed to upload highlights", err)
+		return
+	}
+
+	h.api.SendChatMessages(h.stream.ChannelID(), &ports.AnswerType{
+		Text: []string{"топ моментов стрима: " + h.fs.GetURL(key)},
+	})
+}
@@ -18,7 +18,6 @@ import (
 	"twitchspam/internal/app/domain/message"
 	"twitchspam/internal/app/domain/template"
 	"twitchspam/internal/app/infrastructure/config"
-	"twitchspam/internal/app/infrastructure/storage"
 	"twitchspam/internal/app/ports"
 	"twitchspam/pkg/logger"
 )
@@ -109,9 +108,10 @@ func (s *Status) Execute(cfg *config.Config, channel string, _ *message.ChatMess
 }
 
 type Auth struct {
-	log    logger.Logger
-	stream ports.StreamPort
-	api    ports.APIPort
+	log     logger.Logger
+	manager *config.Manager
+	stream  ports.StreamPort
+	api     ports.APIPort
 }
 
 func (a *Auth) Execute(cfg *config.Config, channel string, _ *message.ChatMessage) *ports.AnswerType {
@@ -137,7 +137,9 @@ func (a *Auth) Execute(cfg *config.Config, channel string, _ *message.ChatMessag
 		ExpiresIn:    resp.ExpiresIn,
 		ObtainedAt:   time.Now(),
 	}
-	cfg.UsersTokens[a.stream.ChannelID()] = newToken
+	if err := a.manager.SetUserTokens(a.stream.ChannelID(), newToken); err != nil {
+		a.log.Error("Failed to save user tokens", err, slog.String("channel_id", channel))
+	}
 
 	return &ports.AnswerType{Text: []string{"авторизация пройдена!"}, IsReply: true}
 }
@@ -203,7 +205,7 @@ type SetCategory struct {
 	log             logger.Logger
 	stream          ports.StreamPort
 	api             ports.APIPort
-	cacheCategories *storage.Cache[Category]
+	cacheCategories ports.CachePort[Category]
 }
 
 type Category struct {
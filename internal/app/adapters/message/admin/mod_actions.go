@@ -36,7 +36,9 @@ func (b *BanUser) Execute(_ *config.Config, _ string, msg *message.ChatMessage)
 	}
 	b.log.Info("Ban command received", slog.String("username", username), slog.String("reason", reason))
 
-	b.api.BanUser(b.stream.ChannelName(), b.stream.ChannelID(), ids[strings.ToLower(username)], reason)
+	if err := b.api.BanUser(b.stream.ChannelName(), b.stream.ChannelID(), ids[strings.ToLower(username)], reason); err != nil {
+		return unknownError
+	}
 	return &ports.AnswerType{Text: []string{fmt.Sprintf("пользователь %s забанен!", username)}, IsReply: true}
 }
 
@@ -61,7 +63,9 @@ func (u *UnbanUser) Execute(_ *config.Config, _ string, msg *message.ChatMessage
 	}
 	u.log.Info("Unban command received", slog.String("username", username))
 
-	u.api.UnbanUser(u.stream.ChannelID(), ids[strings.ToLower(username)])
+	if err := u.api.UnbanUser(u.stream.ChannelID(), ids[strings.ToLower(username)]); err != nil {
+		return unknownError
+	}
 	return &ports.AnswerType{Text: []string{fmt.Sprintf("ограничения с пользователя %s сняты!", username)}, IsReply: true}
 }
 
@@ -126,7 +130,9 @@ func (t *TimeoutUser) Execute(_ *config.Config, _ string, msg *message.ChatMessa
 	}
 	t.log.Info("Timeout command received", slog.String("username", username), slog.Int("duration", duration), slog.String("reason", reason))
 
-	t.api.TimeoutUser(t.stream.ChannelName(), t.stream.ChannelID(), ids[strings.ToLower(username)], duration, reason)
+	if err := t.api.TimeoutUser(t.stream.ChannelName(), t.stream.ChannelID(), ids[strings.ToLower(username)], duration, reason); err != nil {
+		return unknownError
+	}
 	return &ports.AnswerType{Text: []string{fmt.Sprintf("пользователь %s отправлен в таймаут на %d сек!", username, duration)}, IsReply: true}
 }
 
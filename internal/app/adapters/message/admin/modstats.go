@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"twitchspam/internal/app/domain/message"
+	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/ports"
+)
+
+type ModStats struct {
+	re     *regexp.Regexp
+	stream ports.StreamPort
+}
+
+func (m *ModStats) Execute(cfg *config.Config, _ string, msg *message.ChatMessage) *ports.AnswerType {
+	matches := m.re.FindStringSubmatch(msg.Message.Text.Text()) // !am modstats [stream|<дни>d|all]
+	if len(matches) != 2 {
+		return nonParametr
+	}
+
+	var days int
+	switch period := strings.ToLower(strings.TrimSpace(matches[1])); {
+	case period == "" || period == "stream":
+	case period == "all":
+		days = -1
+	case strings.HasSuffix(period, "d"):
+		n, err := strconv.Atoi(strings.TrimSuffix(period, "d"))
+		if err != nil || n < 1 || n > 366 {
+			return &ports.AnswerType{
+				Text:    []string{"период должен быть от 1d до 366d!"},
+				IsReply: true,
+			}
+		}
+		days = n
+	default:
+		return nonParametr
+	}
+
+	return m.stream.Stats().GetModStats(days, cfg.App.Username)
+}
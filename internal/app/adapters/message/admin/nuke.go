@@ -2,11 +2,15 @@ package admin
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"twitchspam/internal/app/adapters/message/checker"
+	"twitchspam/internal/app/domain"
 	"twitchspam/internal/app/domain/message"
 	"twitchspam/internal/app/infrastructure/config"
 	"twitchspam/internal/app/infrastructure/storage"
@@ -25,79 +29,102 @@ type Nuke struct {
 }
 
 func (n *Nuke) Execute(_ *config.Config, _ string, msg *message.ChatMessage) *ports.AnswerType {
-	// !am nuke <*наказание> <*длительность> <*scrollback> <слова/фразы через запятую или regex>
+	// !am nuke <*#название> <*наказание> <*длительность> <*scrollback> <слова/фразы через запятую или regex>
 	matches := n.re.FindStringSubmatch(msg.Message.Text.Text())
-	if len(matches) != 5 {
+	if len(matches) != 6 {
 		return nonParametr
 	}
 
-	var globalErrs []string
-	punishment := config.Punishment{
-		Action:   "timeout",
-		Duration: 60,
-	}
-	duration := 5 * time.Minute
-
-	scrollback := n.messages.GetTTL()
-	if scrollback > 60*time.Second {
-		scrollback = 60 * time.Second
-	}
-
-	if strings.TrimSpace(matches[1]) != "" {
-		p, err := n.template.Punishment().Parse(strings.TrimSpace(matches[1]), false)
-		if err != nil {
-			globalErrs = append(globalErrs, "не удалось распарсить наказание, применено дефолтное (60))")
-		} else {
-			punishment = p
-		}
-	}
-
-	if strings.TrimSpace(matches[2]) != "" {
-		if val, ok := n.template.Parser().ParseIntArg(strings.TrimSpace(matches[2]), 1, 3600); ok {
-			duration = time.Duration(val) * time.Second
-		}
-	}
-
-	if strings.TrimSpace(matches[3]) != "" {
-		if val, ok := n.template.Parser().ParseIntArg(strings.TrimSpace(matches[3]), 1, 180); ok {
-			scrollback = time.Duration(val) * time.Second
-		}
-	}
+	rule, scrollback, globalErrs := n.parseArgs(matches[2], matches[3], matches[4])
 
-	if strings.TrimSpace(matches[4]) == "" {
+	if strings.TrimSpace(matches[5]) == "" {
 		return &ports.AnswerType{
 			Text:    []string{"не указаны слова для массбана!"},
 			IsReply: true,
 		}
 	}
-	wordsMatches := n.reWords.FindAllStringSubmatch(strings.TrimSpace(matches[4]), -1)
+	wordsMatches := n.reWords.FindAllStringSubmatch(strings.TrimSpace(matches[5]), -1)
 
-	var containsWords, words []string
-	var re *regexp.Regexp
 	for _, m := range wordsMatches {
 		switch {
 		case strings.TrimSpace(m[1]) != "": // r'...'
 			var err error
-			re, err = regexp.Compile(strings.TrimSpace(m[1]))
+			rule.Regexp, err = regexp.Compile(strings.TrimSpace(m[1]))
 			if err != nil {
 				return invalidRegex
 			}
 		case strings.TrimSpace(m[2]) != "": // r"..."
 			var err error
-			re, err = regexp.Compile(strings.TrimSpace(m[2]))
+			rule.Regexp, err = regexp.Compile(strings.TrimSpace(m[2]))
 			if err != nil {
 				return invalidRegex
 			}
 		case strings.TrimSpace(m[3]) != "": // '...'
-			words = append(words, strings.TrimSpace(m[3]))
+			rule.Words = append(rule.Words, strings.TrimSpace(m[3]))
 		case strings.TrimSpace(m[4]) != "": // "..."
-			words = append(words, strings.TrimSpace(m[4]))
+			rule.Words = append(rule.Words, strings.TrimSpace(m[4]))
 		case strings.TrimSpace(m[5]) != "": // bareword
-			containsWords = append(containsWords, strings.TrimSpace(m[5]))
+			rule.ContainsWords = append(rule.ContainsWords, strings.TrimSpace(m[5]))
 		}
 	}
 
-	n.template.Nuke().Start(punishment, duration, containsWords, words, re, func(ctx context.Context) {
+	name := n.template.Nuke().Start(matches[1], rule, n.scrollbackFn(rule.Punishment, scrollback))
+	return n.startedAnswer(name, globalErrs)
+}
+
+func (n *Nuke) startedAnswer(name string, globalErrs []string) *ports.AnswerType {
+	text := fmt.Sprintf("массбан %s запущен!", name)
+	if len(globalErrs) != 0 {
+		text = fmt.Sprintf("массбан %s запущен • %s", name, strings.Join(globalErrs, " • "))
+	}
+
+	return &ports.AnswerType{
+		Text:    []string{text},
+		IsReply: true,
+	}
+}
+
+func (n *Nuke) parseArgs(punishmentArg, durationArg, scrollbackArg string) (ports.NukeRule, time.Duration, []string) {
+	var globalErrs []string
+	rule := ports.NukeRule{
+		Punishment: config.Punishment{
+			Action:   "timeout",
+			Duration: 60,
+		},
+		Duration: 5 * time.Minute,
+	}
+
+	scrollback := n.messages.GetTTL()
+	if scrollback > 60*time.Second {
+		scrollback = 60 * time.Second
+	}
+
+	if strings.TrimSpace(punishmentArg) != "" {
+		p, err := n.template.Punishment().Parse(strings.TrimSpace(punishmentArg), false)
+		if err != nil {
+			globalErrs = append(globalErrs, "не удалось распарсить наказание, применено дефолтное (60))")
+		} else {
+			rule.Punishment = p
+		}
+	}
+
+	if strings.TrimSpace(durationArg) != "" {
+		if val, ok := n.template.Parser().ParseIntArg(strings.TrimSpace(durationArg), 1, 3600); ok {
+			rule.Duration = time.Duration(val) * time.Second
+		}
+	}
+
+	if strings.TrimSpace(scrollbackArg) != "" {
+		if val, ok := n.template.Parser().ParseIntArg(strings.TrimSpace(scrollbackArg), 1, 180); ok {
+			scrollback = time.Duration(val) * time.Second
+		}
+	}
+
+	return rule, scrollback, globalErrs
+}
+
+func (n *Nuke) scrollbackFn(punishment config.Punishment, scrollback time.Duration) func(ctx context.Context, name string) {
+	return func(ctx context.Context, name string) {
 		checkCtx := func() bool {
 			select {
 			case <-ctx.Done():
@@ -108,30 +135,50 @@ func (n *Nuke) Execute(_ *config.Config, _ string, msg *message.ChatMessage) *po
 			}
 		}
 
-		executeAction := func(username string, messageID string, msg storage.Message) {
+		executeAction := func(name, username string, messageID string, msg storage.Message) {
+			release, ok := n.template.Nuke().Acquire(name)
+			if !ok {
+				return
+			}
+
 			err := n.api.Pool().Submit(func() {
+				defer release()
 				if !checkCtx() {
 					return
 				}
 
+				var err error
 				switch punishment.Action {
 				case checker.Ban:
 					n.log.Warn("Ban user", slog.String("username", username), slog.String("text", msg.Data.Message.Text.Text()))
-					n.api.BanUser(n.stream.ChannelName(), n.stream.ChannelID(), msg.Data.Chatter.UserID, "массбан")
+					err = n.api.BanUser(n.stream.ChannelName(), n.stream.ChannelID(), msg.Data.Chatter.UserID, "массбан")
 				case checker.Timeout:
 					n.log.Warn("Timeout user", slog.String("username", username),
 						slog.String("text", msg.Data.Message.Text.Text()),
 						slog.Int("duration", int((time.Duration(punishment.Duration)*time.Second).Seconds())),
 					)
-					n.api.TimeoutUser(n.stream.ChannelName(), n.stream.ChannelID(), msg.Data.Chatter.UserID, punishment.Duration, "массбан")
+					err = n.api.TimeoutUser(n.stream.ChannelName(), n.stream.ChannelID(), msg.Data.Chatter.UserID, punishment.Duration, "массбан")
 				case checker.Delete:
 					n.log.Warn("Delete message", slog.String("username", username), slog.String("text", msg.Data.Message.Text.Text()))
-					if err := n.api.DeleteChatMessage(n.stream.ChannelName(), n.stream.ChannelID(), messageID); err != nil {
+					if err = n.api.DeleteChatMessage(n.stream.ChannelName(), n.stream.ChannelID(), messageID); err != nil {
 						n.log.Error("Failed to delete message on chat", err)
 					}
 				}
+
+				hit := ports.NukeHit{
+					UserID:   msg.Data.Chatter.UserID,
+					Username: msg.Data.Chatter.Username,
+					Text:     msg.Data.Message.Text.Text(),
+					Action:   punishment.Action,
+					Source:   "scrollback",
+				}
+				if err != nil {
+					hit.Error = err.Error()
+				}
+				n.template.Nuke().Record(name, hit)
 			})
 			if err != nil {
+				release()
 				n.log.Error("Failed to submit request", err)
 			}
 		}
@@ -160,35 +207,78 @@ func (n *Nuke) Execute(_ *config.Config, _ string, msg *message.ChatMessage) *po
 					return cur
 				})
 
-				action := n.template.Nuke().Check(&msg.Data.Message.Text, msg.IgnoreNuke)
+				action := n.template.Nuke().CheckOne(name, &msg.Data.Message.Text, msg.IgnoreNuke)
 				if action == nil || msg.Data.Chatter.IsBroadcaster || msg.Data.Chatter.IsMod {
 					continue
 				}
 
-				executeAction(username, messageID, msg)
+				executeAction(name, username, messageID, msg)
 				if punishment.Action != "delete" {
 					break
 				}
 			}
 		}
-	})
+	}
+}
 
-	if len(globalErrs) != 0 {
+type NukeLike struct {
+	re   *regexp.Regexp
+	nuke *Nuke
+}
+
+func (n *NukeLike) Execute(cfg *config.Config, channel string, msg *message.ChatMessage) *ports.AnswerType {
+	// !am nuke like <*#название> <*наказание> <*длительность> <*scrollback> (ответом на сообщение)
+	if msg.Reply == nil {
 		return &ports.AnswerType{
-			Text:    []string{strings.Join(globalErrs, " • ")},
+			Text:    []string{"вызови команду ответом на сообщение!"},
 			IsReply: true,
 		}
 	}
-	return success
+
+	matches := n.re.FindStringSubmatch(msg.Message.Text.Text())
+	if len(matches) != 5 {
+		return nonParametr
+	}
+
+	sample := msg.Reply.ParentMessage.Text.Words(message.RemovePunctuationOption)
+	if len(sample) == 0 {
+		return &ports.AnswerType{
+			Text:    []string{"сообщение-образец пустое!"},
+			IsReply: true,
+		}
+	}
+
+	rule, scrollback, globalErrs := n.nuke.parseArgs(matches[2], matches[3], matches[4])
+	rule.Sample = sample
+	rule.Threshold = cfg.Channels[channel].Spam.SettingsDefault.SimilarityThreshold
+
+	n.nuke.log.Info("Nuke by example started",
+		slog.String("username", msg.Reply.ParentChatter.Username),
+		slog.String("text", msg.Reply.ParentMessage.Text.Text()),
+		slog.Float64("threshold", rule.Threshold),
+	)
+	name := n.nuke.template.Nuke().Start(matches[1], rule, n.nuke.scrollbackFn(rule.Punishment, scrollback))
+	return n.nuke.startedAnswer(name, globalErrs)
 }
 
 type NukeStop struct {
+	re       *regexp.Regexp
 	template ports.TemplatePort
 }
 
-func (n *NukeStop) Execute(_ *config.Config, _ string, _ *message.ChatMessage) *ports.AnswerType {
-	// !am nuke stop
-	n.template.Nuke().Cancel()
+func (n *NukeStop) Execute(_ *config.Config, _ string, msg *message.ChatMessage) *ports.AnswerType {
+	// !am nuke stop <*название>
+	matches := n.re.FindStringSubmatch(msg.Message.Text.Text())
+	if len(matches) != 2 {
+		return nonParametr
+	}
+
+	if err := n.template.Nuke().Cancel(strings.TrimPrefix(strings.TrimSpace(matches[1]), "#")); err != nil {
+		return &ports.AnswerType{
+			Text:    []string{"массбан не найден!"},
+			IsReply: true,
+		}
+	}
 
 	return success
 }
@@ -199,12 +289,170 @@ type ReNuke struct {
 
 func (n *ReNuke) Execute(_ *config.Config, _ string, _ *message.ChatMessage) *ports.AnswerType {
 	// !am nuke re
-	if err := n.template.Nuke().Restart(); err != nil {
+	name, err := n.template.Nuke().Restart()
+	if err != nil {
 		return &ports.AnswerType{
 			Text:    []string{"повтор предыдущего массбана не возможен!"},
 			IsReply: true,
 		}
 	}
 
-	return success
+	return &ports.AnswerType{
+		Text:    []string{fmt.Sprintf("массбан %s запущен повторно!", name)},
+		IsReply: true,
+	}
+}
+
+type NukeList struct {
+	template ports.TemplatePort
+}
+
+func (n *NukeList) Execute(_ *config.Config, _ string, _ *message.ChatMessage) *ports.AnswerType {
+	// !am nuke list
+	list := n.template.Nuke().List()
+	if len(list) == 0 {
+		return &ports.AnswerType{
+			Text:    []string{"активные массбаны не найдены!"},
+			IsReply: true,
+		}
+	}
+
+	parts := make([]string, 0, len(list))
+	for _, nuke := range list {
+		parts = append(parts, fmt.Sprintf("%s (%s, осталось %s, %s, наказано: %d)",
+			nuke.Name,
+			n.template.Punishment().Format(nuke.Rule.Punishment),
+			domain.FormatDuration(time.Until(nuke.ExpiresAt).Truncate(time.Second)),
+			formatNukeRule(nuke.Rule),
+			nuke.Targets,
+		))
+	}
+
+	return &ports.AnswerType{
+		Text:    []string{"активные массбаны: " + strings.Join(parts, " • ")},
+		IsReply: true,
+	}
+}
+
+func formatNukeRule(rule ports.NukeRule) string {
+	var parts []string
+	if rule.Regexp != nil {
+		parts = append(parts, "regex "+rule.Regexp.String())
+	}
+	if len(rule.Sample) > 0 {
+		parts = append(parts, fmt.Sprintf("похожие на \"%s\" (%.2f)", strings.Join(rule.Sample, " "), rule.Threshold))
+	}
+	if words := append(append([]string(nil), rule.ContainsWords...), rule.Words...); len(words) > 0 {
+		parts = append(parts, "слова: "+strings.Join(words, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+type NukeUndo struct {
+	re       *regexp.Regexp
+	log      logger.Logger
+	api      ports.APIPort
+	template ports.TemplatePort
+	stream   ports.StreamPort
+}
+
+func (n *NukeUndo) Execute(_ *config.Config, _ string, msg *message.ChatMessage) *ports.AnswerType {
+	// !am nuke undo <юзернеймы для исключения через запятую или пробел?>
+	matches := n.re.FindStringSubmatch(msg.Message.Text.Text())
+	if len(matches) != 2 {
+		return nonParametr
+	}
+
+	excluded := make(map[string]struct{})
+	for _, username := range strings.FieldsFunc(matches[1], func(r rune) bool { return r == ',' || r == ' ' }) {
+		excluded[strings.ToLower(strings.TrimPrefix(strings.TrimSpace(username), "@"))] = struct{}{}
+	}
+
+	var targets []ports.NukeTarget
+	for _, t := range n.template.Nuke().Targets() {
+		if _, ok := excluded[strings.ToLower(t.Username)]; ok {
+			continue
+		}
+
+		// удаление сообщения отменить невозможно, предупреждение снимается само
+		if t.Action != checker.Ban && t.Action != checker.Timeout {
+			continue
+		}
+		targets = append(targets, t)
+	}
+
+	if len(targets) == 0 {
+		return &ports.AnswerType{
+			Text:    []string{"нет пользователей для снятия ограничений!"},
+			IsReply: true,
+		}
+	}
+	n.log.Info("Nuke undo started", slog.Int("count", len(targets)), slog.Int("excluded", len(excluded)))
+
+	var done, failed atomic.Int32
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	unbanned := make([]ports.NukeTarget, 0, len(targets))
+	for _, t := range targets {
+		wg.Add(1)
+		if err := n.api.Pool().Submit(func() {
+			defer wg.Done()
+
+			n.log.Warn("Unban user", slog.String("username", t.Username), slog.String("action", t.Action))
+			if err := n.api.UnbanUser(n.stream.ChannelID(), t.UserID); err != nil {
+				failed.Add(1)
+				return
+			}
+			done.Add(1)
+
+			mu.Lock()
+			unbanned = append(unbanned, t)
+			mu.Unlock()
+		}); err != nil {
+			wg.Done()
+			failed.Add(1)
+			n.log.Error("Failed to submit request", err, slog.String("username", t.Username))
+		}
+	}
+
+	reply := func(text string) {
+		n.api.SendChatMessages(n.stream.ChannelID(), &ports.AnswerType{
+			Text:          []string{text},
+			IsReply:       true,
+			ReplyUsername: msg.Chatter.Username,
+		})
+	}
+
+	go func() {
+		finished := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(finished)
+		}()
+
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				reply(fmt.Sprintf("снятие ограничений: %d/%d", done.Load(), len(targets)))
+			case <-finished:
+				// неудачные снятия остаются в списке, чтобы их можно было повторить
+				n.template.Nuke().RemoveTargets(unbanned)
+
+				text := fmt.Sprintf("ограничения сняты с %d из %d пользователей!", done.Load(), len(targets))
+				if f := failed.Load(); f > 0 {
+					text = fmt.Sprintf("ограничения сняты с %d из %d пользователей, не удалось обработать %d!", done.Load(), len(targets), f)
+				}
+				reply(text)
+				return
+			}
+		}
+	}()
+
+	return &ports.AnswerType{
+		Text:    []string{fmt.Sprintf("снимаю ограничения с %d пользователей...", len(targets))},
+		IsReply: true,
+	}
 }
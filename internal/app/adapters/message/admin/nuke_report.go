@@ -0,0 +1,191 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"twitchspam/internal/app/domain/message"
+	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/ports"
+	"twitchspam/pkg/logger"
+)
+
+const nukeReportsDir = "cache/nukes"
+
+type NukeReporter struct {
+	log    logger.Logger
+	api    ports.APIPort
+	stream ports.StreamPort
+	fs     ports.FileServerPort
+}
+
+// Finish сохраняет отчёт по завершённому массбану и публикует ссылку на него в чат.
+func (r *NukeReporter) Finish(report ports.NukeReport) {
+	text := formatNukeReport(report)
+	if r.stream.IsLive() {
+		r.stream.Stats().AddEvent("nuke", fmt.Sprintf("%s - совпадений: %d", report.Name, len(report.Hits)))
+	}
+
+	if err := r.save(report, text); err != nil {
+		r.log.Error("Failed to save nuke report", err, slog.String("name", report.Name))
+	}
+
+	if len(report.Hits) == 0 {
+		return
+	}
+
+	key, err := r.fs.UploadToHaste(text)
+	if err != nil {
+		r.log.Error("Failed to upload nuke report", err, slog.String("name", report.Name))
+		return
+	}
+
+	r.api.SendChatMessages(r.stream.ChannelID(), &ports.AnswerType{
+		Text: []string{fmt.Sprintf("отчёт по массбану %s: %s", report.Name, r.fs.GetURL(key))},
+	})
+}
+
+func (r *NukeReporter) dir() string {
+	return filepath.Join(nukeReportsDir, r.stream.ChannelName())
+}
+
+func (r *NukeReporter) save(report ports.NukeReport, text string) error {
+	if err := os.MkdirAll(r.dir(), 0o700); err != nil {
+		return err
+	}
+
+	file := fmt.Sprintf("%s_%s.txt", report.StartedAt.Format("20060102-150405"), reportFileName(report.Name))
+	return os.WriteFile(filepath.Join(r.dir(), file), []byte(text), 0o600)
+}
+
+// latest возвращает последний сохранённый отчёт; при непустом названии - последний отчёт с этим названием.
+func (r *NukeReporter) latest(name string) (string, bool) {
+	entries, err := os.ReadDir(r.dir())
+	if err != nil {
+		return "", false
+	}
+
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".txt") {
+			continue
+		}
+
+		if name != "" && !strings.HasSuffix(e.Name(), "_"+reportFileName(name)+".txt") {
+			continue
+		}
+		files = append(files, e.Name())
+	}
+
+	if len(files) == 0 {
+		return "", false
+	}
+	slices.Sort(files) // имена начинаются с времени запуска
+
+	data, err := os.ReadFile(filepath.Join(r.dir(), files[len(files)-1]))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+func reportFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+func formatNukeReport(report ports.NukeReport) string {
+	var sb strings.Builder
+
+	punishment := report.Rule.Punishment.Action
+	if punishment == "timeout" {
+		punishment = fmt.Sprintf("timeout %d сек", report.Rule.Punishment.Duration)
+	}
+
+	ended := "активен"
+	if !report.EndedAt.IsZero() {
+		ended = report.EndedAt.Format(time.DateTime)
+	}
+
+	users := make(map[string]struct{})
+	var failed, live, scrollback int
+	for _, hit := range report.Hits {
+		users[hit.UserID] = struct{}{}
+		if hit.Error != "" {
+			failed++
+		}
+		if hit.Source == "live" {
+			live++
+		} else {
+			scrollback++
+		}
+	}
+
+	fmt.Fprintf(&sb, "Массбан: %s\n", report.Name)
+	fmt.Fprintf(&sb, "Наказание: %s\n", punishment)
+	fmt.Fprintf(&sb, "Правило: %s\n", formatNukeRule(report.Rule))
+	fmt.Fprintf(&sb, "Начало: %s\n", report.StartedAt.Format(time.DateTime))
+	fmt.Fprintf(&sb, "Окончание: %s\n", ended)
+	fmt.Fprintf(&sb, "Совпадений: %d (scrollback: %d, live: %d)\n", len(report.Hits), scrollback, live)
+	fmt.Fprintf(&sb, "Пользователей: %d\n", len(users))
+	fmt.Fprintf(&sb, "Ошибок API: %d\n", failed)
+
+	if len(report.Hits) == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString("\nСовпадения:\n")
+	for _, hit := range report.Hits {
+		fmt.Fprintf(&sb, "[%s] %s %s (%s): %s", hit.Time.Format(time.TimeOnly), hit.Action, hit.Username, hit.Source, hit.Text)
+		if hit.Error != "" {
+			fmt.Fprintf(&sb, " - ошибка: %s", hit.Error)
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+type NukeReport struct {
+	re       *regexp.Regexp
+	template ports.TemplatePort
+	reporter *NukeReporter
+}
+
+func (n *NukeReport) Execute(_ *config.Config, _ string, msg *message.ChatMessage) *ports.AnswerType {
+	// !am nuke report <*название>
+	matches := n.re.FindStringSubmatch(msg.Message.Text.Text())
+	if len(matches) != 2 {
+		return nonParametr
+	}
+	name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(matches[1]), "#"))
+
+	var text string
+	if report, ok := n.template.Nuke().Report(name); ok && report.EndedAt.IsZero() {
+		text = formatNukeReport(report)
+	} else if text, ok = n.reporter.latest(name); !ok {
+		return &ports.AnswerType{
+			Text:    []string{"отчёт не найден!"},
+			IsReply: true,
+		}
+	}
+
+	key, err := n.reporter.fs.UploadToHaste(text)
+	if err != nil {
+		return unknownError
+	}
+
+	return &ports.AnswerType{
+		Text:    []string{n.reporter.fs.GetURL(key)},
+		IsReply: true,
+	}
+}
@@ -91,6 +91,9 @@ func (p *CreatePoll) Execute(_ *config.Config, _ string, msg *message.ChatMessag
 		return unknownError
 	}
 	p.poll = poll
+	if p.stream.IsLive() {
+		p.stream.Stats().AddEvent("poll", "опрос: "+poll.Title)
+	}
 
 	return success
 }
@@ -180,6 +183,9 @@ func (p *RePoll) Execute(_ *config.Config, _ string, _ *message.ChatMessage) *po
 		return unknownError
 	}
 	p.poll = poll
+	if p.stream.IsLive() {
+		p.stream.Stats().AddEvent("poll", "опрос: "+poll.Title)
+	}
 
 	return success
 }
@@ -83,6 +83,9 @@ func (p *CreatePrediction) Execute(_ *config.Config, _ string, msg *message.Chat
 		return unknownError
 	}
 	p.pred = pred
+	if p.stream.IsLive() {
+		p.stream.Stats().AddEvent("prediction", "ставка: "+pred.Title)
+	}
 
 	return success
 }
@@ -201,6 +204,9 @@ func (p *RePrediction) Execute(_ *config.Config, _ string, _ *message.ChatMessag
 		return unknownError
 	}
 	p.pred = pred
+	if p.stream.IsLive() {
+		p.stream.Stats().AddEvent("prediction", "ставка: "+pred.Title)
+	}
 
 	return success
 }
@@ -0,0 +1,234 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"twitchspam/internal/app/domain/message"
+	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/ports"
+	"twitchspam/pkg/logger"
+)
+
+var profileNotFound = &ports.AnswerType{
+	Text:    []string{"профиль не найден!"},
+	IsReply: true,
+}
+
+type ProfileList struct{}
+
+func (p *ProfileList) Execute(cfg *config.Config, channel string, _ *message.ChatMessage) *ports.AnswerType {
+	// !am profile list
+	ch := cfg.Channels[channel]
+	if len(ch.Profiles) == 0 {
+		return &ports.AnswerType{
+			Text:    []string{"профили не найдены!"},
+			IsReply: true,
+		}
+	}
+
+	names := slices.Sorted(maps.Keys(ch.Profiles))
+	for i, name := range names {
+		if name == ch.ActiveProfile {
+			names[i] = name + " (активен)"
+		}
+	}
+
+	parts := []string{"профили: " + strings.Join(names, ", ")}
+	if ch.ProfileAuto.Online != "" {
+		parts = append(parts, "онлайн: "+ch.ProfileAuto.Online)
+	}
+	if ch.ProfileAuto.Offline != "" {
+		parts = append(parts, "оффлайн: "+ch.ProfileAuto.Offline)
+	}
+	for _, category := range slices.Sorted(maps.Keys(ch.ProfileAuto.Categories)) {
+		parts = append(parts, fmt.Sprintf("%s: %s", category, ch.ProfileAuto.Categories[category]))
+	}
+
+	return &ports.AnswerType{
+		Text:    []string{strings.Join(parts, " • ")},
+		IsReply: true,
+	}
+}
+
+type ProfileUse struct {
+	re       *regexp.Regexp
+	template ports.TemplatePort
+	trusts   ports.TrustsPort
+	timer    *AddTimer
+}
+
+func (p *ProfileUse) Execute(cfg *config.Config, channel string, msg *message.ChatMessage) *ports.AnswerType {
+	matches := p.re.FindStringSubmatch(msg.Message.Text.Text()) // !am profile use <название>
+	if len(matches) != 2 {
+		return nonParametr
+	}
+
+	if err := config.ApplyProfile(cfg.Channels[channel], matches[1]); err != nil {
+		return profileNotFound
+	}
+	ApplyConfig(cfg, p.template, p.trusts, p.timer)
+
+	return success
+}
+
+type ProfileSave struct {
+	re *regexp.Regexp
+}
+
+func (p *ProfileSave) Execute(cfg *config.Config, channel string, msg *message.ChatMessage) *ports.AnswerType {
+	matches := p.re.FindStringSubmatch(msg.Message.Text.Text()) // !am profile save <название>
+	if len(matches) != 2 {
+		return nonParametr
+	}
+
+	if err := config.SaveProfile(cfg.Channels[channel], matches[1]); err != nil {
+		return unknownError
+	}
+
+	return success
+}
+
+type ProfileDel struct {
+	re *regexp.Regexp
+}
+
+func (p *ProfileDel) Execute(cfg *config.Config, channel string, msg *message.ChatMessage) *ports.AnswerType {
+	matches := p.re.FindStringSubmatch(msg.Message.Text.Text()) // !am profile del <название>
+	if len(matches) != 2 {
+		return nonParametr
+	}
+
+	ch := cfg.Channels[channel]
+	name := strings.ToLower(matches[1])
+	if _, ok := ch.Profiles[name]; !ok {
+		return profileNotFound
+	}
+
+	delete(ch.Profiles, name)
+	if ch.ActiveProfile == name {
+		ch.ActiveProfile = ""
+	}
+
+	// правила автопереключения не должны ссылаться на удалённый профиль
+	if ch.ProfileAuto.Online == name {
+		ch.ProfileAuto.Online = ""
+	}
+	if ch.ProfileAuto.Offline == name {
+		ch.ProfileAuto.Offline = ""
+	}
+	maps.DeleteFunc(ch.ProfileAuto.Categories, func(_, profile string) bool {
+		return profile == name
+	})
+
+	return success
+}
+
+type ProfileAuto struct {
+	re *regexp.Regexp
+}
+
+func (p *ProfileAuto) Execute(cfg *config.Config, channel string, msg *message.ChatMessage) *ports.AnswerType {
+	matches := p.re.FindStringSubmatch(msg.Message.Text.Text()) // !am profile auto <online/offline/cat> <название/off> <категория?>
+	if len(matches) != 4 {
+		return nonParametr
+	}
+
+	ch := cfg.Channels[channel]
+	event, name, category := strings.ToLower(matches[1]), strings.ToLower(matches[2]), strings.ToLower(strings.TrimSpace(matches[3]))
+	if name == "off" {
+		name = ""
+	} else if _, ok := ch.Profiles[name]; !ok {
+		return profileNotFound
+	}
+
+	switch event {
+	case "online":
+		ch.ProfileAuto.Online = name
+	case "offline":
+		ch.ProfileAuto.Offline = name
+	case "cat":
+		if category == "" {
+			return nonParametr
+		}
+
+		if name == "" {
+			delete(ch.ProfileAuto.Categories, category)
+			break
+		}
+		ch.ProfileAuto.Categories[category] = name
+	}
+
+	return success
+}
+
+// ProfileSwitcher автоматически переключает профили канала при начале и окончании стрима и смене категории.
+type ProfileSwitcher struct {
+	log      logger.Logger
+	manager  *config.Manager
+	stream   ports.StreamPort
+	template ports.TemplatePort
+	trusts   ports.TrustsPort
+	timer    *AddTimer
+}
+
+func NewProfileSwitcher(log logger.Logger, manager *config.Manager, stream ports.StreamPort, template ports.TemplatePort, trusts ports.TrustsPort, timer *AddTimer) *ProfileSwitcher {
+	return &ProfileSwitcher{
+		log:      log,
+		manager:  manager,
+		stream:   stream,
+		template: template,
+		trusts:   trusts,
+		timer:    timer,
+	}
+}
+
+func (p *ProfileSwitcher) OnLiveChange(isLive bool) {
+	p.switchProfile(isLive, p.stream.Category())
+}
+
+func (p *ProfileSwitcher) OnCategoryChange(category string) {
+	p.switchProfile(p.stream.IsLive(), category)
+}
+
+// switchProfile применяет профиль оффлайна, если стрима нет; во время стрима - профиль категории,
+// если он задан, иначе профиль онлайна.
+func (p *ProfileSwitcher) switchProfile(isLive bool, category string) {
+	channel := p.stream.ChannelName()
+
+	var name string
+	if err := p.manager.UpdateBy(channel, "auto", "profile auto", func(cfg *config.Config) {
+		ch := cfg.Channels[channel]
+
+		target := ch.ProfileAuto.Offline
+		if isLive {
+			target = ch.ProfileAuto.Online
+			if name, ok := ch.ProfileAuto.Categories[strings.ToLower(category)]; ok {
+				target = name
+			}
+		}
+
+		if target == "" || target == ch.ActiveProfile {
+			return
+		}
+
+		if err := config.ApplyProfile(ch, target); err != nil {
+			p.log.Warn("Auto profile not applied", slog.String("profile", target), slog.String("error", err.Error()))
+			return
+		}
+		name = target
+	}); err != nil {
+		p.log.Error("Failed to switch profile", err, slog.String("channel", channel))
+		return
+	}
+
+	if name == "" {
+		return
+	}
+
+	ApplyConfig(p.manager.Get(), p.template, p.trusts, p.timer)
+	p.log.Info("Profile switched automatically", slog.String("channel", channel), slog.String("profile", name), slog.Bool("is_live", isLive), slog.String("category", category))
+}
@@ -0,0 +1,166 @@
+package admin_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"twitchspam/internal/app/adapters/message/admin"
+	"twitchspam/internal/app/domain/stream"
+	"twitchspam/internal/app/domain/template"
+	"twitchspam/internal/app/domain/trusts"
+	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/infrastructure/storage"
+	"twitchspam/internal/app/infrastructure/timers"
+	"twitchspam/pkg/logger"
+)
+
+const testConfig = `{"app": {"oauth": "oauth-token", "client_id": "client-id", "username": "bot", "user_id": "1", "auth_token": "auth-token"}}`
+
+// newTestManager создаёт менеджер во временном каталоге с одним каналом name.
+func newTestManager(t *testing.T, name string) *config.Manager {
+	t.Chdir(t.TempDir())
+
+	require.NoError(t, os.MkdirAll("configs", 0750))
+	require.NoError(t, os.WriteFile(filepath.Join("configs", "config.json"), []byte(testConfig), 0644))
+
+	m, err := config.New()
+	require.NoError(t, err)
+
+	require.NoError(t, m.Update(func(cfg *config.Config) {
+		ch := m.GetChannel()
+		ch.Name = name
+		ch.Profiles = make(map[string]*config.Profile)
+		cfg.Channels[name] = ch
+	}))
+	return m
+}
+
+func newTestSwitcher(t *testing.T) (*admin.ProfileSwitcher, *config.Manager, *stream.Stream) {
+	m := newTestManager(t, "test")
+
+	// профили различаются лимитом сообщений антиспама
+	require.NoError(t, m.Update(func(cfg *config.Config) {
+		ch := cfg.Channels["test"]
+		for name, limit := range map[string]int{"day": 4, "night": 5, "chat": 6} {
+			ch.Spam.SettingsDefault.MessageLimit = limit
+			require.NoError(t, config.SaveProfile(ch, name))
+		}
+		ch.Spam.SettingsDefault.MessageLimit = 3
+		ch.ActiveProfile = ""
+		ch.ProfileAuto = config.ProfileAuto{
+			Online:     "day",
+			Offline:    "night",
+			Categories: map[string]string{"just chatting": "chat"},
+		}
+	}))
+
+	st := stream.NewStream("test",
+		nil,
+		storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0),
+		storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0),
+		storage.NewCache[map[string]*stream.ChatterStats](0, 0, false, false, "", 0),
+	)
+
+	cfg := m.Get()
+	tmpl := template.New(
+		template.WithAliases(cfg.Channels["test"].Aliases, cfg.Channels["test"].AliasGroups, cfg.GlobalAliases),
+		template.WithBanwords(cfg.Banwords),
+		template.WithMword(cfg.Channels["test"].Mword, cfg.Channels["test"].MwordGroup),
+	)
+	timer := &admin.AddTimer{Manager: m, Timers: timers.NewTimingWheel(100*time.Millisecond, 10), Stream: st}
+
+	return admin.NewProfileSwitcher(logger.New(), m, st, tmpl, trusts.New(nil, nil, nil), timer), m, st
+}
+
+func activeProfile(m *config.Manager) (string, int) {
+	ch := m.Get().Channels["test"]
+	return ch.ActiveProfile, ch.Spam.SettingsDefault.MessageLimit
+}
+
+func TestProfileSwitcher_OnLiveChange(t *testing.T) {
+	p, m, st := newTestSwitcher(t)
+
+	st.SetIslive(true)
+	p.OnLiveChange(true)
+
+	name, limit := activeProfile(m)
+	assert.Equal(t, "day", name)
+	assert.Equal(t, 4, limit)
+
+	st.SetIslive(false)
+	p.OnLiveChange(false)
+
+	name, limit = activeProfile(m)
+	assert.Equal(t, "night", name)
+	assert.Equal(t, 5, limit)
+
+	snapshots, err := m.History("test")
+	require.NoError(t, err)
+	require.NotEmpty(t, snapshots)
+	assert.Equal(t, "auto", snapshots[0].Author)
+}
+
+func TestProfileSwitcher_OnCategoryChange(t *testing.T) {
+	p, m, st := newTestSwitcher(t)
+
+	st.SetIslive(true)
+	st.SetCategory("Just Chatting")
+	p.OnCategoryChange("Just Chatting")
+
+	name, limit := activeProfile(m)
+	assert.Equal(t, "chat", name)
+	assert.Equal(t, 6, limit)
+
+	// у категории нет своего профиля - возвращается профиль онлайна
+	st.SetCategory("Minecraft")
+	p.OnCategoryChange("Minecraft")
+
+	name, limit = activeProfile(m)
+	assert.Equal(t, "day", name)
+	assert.Equal(t, 4, limit)
+}
+
+func TestProfileSwitcher_OfflineAfterCategory(t *testing.T) {
+	p, m, st := newTestSwitcher(t)
+
+	st.SetIslive(true)
+	st.SetCategory("Just Chatting")
+	p.OnCategoryChange("Just Chatting")
+
+	name, _ := activeProfile(m)
+	require.Equal(t, "chat", name)
+
+	// после стрима категория остаётся прежней, но применяется профиль оффлайна
+	st.SetIslive(false)
+	p.OnLiveChange(false)
+
+	name, limit := activeProfile(m)
+	assert.Equal(t, "night", name)
+	assert.Equal(t, 5, limit)
+
+	// смена категории вне стрима не переключает профиль
+	p.OnCategoryChange("Just Chatting")
+
+	name, _ = activeProfile(m)
+	assert.Equal(t, "night", name)
+}
+
+func TestProfileSwitcher_KeepsActiveProfile(t *testing.T) {
+	p, m, st := newTestSwitcher(t)
+
+	st.SetIslive(true)
+	p.OnLiveChange(true)
+
+	snapshots, err := m.History("test")
+	require.NoError(t, err)
+
+	// повторное событие с тем же профилем не меняет конфиг и не пишет историю
+	p.OnLiveChange(true)
+
+	again, err := m.History("test")
+	require.NoError(t, err)
+	assert.Len(t, again, len(snapshots))
+}
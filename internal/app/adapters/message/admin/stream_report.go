@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/ports"
+	"twitchspam/pkg/logger"
+)
+
+// StreamReporter после окончания стрима публикует полный отчёт по нему.
+type StreamReporter struct {
+	log     logger.Logger
+	manager *config.Manager
+	stream  ports.StreamPort
+	api     ports.APIPort
+	fs      ports.FileServerPort
+}
+
+func NewStreamReporter(log logger.Logger, manager *config.Manager, stream ports.StreamPort, api ports.APIPort, fs ports.FileServerPort) *StreamReporter {
+	return &StreamReporter{
+		log:     log,
+		manager: manager,
+		stream:  stream,
+		api:     api,
+		fs:      fs,
+	}
+}
+
+// Finish формирует отчёт, загружает его на файловый сервер и отправляет ссылку в чат.
+func (r *StreamReporter) Finish() {
+	channel := r.stream.ChannelName()
+	cfg := r.manager.Get()
+
+	ch, ok := cfg.Channels[channel]
+	if !ok || !ch.Enabled {
+		return
+	}
+
+	stats := r.stream.Stats()
+	report := stats.Report(cfg.App.Username, r.markers(ch, stats.StreamID()))
+	if report == "" {
+		return
+	}
+
+	key, err := r.fs.UploadToHaste(report)
+	if err != nil {
+		r.log.Error("Failed to upload stream report", err, slog.String("channel", channel))
+		return
+	}
+
+	r.api.SendChatMessages(r.stream.ChannelID(), &ports.AnswerType{
+		Text: []string{"полный отчёт по стриму: " + r.fs.GetURL(key)},
+	})
+}
+
+// markers возвращает таймкоды маркеров стрима; ключ - "автор: название".
+func (r *StreamReporter) markers(ch *config.Channel, streamID string) map[string][]time.Duration {
+	if streamID == "" {
+		return nil
+	}
+
+	markers := make(map[string][]time.Duration)
+	if ch.Highlights.Enabled {
+		for _, m := range r.stream.Stats().TopMoments(highlightsTop) {
+			if m.StreamID == streamID {
+				markers[highlightsLabel] = append(markers[highlightsLabel], m.Timecode)
+			}
+		}
+	}
+
+	for userKey, byName := range ch.Markers {
+		login := strings.TrimSuffix(userKey, "_"+r.stream.ChannelID())
+		for name, list := range byName {
+			for _, m := range list {
+				if m.StreamID == streamID {
+					label := login + ": " + name
+					markers[label] = append(markers[label], m.Timecode)
+				}
+			}
+		}
+	}
+	return markers
+}
@@ -32,7 +32,7 @@ type Empty struct{}
 
 type Checker struct {
 	log      logger.Logger
-	cfg      *config.Config
+	manager  *config.Manager
 	stream   ports.StreamPort
 	trusts   ports.TrustsPort
 	sevenTV  ports.SevenTVPort
@@ -42,13 +42,13 @@ type Checker struct {
 	timeouts ports.StorePort[int]
 }
 
-func NewCheck(log logger.Logger, cfg *config.Config, stream ports.StreamPort, trusts ports.TrustsPort, template ports.TemplatePort, messages ports.StorePort[storage.Message], timeouts ports.StorePort[int], client *http.Client) *Checker {
+func NewCheck(log logger.Logger, manager *config.Manager, stream ports.StreamPort, trusts ports.TrustsPort, template ports.TemplatePort, messages ports.StorePort[storage.Message], timeouts ports.StorePort[int], client *http.Client) *Checker {
 	return &Checker{
 		log:      log,
-		cfg:      cfg,
+		manager:  manager,
 		stream:   stream,
 		trusts:   trusts,
-		sevenTV:  seventv.New(log, cfg, stream, client),
+		sevenTV:  seventv.New(log, manager, stream, client),
 		template: template,
 		messages: messages,
 		timeouts: timeouts,
@@ -62,7 +62,9 @@ func (c *Checker) Check(msg *message.ChatMessage, checkSpam bool) *ports.Checker
 		slog.Bool("check_spam", checkSpam),
 	)
 
-	if !c.cfg.Channels[msg.Broadcaster.Login].Enabled {
+	// конфиг читается один раз на сообщение, чтобы все проверки видели одну и ту же версию
+	ch, ok := c.manager.Get().Channels[msg.Broadcaster.Login]
+	if !ok || !ch.Enabled {
 		c.log.Debug("Bot disabled, skipping message",
 			slog.String("username", msg.Chatter.Username),
 			slog.String("message", msg.Message.Text.Text()),
@@ -80,7 +82,8 @@ func (c *Checker) Check(msg *message.ChatMessage, checkSpam bool) *ports.Checker
 		fn     func() *ports.CheckerAction
 	}{
 		{"nuke", func() *ports.CheckerAction {
-			return c.template.Nuke().Check(&msg.Message.Text, false)
+			_, action := c.template.Nuke().Check(&msg.Message.Text, false)
+			return action
 		}},
 		{"banwords", func() *ports.CheckerAction {
 			return c.checkBanwords(msg)
@@ -88,8 +91,8 @@ func (c *Checker) Check(msg *message.ChatMessage, checkSpam bool) *ports.Checker
 		{"ads", func() *ports.CheckerAction {
 			return c.checkAds(msg)
 		}},
-		{"mwords", func() *ports.CheckerAction {
-			return c.checkMwords(msg)
+		{"mword", func() *ports.CheckerAction {
+			return c.checkMwords(msg, ch)
 		}},
 	}
 
@@ -100,7 +103,7 @@ func (c *Checker) Check(msg *message.ChatMessage, checkSpam bool) *ports.Checker
 	}
 
 	if checkSpam {
-		if act := c.runCheck("spam", func() *ports.CheckerAction { return c.checkSpam(msg) }, msg); act != nil {
+		if act := c.runCheck("spam", func() *ports.CheckerAction { return c.checkSpam(msg, ch) }, msg); act != nil {
 			return act
 		}
 	}
@@ -118,6 +121,9 @@ func (c *Checker) runCheck(module string, fn func() *ports.CheckerAction, msg *m
 		c.log.Trace("Module no action", slog.String("module", module), slog.String("user", msg.Chatter.Username))
 		return nil
 	}
+	if action.Module == "" {
+		action.Module = module
+	}
 
 	c.log.Debug("Module triggered",
 		slog.String("module", module),
@@ -186,6 +192,7 @@ func (c *Checker) checkAds(msg *message.ChatMessage) *ports.CheckerAction {
 		return &ports.CheckerAction{
 			Type:      Ban,
 			ReasonMod: "реклама",
+			Rule:      "self",
 		}
 	}
 
@@ -206,6 +213,7 @@ func (c *Checker) checkAds(msg *message.ChatMessage) *ports.CheckerAction {
 		return &ports.CheckerAction{
 			Type:      Ban,
 			ReasonMod: "реклама",
+			Rule:      "foreign",
 		}
 	}
 
@@ -213,7 +221,7 @@ func (c *Checker) checkAds(msg *message.ChatMessage) *ports.CheckerAction {
 	return nil
 }
 
-func (c *Checker) checkMwords(msg *message.ChatMessage) *ports.CheckerAction {
+func (c *Checker) checkMwords(msg *message.ChatMessage, ch *config.Channel) *ports.CheckerAction {
 	if c.trusts.HasScope(msg.Chatter.UserID, trusts.ScopeIgnoreMword) {
 		c.log.Debug("Bypass: the user has a trust with a scope ignore_mword",
 			slog.String("username", msg.Chatter.Username),
@@ -232,7 +240,7 @@ func (c *Checker) checkMwords(msg *message.ChatMessage) *ports.CheckerAction {
 	if !ok {
 		c.log.Debug("Initializing muteword punishment counter", slog.String("user", msg.Chatter.Username), slog.String("message", msg.Message.Text.Text()))
 		c.timeouts.Push(msg.Chatter.Username, "mword", 0, storage.WithTTL(
-			time.Duration(c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsDefault.DurationResetPunishments)*time.Second),
+			time.Duration(ch.Spam.SettingsDefault.DurationResetPunishments)*time.Second),
 		)
 	}
 
@@ -258,10 +266,11 @@ func (c *Checker) checkMwords(msg *message.ChatMessage) *ports.CheckerAction {
 		ReasonMod:  fmt.Sprintf("мворд (%s)", trigger),
 		ReasonUser: fmt.Sprintf("Не используй запрещенное слово! (%s)", trigger),
 		Duration:   dur,
+		Rule:       trigger,
 	}
 }
 
-func (c *Checker) checkSpam(msg *message.ChatMessage) *ports.CheckerAction {
+func (c *Checker) checkSpam(msg *message.ChatMessage, ch *config.Channel) *ports.CheckerAction {
 	if c.trusts.HasScope(msg.Chatter.UserID, trusts.ScopeIgnoreAntispam) {
 		c.log.Debug("Bypass: the user has a trust with a scope ignore_antispam",
 			slog.String("username", msg.Chatter.Username),
@@ -270,10 +279,10 @@ func (c *Checker) checkSpam(msg *message.ChatMessage) *ports.CheckerAction {
 		return nil
 	}
 
-	settings := c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsDefault
+	settings := ch.Spam.SettingsDefault
 	if msg.Chatter.IsVip {
 		c.log.Trace("Applied VIP spam settings", slog.String("user", msg.Chatter.Username))
-		settings = c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsVIP
+		settings = ch.Spam.SettingsVIP
 	}
 
 	if !settings.Enabled || !c.template.SpamPause().CanProcess() {
@@ -285,11 +294,11 @@ func (c *Checker) checkSpam(msg *message.ChatMessage) *ports.CheckerAction {
 		return nil
 	}
 
-	if ((c.cfg.Channels[msg.Broadcaster.Login].Spam.Mode == config.OnlineMode || c.cfg.Channels[msg.Broadcaster.Login].Spam.Mode == 0) && !c.stream.IsLive()) ||
-		(c.cfg.Channels[msg.Broadcaster.Login].Spam.Mode == config.OfflineMode && c.stream.IsLive()) {
+	if ((ch.Spam.Mode == config.OnlineMode || ch.Spam.Mode == 0) && !c.stream.IsLive()) ||
+		(ch.Spam.Mode == config.OfflineMode && c.stream.IsLive()) {
 		c.log.Debug("Spam check skipped due to mode mismatch",
 			slog.String("user", msg.Chatter.Username),
-			slog.Int("spam_mode", c.cfg.Channels[msg.Broadcaster.Login].Spam.Mode),
+			slog.Int("spam_mode", ch.Spam.Mode),
 			slog.Bool("is_live", c.stream.IsLive()),
 		)
 		return nil
@@ -305,7 +314,7 @@ func (c *Checker) checkSpam(msg *message.ChatMessage) *ports.CheckerAction {
 		return action
 	}
 
-	countSpam, avgGap := c.calculateSpamMessages(msg, settings)
+	countSpam, avgGap, similarity := c.calculateSpamMessages(msg, ch, settings)
 	c.log.Debug("Calculated spam stats",
 		slog.String("user", msg.Chatter.Username),
 		slog.String("message", msg.Message.Text.Text()),
@@ -313,7 +322,7 @@ func (c *Checker) checkSpam(msg *message.ChatMessage) *ports.CheckerAction {
 		slog.Duration("avg_gap", avgGap),
 	)
 
-	if action := c.handleEmotes(msg, countSpam); action != nil {
+	if action := c.handleEmotes(msg, ch, countSpam); action != nil {
 		c.log.Debug("Emote spam check triggered",
 			slog.String("user", msg.Chatter.Username),
 			slog.String("message", msg.Message.Text.Text()),
@@ -324,10 +333,11 @@ func (c *Checker) checkSpam(msg *message.ChatMessage) *ports.CheckerAction {
 		if action.Type != None {
 			c.messages.ClearKey(msg.Chatter.Username)
 		}
+		action.Similarity = similarity
 		return action
 	}
 
-	if action := c.handleExceptions(msg, countSpam, "default"); action != nil {
+	if action := c.handleExceptions(msg, ch, countSpam, "default"); action != nil {
 		c.log.Debug("Spam exception triggered",
 			slog.String("user", msg.Chatter.Username),
 			slog.String("message", msg.Message.Text.Text()),
@@ -337,6 +347,7 @@ func (c *Checker) checkSpam(msg *message.ChatMessage) *ports.CheckerAction {
 		if action.Type != None {
 			c.messages.ClearKey(msg.Chatter.Username)
 		}
+		action.Similarity = similarity
 		return action
 	}
 
@@ -351,10 +362,10 @@ func (c *Checker) checkSpam(msg *message.ChatMessage) *ports.CheckerAction {
 	}
 
 	cacheKey := "spam_default"
-	cacheTTL := time.Duration(c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsDefault.DurationResetPunishments) * time.Second
+	cacheTTL := time.Duration(ch.Spam.SettingsDefault.DurationResetPunishments) * time.Second
 	if msg.Chatter.IsVip {
 		cacheKey = "spam_vip"
-		cacheTTL = time.Duration(c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsVIP.DurationResetPunishments) * time.Second
+		cacheTTL = time.Duration(ch.Spam.SettingsVIP.DurationResetPunishments) * time.Second
 	}
 
 	countTimeouts, ok := c.timeouts.Get(msg.Chatter.Username, cacheKey)
@@ -385,11 +396,16 @@ func (c *Checker) checkSpam(msg *message.ChatMessage) *ports.CheckerAction {
 		ReasonMod:  "спам",
 		ReasonUser: "Не спамь!",
 		Duration:   dur,
+		Rule:       strings.TrimPrefix(cacheKey, "spam_"),
+		Similarity: similarity,
 	}
 }
 
-func (c *Checker) calculateSpamMessages(msg *message.ChatMessage, settings config.SpamSettings) (int, time.Duration) {
+// calculateSpamMessages возвращает кол-во похожих сообщений, средний интервал между ними
+// и наибольшую схожесть нового сообщения с предыдущими.
+func (c *Checker) calculateSpamMessages(msg *message.ChatMessage, ch *config.Channel, settings config.SpamSettings) (int, time.Duration, float64) {
 	var countSpam, gap int
+	var maxSimilarity float64
 	var timestamps []time.Time
 
 	c.messages.ForEach(msg.Chatter.Username, func(item *storage.Message) {
@@ -412,6 +428,7 @@ func (c *Checker) calculateSpamMessages(msg *message.ChatMessage, settings confi
 			slog.String("old_message", item.Data.Message.Text.Text()),
 			slog.Float64("similarity", similarity),
 		)
+		maxSimilarity = max(maxSimilarity, similarity)
 
 		if similarity >= settings.SimilarityThreshold {
 			c.log.Trace("Message is similar enough to be considered for spam",
@@ -421,7 +438,7 @@ func (c *Checker) calculateSpamMessages(msg *message.ChatMessage, settings confi
 				slog.Float64("similarity", similarity),
 			)
 
-			if !c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsEmotes.Enabled {
+			if !ch.Spam.SettingsEmotes.Enabled {
 				_, isOnlyEmotes := c.sevenTV.EmoteStats(item.Data.Message.Text.Words(message.RemovePunctuationOption))
 				if isOnlyEmotes || item.Data.Message.EmoteOnly {
 					c.log.Trace("Skipping message because it contains only emotes",
@@ -462,7 +479,7 @@ func (c *Checker) calculateSpamMessages(msg *message.ChatMessage, settings confi
 			slog.String("message", msg.Message.Text.Text()),
 			slog.Int("spam_count", countSpam),
 		)
-		return countSpam, 0
+		return countSpam, 0, maxSimilarity
 	}
 
 	var total time.Duration
@@ -488,7 +505,7 @@ func (c *Checker) calculateSpamMessages(msg *message.ChatMessage, settings confi
 		slog.Duration("average_gap_between_spam", avgGap),
 	)
 
-	return countSpam, avgGap
+	return countSpam, avgGap, maxSimilarity
 }
 
 func (c *Checker) handleWordLength(words []string, settings config.SpamSettings) *ports.CheckerAction {
@@ -505,13 +522,14 @@ func (c *Checker) handleWordLength(words []string, settings config.SpamSettings)
 				ReasonMod:  "превышена максимальная длина слова",
 				ReasonUser: "Твоё сообщение содержит слишком длинное слово!",
 				Duration:   time.Duration(settings.MaxWordPunishment.Duration) * time.Second,
+				Rule:       "max_word_length",
 			}
 		}
 	}
 	return nil
 }
 
-func (c *Checker) handleEmotes(msg *message.ChatMessage, countSpam int) *ports.CheckerAction {
+func (c *Checker) handleEmotes(msg *message.ChatMessage, ch *config.Channel, countSpam int) *ports.CheckerAction {
 	count, isOnlyEmotes := c.sevenTV.EmoteStats(msg.Message.Text.Words(message.RemovePunctuationOption))
 	emoteOnly := msg.Message.EmoteOnly || isOnlyEmotes
 
@@ -527,12 +545,12 @@ func (c *Checker) handleEmotes(msg *message.ChatMessage, countSpam int) *ports.C
 		return nil
 	}
 
-	if !c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsEmotes.Enabled {
+	if !ch.Spam.SettingsEmotes.Enabled {
 		c.log.Debug("Emote spam checking disabled in config")
 		return &ports.CheckerAction{Type: None}
 	}
 
-	if action := c.handleExceptions(msg, countSpam, "emote"); action != nil {
+	if action := c.handleExceptions(msg, ch, countSpam, "emote"); action != nil {
 		c.log.Debug("Exception rule applied for emote spam",
 			slog.String("user", msg.Chatter.Username),
 			slog.String("message", msg.Message.Text.Text()),
@@ -542,28 +560,30 @@ func (c *Checker) handleEmotes(msg *message.ChatMessage, countSpam int) *ports.C
 		return action
 	}
 
-	if c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsEmotes.MaxEmotesLength > 0 {
+	if ch.Spam.SettingsEmotes.MaxEmotesLength > 0 {
 		emoteCount := max(len(msg.Message.Emotes), count)
-		if emoteCount >= c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsEmotes.MaxEmotesLength {
+		if emoteCount >= ch.Spam.SettingsEmotes.MaxEmotesLength {
 			c.log.Debug("Message exceeds maximum emote count",
 				slog.String("message", msg.Message.Text.Text()),
 				slog.Int("emote_count", emoteCount),
-				slog.Int("max_allowed", c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsEmotes.MaxEmotesLength),
+				slog.Int("max_allowed", ch.Spam.SettingsEmotes.MaxEmotesLength),
 			)
 			return &ports.CheckerAction{
-				Type:       c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsEmotes.MaxEmotesPunishment.Action,
+				Type:       ch.Spam.SettingsEmotes.MaxEmotesPunishment.Action,
 				ReasonMod:  "превышено максимальное кол-во эмоутов в сообщении",
 				ReasonUser: "Твоё сообщение содержит слишком много эмоутов!",
-				Duration:   time.Duration(c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsEmotes.MaxEmotesPunishment.Duration) * time.Second,
+				Duration:   time.Duration(ch.Spam.SettingsEmotes.MaxEmotesPunishment.Duration) * time.Second,
+				Module:     "emote",
+				Rule:       "max_emotes",
 			}
 		}
 	}
 
-	if countSpam < c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsEmotes.MessageLimit {
+	if countSpam < ch.Spam.SettingsEmotes.MessageLimit {
 		c.log.Debug("Spam emote message limit not reached yet",
 			slog.String("message", msg.Message.Text.Text()),
 			slog.Int("countSpam", countSpam),
-			slog.Int("message_limit", c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsEmotes.MessageLimit),
+			slog.Int("message_limit", ch.Spam.SettingsEmotes.MessageLimit),
 		)
 		return &ports.CheckerAction{Type: None}
 	}
@@ -571,11 +591,11 @@ func (c *Checker) handleEmotes(msg *message.ChatMessage, countSpam int) *ports.C
 	countTimeouts, ok := c.timeouts.Get(msg.Chatter.Username, "spam_emote")
 	if !ok {
 		c.timeouts.Push(msg.Chatter.Username, "spam_emote", 0, storage.WithTTL(
-			time.Duration(c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsDefault.DurationResetPunishments)*time.Second),
+			time.Duration(ch.Spam.SettingsDefault.DurationResetPunishments)*time.Second),
 		)
 	}
 
-	action, dur := c.template.Punishment().Get(c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsEmotes.Punishments, countTimeouts)
+	action, dur := c.template.Punishment().Get(ch.Spam.SettingsEmotes.Punishments, countTimeouts)
 	c.timeouts.Update(msg.Chatter.Username, "spam_emote", func(cur int, exists bool) int {
 		if !exists {
 			return 1
@@ -596,13 +616,15 @@ func (c *Checker) handleEmotes(msg *message.ChatMessage, countSpam int) *ports.C
 		ReasonMod:  "спам эмоутов",
 		ReasonUser: "Не спамь!",
 		Duration:   dur,
+		Module:     "emote",
+		Rule:       "spam",
 	}
 }
 
-func (c *Checker) handleExceptions(msg *message.ChatMessage, countSpam int, typeSpam string) *ports.CheckerAction {
-	exceptions, subKey := c.cfg.Channels[msg.Broadcaster.Login].Spam.Exceptions, "except_spam"
+func (c *Checker) handleExceptions(msg *message.ChatMessage, ch *config.Channel, countSpam int, typeSpam string) *ports.CheckerAction {
+	exceptions, subKey := ch.Spam.Exceptions, "except_spam"
 	if typeSpam == "emote" {
-		exceptions, subKey = c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsEmotes.Exceptions, "except_emote"
+		exceptions, subKey = ch.Spam.SettingsEmotes.Exceptions, "except_emote"
 	}
 
 	for word, ex := range exceptions {
@@ -617,7 +639,7 @@ func (c *Checker) handleExceptions(msg *message.ChatMessage, countSpam int, type
 		countTimeouts, ok := c.timeouts.Get(msg.Chatter.Username, subKey)
 		if !ok {
 			c.timeouts.Push(msg.Chatter.Username, subKey, 0, storage.WithTTL(
-				time.Duration(c.cfg.Channels[msg.Broadcaster.Login].Spam.SettingsDefault.DurationResetPunishments)*time.Second),
+				time.Duration(ch.Spam.SettingsDefault.DurationResetPunishments)*time.Second),
 			)
 		}
 		action, dur := c.template.Punishment().Get(ex.Punishments, countTimeouts)
@@ -633,6 +655,8 @@ func (c *Checker) handleExceptions(msg *message.ChatMessage, countSpam int, type
 			ReasonMod:  "спам",
 			ReasonUser: "Не спамь!",
 			Duration:   dur,
+			Module:     "exceptions",
+			Rule:       typeSpam + ":" + word,
 		}
 	}
 
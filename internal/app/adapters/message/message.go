@@ -4,6 +4,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"log/slog"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"time"
 	"twitchspam/internal/app/adapters/file_server"
@@ -14,6 +15,7 @@ import (
 	"twitchspam/internal/app/domain/message"
 	"twitchspam/internal/app/domain/template"
 	"twitchspam/internal/app/domain/trusts"
+	"twitchspam/internal/app/infrastructure/chatlog"
 	"twitchspam/internal/app/infrastructure/config"
 	"twitchspam/internal/app/infrastructure/storage"
 	"twitchspam/internal/app/infrastructure/timers"
@@ -23,49 +25,65 @@ import (
 
 type Message struct {
 	log         logger.Logger
-	cfg         *config.Config
+	manager     *config.Manager
 	api         ports.APIPort
 	stream      ports.StreamPort
 	trusts      ports.TrustsPort
 	template    ports.TemplatePort
 	admin, user ports.CommandPort
 	checker     ports.CheckerPort
+	chatLog     ports.ChatLogPort
 
 	messages ports.StorePort[storage.Message]
 	timeouts ports.StorePort[int]
 }
 
-func New(log logger.Logger, manager *config.Manager, stream ports.StreamPort, api ports.APIPort, client *http.Client) *Message {
+func New(log logger.Logger, manager *config.Manager, stream ports.StreamPort, api ports.APIPort, cacheNukes ports.CachePort[[]ports.NukeState], cacheCategories ports.CachePort[admin.Category], client *http.Client) *Message {
 	cfg := manager.Get()
 	fs := file_server.New(log, client)
 	timer := timers.NewTimingWheel(100*time.Millisecond, 600)
 
 	m := &Message{
-		log:    log,
-		cfg:    cfg,
-		api:    api,
-		trusts: trusts.New(cfg.Channels[stream.ChannelName()].Roles, cfg.GlobalRoles, cfg.Channels[stream.ChannelName()].Trusts),
-		stream: stream,
+		log:     log,
+		manager: manager,
+		api:     api,
+		trusts:  trusts.New(cfg.Channels[stream.ChannelName()].Roles, cfg.GlobalRoles, cfg.Channels[stream.ChannelName()].Trusts),
+		stream:  stream,
 		template: template.New(
 			template.WithAliases(cfg.Channels[stream.ChannelName()].Aliases, cfg.Channels[stream.ChannelName()].AliasGroups, cfg.GlobalAliases),
 			template.WithPlaceholders(stream),
 			template.WithBanwords(cfg.Banwords),
 			template.WithMword(cfg.Channels[stream.ChannelName()].Mword, cfg.Channels[stream.ChannelName()].MwordGroup),
+			template.WithNukeCache(cacheNukes, stream.ChannelName()),
 		),
 		messages: storage.New[storage.Message](50, time.Duration(cfg.Channels[stream.ChannelName()].WindowSecs)*time.Second),
 		timeouts: storage.New[int](15, 0),
 	}
-	m.admin = admin.New(log, manager, stream, m.trusts, api, m.template, fs, timer, m.messages)
+	m.chatLog = chatlog.New(filepath.Join("cache", "chatlog", stream.ChannelName()), cfg.Channels[stream.ChannelName()].ChatLog.RetentionDays, func(err error) {
+		m.log.Error("Failed to write chat log", err)
+	})
+	m.admin = admin.New(log, manager, stream, m.trusts, api, m.template, fs, timer, m.messages, cacheCategories, m.chatLog)
 	m.user = user.New(log, manager, stream, m.trusts, m.template, fs, api)
-	m.checker = checker.NewCheck(log, cfg, stream, m.trusts, m.template, m.messages, m.timeouts, client)
+	m.checker = checker.NewCheck(log, manager, stream, m.trusts, m.template, m.messages, m.timeouts, client)
 
-	for cmd, data := range cfg.Channels[m.stream.ChannelName()].Commands {
-		if data.Timer == nil {
-			continue
-		}
+	addTimer := &admin.AddTimer{Manager: manager, Timers: timer, Stream: stream, Api: api}
+	addTimer.StartAll(cfg.Channels[stream.ChannelName()].Commands)
+	manager.OnReload(func(cfg *config.Config) {
+		admin.ApplyConfig(cfg, m.template, m.trusts, addTimer)
+		m.chatLog.SetRetention(cfg.Channels[stream.ChannelName()].ChatLog.RetentionDays)
+		m.log.Info("Config reloaded from disk")
+	})
 
-		(&admin.AddTimer{Cfg: cfg, Timers: timer, Stream: stream, Api: api}).AddTimer(cmd, data)
-	}
+	profiles := admin.NewProfileSwitcher(log, manager, stream, m.template, m.trusts, addTimer)
+	stream.OnLiveChange(profiles.OnLiveChange)
+	stream.OnCategoryChange(profiles.OnCategoryChange)
+
+	highlights := admin.NewHighlights(log, manager, stream, api, fs)
+	stream.Stats().OnSpike(highlights.OnSpike)
+	stream.Stats().OnStreamEnd(highlights.Finish)
+
+	reporter := admin.NewStreamReporter(log, manager, stream, api, fs)
+	stream.Stats().OnStreamEnd(reporter.Finish)
 
 	return m
 }
@@ -75,6 +93,7 @@ func (m *Message) Check(msg *message.ChatMessage) {
 	m.log.Trace("Processing new message", slog.String("username", msg.Chatter.Username), slog.String("message", msg.Message.Text.Text()))
 	if m.stream.IsLive() {
 		m.stream.Stats().AddMessage(msg.Chatter.Username)
+		m.stream.Stats().AddEmotes(len(msg.Message.Emotes))
 		m.log.Trace("Added message to stream stats", slog.String("channel", m.stream.ChannelName()), slog.String("username", msg.Chatter.Username))
 	}
 
@@ -82,12 +101,18 @@ func (m *Message) Check(msg *message.ChatMessage) {
 	m.messages.Push(msg.Chatter.Username, msg.Message.ID, storage.Message{
 		Data:           msg,
 		Time:           time.Now(),
-		IgnoreAntispam: !m.cfg.Channels[m.stream.ChannelName()].Enabled || !m.template.SpamPause().CanProcess() || !m.cfg.Channels[m.stream.ChannelName()].Spam.SettingsDefault.Enabled,
+		IgnoreAntispam: !m.manager.Get().Channels[m.stream.ChannelName()].Enabled || !m.template.SpamPause().CanProcess() || !m.manager.Get().Channels[m.stream.ChannelName()].Spam.SettingsDefault.Enabled,
 	})
 	endModuleProcessing := time.Since(startModuleProcessing).Seconds()
 	metrics.ModulesProcessingTime.With(prometheus.Labels{"module": "push_message"}).Observe(endModuleProcessing)
 	m.log.Trace("Message pushed to storage", slog.String("username", msg.Chatter.Username), slog.String("message_id", msg.Message.ID))
 
+	startModuleProcessing = time.Now()
+	if err := m.chatLog.Add(msg); err != nil {
+		m.log.Error("Failed to write chat log", err)
+	}
+	metrics.ModulesProcessingTime.With(prometheus.Labels{"module": "chat_log"}).Observe(time.Since(startModuleProcessing).Seconds())
+
 	skip := false
 	for _, prefix := range []string{
 		"!am title ", "!am cat ", "!am mw ", "!am mwg ",
@@ -141,22 +166,27 @@ func (m *Message) Check(msg *message.ChatMessage) {
 	m.getAction(action, msg)
 }
 
+// Close дописывает журнал чата на диск; вызывается при остановке бота.
+func (m *Message) Close() error {
+	return m.chatLog.Close()
+}
+
 func (m *Message) CheckAutomod(msg *message.ChatMessage) {
 	startProcessing := time.Now()
 	if m.stream.IsLive() {
 		m.stream.Stats().AddMessage(msg.Chatter.Username)
 	}
 
-	if !m.cfg.Channels[m.stream.ChannelName()].Enabled || !m.cfg.Channels[m.stream.ChannelName()].Automod.Enabled {
+	if !m.manager.Get().Channels[m.stream.ChannelName()].Enabled || !m.manager.Get().Channels[m.stream.ChannelName()].Automod.Enabled {
 		return
 	}
 
-	if m.cfg.Channels[m.stream.ChannelName()].Automod.Delay > 0 {
-		time.Sleep(time.Duration(m.cfg.Channels[m.stream.ChannelName()].Automod.Delay) * time.Second)
+	if m.manager.Get().Channels[m.stream.ChannelName()].Automod.Delay > 0 {
+		time.Sleep(time.Duration(m.manager.Get().Channels[m.stream.ChannelName()].Automod.Delay) * time.Second)
 	}
 
 	if msg.Message.Text.Text(message.RemoveDuplicateLettersOption) == "(" {
-		err := m.api.ManageHeldAutoModMessage(m.cfg.App.UserID, msg.Message.ID, "ALLOW")
+		err := m.api.ManageHeldAutoModMessage(m.manager.Get().App.UserID, msg.Message.ID, "ALLOW")
 		if err != nil {
 			m.log.Error("Failed to manage held automod", err)
 		}
@@ -170,27 +200,57 @@ func (m *Message) CheckAutomod(msg *message.ChatMessage) {
 }
 
 func (m *Message) getAction(action *ports.CheckerAction, msg *message.ChatMessage) {
+	var err error
 	switch action.Type {
 	case checker.None:
 		return
 	case checker.Ban:
 		m.log.Warn("Ban user", slog.String("username", msg.Chatter.Username), slog.String("text", msg.Message.Text.Text()))
-		m.api.BanUser(m.stream.ChannelName(), m.stream.ChannelID(), msg.Chatter.UserID, action.ReasonMod)
+		err = m.api.BanUser(m.stream.ChannelName(), m.stream.ChannelID(), msg.Chatter.UserID, action.ReasonMod)
 	case checker.Timeout:
 		m.log.Warn("Timeout user", slog.String("username", msg.Chatter.Username), slog.String("text", msg.Message.Text.Text()), slog.Int("duration", int(action.Duration.Seconds())))
-		m.api.TimeoutUser(m.stream.ChannelName(), m.stream.ChannelID(), msg.Chatter.UserID, int(action.Duration.Seconds()), action.ReasonMod)
+		err = m.api.TimeoutUser(m.stream.ChannelName(), m.stream.ChannelID(), msg.Chatter.UserID, int(action.Duration.Seconds()), action.ReasonMod)
 	case checker.Warn:
 		m.log.Warn("Warn user", slog.String("username", msg.Chatter.Username), slog.String("text", msg.Message.Text.Text()))
-		if err := m.api.WarnUser(m.stream.ChannelName(), m.stream.ChannelID(), msg.Chatter.UserID, action.ReasonUser); err != nil {
+		if err = m.api.WarnUser(m.stream.ChannelName(), m.stream.ChannelID(), msg.Chatter.UserID, action.ReasonUser); err != nil {
 			m.log.Error("Failed to warn message on chat", err)
 		}
-		if err := m.api.DeleteChatMessage(m.stream.ChannelName(), m.stream.ChannelID(), msg.Message.ID); err != nil {
-			m.log.Error("Failed to delete message on chat", err)
+		errDel := m.api.DeleteChatMessage(m.stream.ChannelName(), m.stream.ChannelID(), msg.Message.ID)
+		if errDel != nil {
+			m.log.Error("Failed to delete message on chat", errDel)
 		}
 	case checker.Delete:
 		m.log.Warn("Delete message", slog.String("username", msg.Chatter.Username), slog.String("text", msg.Message.Text.Text()))
-		if err := m.api.DeleteChatMessage(m.stream.ChannelName(), m.stream.ChannelID(), msg.Message.ID); err != nil {
+		if err = m.api.DeleteChatMessage(m.stream.ChannelName(), m.stream.ChannelID(), msg.Message.ID); err != nil {
 			m.log.Error("Failed to delete message on chat", err)
 		}
 	}
+
+	metrics.ModerationDecisions.With(prometheus.Labels{
+		"channel": m.stream.ChannelName(),
+		"module":  action.Module,
+		"rule":    action.Rule,
+		"action":  action.Type,
+	}).Inc()
+	if action.Similarity > 0 {
+		metrics.PunishmentSimilarity.With(prometheus.Labels{"channel": m.stream.ChannelName(), "module": action.Module}).Observe(action.Similarity)
+	}
+
+	if m.stream.IsLive() {
+		m.stream.Stats().AddModuleAction(action.Module, action.Type)
+	}
+
+	if action.Module == "nuke" {
+		hit := ports.NukeHit{
+			UserID:   msg.Chatter.UserID,
+			Username: msg.Chatter.Username,
+			Text:     msg.Message.Text.Text(),
+			Action:   action.Type,
+			Source:   "live",
+		}
+		if err != nil {
+			hit.Error = err.Error()
+		}
+		m.template.Nuke().Record(action.Rule, hit)
+	}
 }
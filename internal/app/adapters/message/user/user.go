@@ -122,13 +122,41 @@ func (u *User) handleStats(msg *message.ChatMessage) *ports.AnswerType {
 		case "all":
 			return u.stream.Stats().GetStats()
 		case "top":
+			if len(words) > 2 && words[2] == "all" {
+				count := 0
+				if len(words) > 3 {
+					count, _ = strconv.Atoi(words[3])
+				}
+				return u.stream.Stats().GetTopLifetimeStats(count, u.manager.Get().Channels[u.stream.ChannelName()].Stats.TopPeriodDays)
+			}
+
 			count := 0
 			if len(words) > 2 {
 				count, _ = strconv.Atoi(words[2])
 			}
 			return u.stream.Stats().GetTopStats(count)
+		case "games":
+			return u.stream.Stats().GetGamesStats()
+		case "last":
+			return u.stream.Stats().GetLastStats()
+		case "compare":
+			var dates []time.Time
+			for _, w := range words[2:] {
+				date, ok := parseStatsDate(w)
+				if !ok {
+					return &ports.AnswerType{Text: []string{"неверный формат даты, пример: 18.10.2025!"}, IsReply: true}
+				}
+				dates = append(dates, date)
+			}
+			return u.stream.Stats().GetCompareStats(dates...)
 		default:
+			if date, ok := parseStatsDate(words[1]); ok {
+				return u.stream.Stats().GetStatsByDate(date)
+			}
 			target = words[1]
+			if len(words) > 2 && words[2] == "all" {
+				return u.stream.Stats().GetUserLifetimeStats(target)
+			}
 		}
 	}
 
@@ -348,6 +376,11 @@ func (u *User) allowCommand(command string, limiter *config.Limiter) bool {
 		}); err != nil {
 			u.log.Error("Failed to update command rate limiter", err)
 		}
+
+		// лимитер создан в новой версии конфига
+		if cmd, ok := u.manager.Get().Channels[u.stream.ChannelName()].Commands[command]; ok && cmd.Limiter != nil {
+			limiter = cmd.Limiter
+		}
 	}
 
 	return limiter.Rate == nil || limiter.Rate.Allow()
@@ -365,3 +398,13 @@ func extractUsername(word string) string {
 
 	return word
 }
+
+// parseStatsDate разбирает дату стрима в формате 18.10.2025 или 2025-10-18.
+func parseStatsDate(s string) (time.Time, bool) {
+	for _, layout := range []string{"02.01.2006", time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
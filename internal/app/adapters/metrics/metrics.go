@@ -74,15 +74,43 @@ var (
 		},
 	)
 
-	// ModerationActions - количество банов, мутов и удалений сообщений по каналам.
-	ModerationActions = promauto.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "bot_moderation_actions",
-			Help: "Number of moderation actions per channel",
+	// ModerationActions - количество выполненных ботом банов, мутов, варнов и удалений сообщений по каналам.
+	ModerationActions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bot_moderation_actions_total",
+			Help: "Total number of moderation actions successfully performed by the bot per channel",
 		},
 		[]string{"channel", "action"},
 	)
 
+	// ModerationDecisions - количество наказаний, выданных ботом, по модулям и правилам.
+	ModerationDecisions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bot_moderation_decisions_total",
+			Help: "Total number of punishments issued by the bot per channel, module, rule and action",
+		},
+		[]string{"channel", "module", "rule", "action"},
+	)
+
+	// PunishmentSimilarity - схожесть сообщения с предыдущими в момент наказания спам-модулями.
+	PunishmentSimilarity = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bot_punishment_similarity",
+			Help:    "Similarity of a message to previous messages at punishment time",
+			Buckets: prometheus.LinearBuckets(0.1, 0.1, 10),
+		},
+		[]string{"channel", "module"},
+	)
+
+	// ModerationAPIErrors - количество неудачных запросов модерации к API Twitch.
+	ModerationAPIErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bot_moderation_api_errors_total",
+			Help: "Total number of failed moderation API requests per channel and method",
+		},
+		[]string{"channel", "method"},
+	)
+
 	// UserCommands - количество вызовов пользовательских команд по каналам.
 	UserCommands = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -101,4 +129,41 @@ var (
 		},
 		[]string{"module"},
 	)
+
+	// CacheFlushDuration - время записи персистентного кэша на диск.
+	CacheFlushDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bot_cache_flush_seconds",
+			Help:    "Time to write a persistent cache to disk",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+		},
+		[]string{"cache"},
+	)
+
+	// CacheFlushBytes - размер последней записи персистентного кэша.
+	CacheFlushBytes = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bot_cache_flush_bytes",
+			Help: "Size of the last persistent cache write in bytes",
+		},
+		[]string{"cache"},
+	)
+
+	// CacheFlushErrors - количество неудачных записей персистентного кэша.
+	CacheFlushErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bot_cache_flush_errors_total",
+			Help: "Total number of failed persistent cache writes",
+		},
+		[]string{"cache"},
+	)
+
+	// CacheWriteErrors - количество неудачных записей кэша во встроенную базу.
+	CacheWriteErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bot_cache_write_errors_total",
+			Help: "Total number of failed cache writes to the embedded database",
+		},
+		[]string{"cache", "op"},
+	)
 )
@@ -16,10 +16,10 @@ import (
 )
 
 type Twitch struct {
-	log    logger.Logger
-	cfg    *config.Config
-	client *http.Client
-	pool   *TwitchPool
+	log     logger.Logger
+	manager *config.Manager
+	client  *http.Client
+	pool    *TwitchPool
 }
 
 type TwitchPool struct {
@@ -30,9 +30,9 @@ type TwitchPool struct {
 
 func NewTwitch(log logger.Logger, manager *config.Manager, client *http.Client, workerCount int) *Twitch {
 	t := &Twitch{
-		log:    log,
-		cfg:    manager.Get(),
-		client: client,
+		log:     log,
+		manager: manager,
+		client:  client,
 		pool: &TwitchPool{
 			tasks:    make(chan func(), 300),
 			shutdown: make(chan struct{}),
@@ -79,9 +79,9 @@ func (t *Twitch) doTwitchRequest(ctx context.Context, reqData twitchRequest, tar
 		return 0, err
 	}
 
-	auth, clientID := t.cfg.App.OAuth, t.cfg.App.ClientID
+	auth, clientID := t.manager.Get().App.OAuth, t.manager.Get().App.ClientID
 	if reqData.Token != nil {
-		auth, clientID = reqData.Token.AccessToken, t.cfg.UserAccess.ClientID
+		auth, clientID = reqData.Token.AccessToken, t.manager.Get().UserAccess.ClientID
 	}
 
 	req.Header.Set("Authorization", "Bearer "+auth)
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
@@ -54,7 +55,7 @@ func (t *Twitch) ValidateToken(ctx context.Context, accessToken string) error {
 }
 
 func (t *Twitch) ensureUserToken(ctx context.Context, broadcasterID string) (*config.UserTokens, error) {
-	token, ok := t.cfg.UsersTokens[broadcasterID]
+	token, ok := t.manager.Get().UsersTokens[broadcasterID]
 	if !ok {
 		return nil, ErrUserAuthNotCompleted
 	}
@@ -71,7 +72,7 @@ func (t *Twitch) ensureUserToken(ctx context.Context, broadcasterID string) (*co
 			ExpiresIn:    resp.ExpiresIn,
 			ObtainedAt:   time.Now(),
 		}
-		t.cfg.UsersTokens[broadcasterID] = newToken
+		t.saveUserTokens(broadcasterID, newToken)
 
 		return newToken, nil
 	}
@@ -79,6 +80,14 @@ func (t *Twitch) ensureUserToken(ctx context.Context, broadcasterID string) (*co
 	return token, nil
 }
 
+// saveUserTokens сохраняет (nil - удаляет) токены до возврата: после обновления старый refresh-токен
+// недействителен. SetUserTokens не берёт блокировку конфига, поэтому вызов безопасен и из команд внутри UpdateBy.
+func (t *Twitch) saveUserTokens(broadcasterID string, token *config.UserTokens) {
+	if err := t.manager.SetUserTokens(broadcasterID, token); err != nil {
+		t.log.Error("Failed to save user tokens", err, slog.String("broadcaster_id", broadcasterID))
+	}
+}
+
 type TokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
@@ -93,8 +102,8 @@ func (t *Twitch) RefreshToken(ctx context.Context, broadcasterID string, token *
 	data := url.Values{}
 	data.Set("grant_type", "refresh_token")
 	data.Set("refresh_token", token.RefreshToken)
-	data.Set("client_id", t.cfg.UserAccess.ClientID)
-	data.Set("client_secret", t.cfg.UserAccess.ClientSecret)
+	data.Set("client_id", t.manager.Get().UserAccess.ClientID)
+	data.Set("client_secret", t.manager.Get().UserAccess.ClientSecret)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://id.twitch.tv/oauth2/token", strings.NewReader(data.Encode()))
 	if err != nil {
@@ -109,7 +118,7 @@ func (t *Twitch) RefreshToken(ctx context.Context, broadcasterID string, token *
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusBadRequest {
-		delete(t.cfg.UsersTokens, broadcasterID)
+		t.saveUserTokens(broadcasterID, nil)
 		return nil, ErrUserAuthNotCompleted
 	}
 
@@ -31,7 +31,7 @@ func (t *Twitch) SendChatMessages(channelID string, msgs *ports.AnswerType) {
 func (t *Twitch) SendChatMessage(channelID, message string) error {
 	reqBody := ChatMessageRequest{
 		BroadcasterID: channelID,
-		SenderID:      t.cfg.App.UserID,
+		SenderID:      t.manager.Get().App.UserID,
 		Message:       message,
 	}
 
@@ -82,7 +82,7 @@ func (t *Twitch) SendChatAnnouncement(channelID, message, color string) error {
 
 	params := url.Values{}
 	params.Add("broadcaster_id", channelID)
-	params.Add("moderator_id", t.cfg.App.UserID)
+	params.Add("moderator_id", t.manager.Get().App.UserID)
 
 	if _, err := t.doTwitchRequest(context.Background(), twitchRequest{
 		Method: http.MethodPost,
@@ -99,7 +99,7 @@ func (t *Twitch) SendChatAnnouncement(channelID, message, color string) error {
 func (t *Twitch) DeleteChatMessage(channelName, channelID, messageID string) error {
 	params := url.Values{}
 	params.Set("broadcaster_id", channelID)
-	params.Set("moderator_id", t.cfg.App.UserID)
+	params.Set("moderator_id", t.manager.Get().App.UserID)
 	if messageID != "" {
 		params.Set("message_id", messageID)
 	}
@@ -110,6 +110,7 @@ func (t *Twitch) DeleteChatMessage(channelName, channelID, messageID string) err
 		Token:  nil,
 		Body:   nil,
 	}, nil); err != nil {
+		moderationAPIError(channelName, "DeleteChatMessage")
 		return err
 	}
 
@@ -117,7 +118,18 @@ func (t *Twitch) DeleteChatMessage(channelName, channelID, messageID string) err
 	return nil
 }
 
-func (t *Twitch) TimeoutUser(channelName, channelID, userID string, duration int, reason string) {
+func (t *Twitch) TimeoutUser(channelName, channelID, userID string, duration int, reason string) error {
+	if err := t.timeoutUser(channelID, userID, duration, reason); err != nil {
+		moderationAPIError(channelName, "TimeoutUser")
+		return err
+	}
+
+	metrics.ModerationActions.With(prometheus.Labels{"channel": channelName, "action": "timeout"}).Inc()
+	return nil
+}
+
+// timeoutUser отправляет запрос мута; duration == 0 - бан.
+func (t *Twitch) timeoutUser(channelID, userID string, duration int, reason string) error {
 	reqBody := TimeoutRequest{
 		Data: TimeoutData{
 			UserID:   userID,
@@ -129,12 +141,12 @@ func (t *Twitch) TimeoutUser(channelName, channelID, userID string, duration int
 	bodyBytes, err := json.Marshal(reqBody)
 	if err != nil {
 		t.log.Error("Failed to marshel body", err)
-		return
+		return err
 	}
 
 	params := url.Values{}
 	params.Set("broadcaster_id", channelID)
-	params.Set("moderator_id", t.cfg.App.UserID)
+	params.Set("moderator_id", t.manager.Get().App.UserID)
 
 	if _, err := t.doTwitchRequest(context.Background(), twitchRequest{
 		Method: http.MethodPost,
@@ -143,14 +155,24 @@ func (t *Twitch) TimeoutUser(channelName, channelID, userID string, duration int
 		Body:   bytes.NewReader(bodyBytes),
 	}, nil); err != nil {
 		t.log.Error("Failed to timeout user", err, slog.Any("data", reqBody.Data))
-		return
+		return err
 	}
 
 	t.log.Info("Timeout applied successfully", slog.String("user_id", userID), slog.Int("duration", duration), slog.String("reason", reason))
-	metrics.ModerationActions.With(prometheus.Labels{"channel": channelName, "action": "timeout"}).Inc()
+	return nil
 }
 
 func (t *Twitch) WarnUser(channelName, broadcasterID, userID, reason string) error {
+	if err := t.warnUser(broadcasterID, userID, reason); err != nil {
+		moderationAPIError(channelName, "WarnUser")
+		return err
+	}
+
+	metrics.ModerationActions.With(prometheus.Labels{"channel": channelName, "action": "warn"}).Inc()
+	return nil
+}
+
+func (t *Twitch) warnUser(broadcasterID, userID, reason string) error {
 	reqBody := WarnRequest{
 		Data: WarnData{
 			UserID: userID,
@@ -192,25 +214,31 @@ func (t *Twitch) WarnUser(channelName, broadcasterID, userID, reason string) err
 	}
 
 	t.log.Info("User warning issued successfully",
-		slog.String("channel", channelName),
+		slog.String("broadcaster_id", broadcasterID),
 		slog.String("user_id", userID),
 		slog.String("reason", reason))
-
-	metrics.ModerationActions.With(prometheus.Labels{"channel": channelName, "action": "warn"}).Inc()
 	return nil
 }
 
-func (t *Twitch) BanUser(channelName, channelID, userID string, reason string) {
-	t.TimeoutUser(channelName, channelID, userID, 0, reason)
+func (t *Twitch) BanUser(channelName, channelID, userID string, reason string) error {
+	if err := t.timeoutUser(channelID, userID, 0, reason); err != nil {
+		moderationAPIError(channelName, "BanUser")
+		return err
+	}
 
 	metrics.ModerationActions.With(prometheus.Labels{"channel": channelName, "action": "ban"}).Inc()
-	metrics.ModerationActions.With(prometheus.Labels{"channel": channelName, "action": "timeout"}).Dec()
+	return nil
+}
+
+// moderationAPIError учитывает неудачный запрос модерации, откуда бы он ни пришёл: антиспам, массбан или команды.
+func moderationAPIError(channelName, method string) {
+	metrics.ModerationAPIErrors.With(prometheus.Labels{"channel": channelName, "method": method}).Inc()
 }
 
-func (t *Twitch) UnbanUser(channelID, userID string) {
+func (t *Twitch) UnbanUser(channelID, userID string) error {
 	params := url.Values{}
 	params.Set("broadcaster_id", channelID)
-	params.Set("moderator_id", t.cfg.App.UserID)
+	params.Set("moderator_id", t.manager.Get().App.UserID)
 	params.Set("user_id", userID)
 
 	if _, err := t.doTwitchRequest(context.Background(), twitchRequest{
@@ -220,8 +248,9 @@ func (t *Twitch) UnbanUser(channelID, userID string) {
 		Body:   nil,
 	}, nil); err != nil {
 		t.log.Error("Failed to unban user", err, slog.String("user_id", userID))
-		return
+		return err
 	}
 
 	t.log.Info("User unbanned successfully", slog.String("user_id", userID))
+	return nil
 }
@@ -0,0 +1,92 @@
+package api_test
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"twitchspam/internal/app/adapters/metrics"
+	"twitchspam/internal/app/adapters/platform/twitch/api"
+	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/pkg/logger"
+)
+
+const testConfig = `{"app": {"oauth": "oauth-token", "client_id": "client-id", "username": "bot", "user_id": "1", "auth_token": "auth-token"}}`
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newTestTwitch создаёт клиент API, на все запросы которого Twitch отвечает status.
+func newTestTwitch(t *testing.T, status int) *api.Twitch {
+	t.Chdir(t.TempDir())
+
+	require.NoError(t, os.MkdirAll("configs", 0750))
+	require.NoError(t, os.WriteFile(filepath.Join("configs", "config.json"), []byte(testConfig), 0644))
+
+	m, err := config.New()
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(`{"error": "Bad Request", "status": 400, "message": "user is not bannable"}`)),
+			Header:     make(http.Header),
+			Request:    req,
+		}, nil
+	})}
+	return api.NewTwitch(logger.New(), m, client, 0)
+}
+
+func apiErrors(channel, method string) float64 {
+	return testutil.ToFloat64(metrics.ModerationAPIErrors.With(prometheus.Labels{"channel": channel, "method": method}))
+}
+
+func moderationActions(channel, action string) float64 {
+	return testutil.ToFloat64(metrics.ModerationActions.With(prometheus.Labels{"channel": channel, "action": action}))
+}
+
+func TestModeration_CountsAPIErrors(t *testing.T) {
+	tw := newTestTwitch(t, http.StatusBadRequest)
+
+	// ошибки учитываются для любого вызывающего, а не только для антиспама
+	require.Error(t, tw.BanUser("api_errors", "10", "20", "массбан"))
+	require.Error(t, tw.TimeoutUser("api_errors", "10", "20", 600, "массбан"))
+	require.Error(t, tw.TimeoutUser("api_errors", "10", "20", 600, "массбан"))
+	require.Error(t, tw.DeleteChatMessage("api_errors", "10", "msg-1"))
+	require.Error(t, tw.WarnUser("api_errors", "10", "20", "флуд"))
+
+	assert.Equal(t, float64(1), apiErrors("api_errors", "BanUser"))
+	assert.Equal(t, float64(2), apiErrors("api_errors", "TimeoutUser"))
+	assert.Equal(t, float64(1), apiErrors("api_errors", "DeleteChatMessage"))
+	assert.Equal(t, float64(1), apiErrors("api_errors", "WarnUser"))
+
+	for _, action := range []string{"ban", "timeout", "delete", "warn"} {
+		assert.Zero(t, moderationActions("api_errors", action), action)
+	}
+}
+
+func TestModeration_CountsActions(t *testing.T) {
+	tw := newTestTwitch(t, http.StatusNoContent)
+
+	require.NoError(t, tw.BanUser("api_ok", "10", "20", "спам"))
+	require.NoError(t, tw.TimeoutUser("api_ok", "10", "20", 600, "спам"))
+	require.NoError(t, tw.DeleteChatMessage("api_ok", "10", "msg-1"))
+
+	// бан не учитывается как мут
+	assert.Equal(t, float64(1), moderationActions("api_ok", "ban"))
+	assert.Equal(t, float64(1), moderationActions("api_ok", "timeout"))
+	assert.Equal(t, float64(1), moderationActions("api_ok", "delete"))
+
+	for _, method := range []string{"BanUser", "TimeoutUser", "DeleteChatMessage"} {
+		assert.Zero(t, apiErrors("api_ok", method), method)
+	}
+}
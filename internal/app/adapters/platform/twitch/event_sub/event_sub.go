@@ -22,10 +22,10 @@ import (
 )
 
 type EventSub struct {
-	log logger.Logger
-	cfg *config.Config
-	api ports.APIPort
-	irc ports.IRCPort
+	log     logger.Logger
+	manager *config.Manager
+	api     ports.APIPort
+	irc     ports.IRCPort
 
 	mu        sync.Mutex
 	channels  map[string]channel
@@ -40,10 +40,10 @@ type channel struct {
 	message  ports.MessagePort
 }
 
-func NewTwitch(log logger.Logger, cfg *config.Config, api ports.APIPort, irc ports.IRCPort, client *http.Client) *EventSub {
+func NewTwitch(log logger.Logger, manager *config.Manager, api ports.APIPort, irc ports.IRCPort, client *http.Client) *EventSub {
 	es := &EventSub{
 		log:      log,
-		cfg:      cfg,
+		manager:  manager,
 		api:      api,
 		irc:      irc,
 		client:   client,
@@ -235,6 +235,10 @@ func (es *EventSub) handleMessage(ctx context.Context, msgBytes []byte) {
 				c.stream.SetIslive(true)
 				c.stream.Stats().Reset()
 				c.stream.Stats().SetStartTime(time.Now())
+				c.stream.Stats().SetStreamID(sm.ID)
+				if category := c.stream.Category(); category != "" {
+					c.stream.Stats().AddCategoryChange(category, time.Now())
+				}
 			}
 		case "stream.offline":
 			var sm StreamMessageEvent
@@ -248,8 +252,9 @@ func (es *EventSub) handleMessage(ctx context.Context, msgBytes []byte) {
 				c.stream.SetIslive(false)
 				c.stream.Stats().SetEndTime(time.Now())
 
-				if es.cfg.Channels[sm.BroadcasterUserLogin].Enabled {
-					es.api.SendChatMessages(c.stream.ChannelID(), c.stream.Stats().GetStats())
+				cfg := es.manager.Get()
+				if cfg.Channels[sm.BroadcasterUserLogin].Enabled {
+					es.api.SendChatMessages(c.stream.ChannelID(), c.stream.Stats().GetEndStats(cfg.Channels[sm.BroadcasterUserLogin].Stats.ModReport, cfg.App.Username))
 				}
 			}
 		case "channel.update":
@@ -313,8 +318,8 @@ func (es *EventSub) subscribeEvent(ctx context.Context, eventType, version strin
 		return fmt.Errorf("create subscription request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+es.cfg.App.OAuth)
-	req.Header.Set("Client-Id", es.cfg.App.ClientID)
+	req.Header.Set("Authorization", "Bearer "+es.manager.Get().App.OAuth)
+	req.Header.Set("Client-Id", es.manager.Get().App.ClientID)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := es.client.Do(req)
@@ -366,7 +371,7 @@ func (es *EventSub) convertMap(msgEvent ChatMessageEvent) *message.ChatMessage {
 	}
 
 	total := emoteChars + textChars
-	emoteOnly := total > 0 && float64(emoteChars)/float64(total) >= es.cfg.Channels[msgEvent.BroadcasterUserLogin].Spam.SettingsEmotes.EmoteThreshold
+	emoteOnly := total > 0 && float64(emoteChars)/float64(total) >= es.manager.Get().Channels[msgEvent.BroadcasterUserLogin].Spam.SettingsEmotes.EmoteThreshold
 
 	msg := &message.ChatMessage{
 		Broadcaster: message.Broadcaster{
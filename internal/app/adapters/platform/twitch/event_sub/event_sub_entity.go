@@ -28,6 +28,7 @@ type EventSubEnvelope struct {
 }
 
 type StreamMessageEvent struct {
+	ID                   string `json:"id"` // идентификатор стрима, только в stream.online
 	BroadcasterUserID    string `json:"broadcaster_user_id"`
 	BroadcasterUserLogin string `json:"broadcaster_user_login"`
 }
@@ -146,19 +147,28 @@ type ChannelModerateEvent struct {
 	Timeout           *struct {
 		UserID    string    `json:"user_id"`
 		Username  string    `json:"user_name"`
+		UserLogin string    `json:"user_login"`
 		ExpiresAt time.Time `json:"expires_at"`
 		Reason    string    `json:"reason"`
 	} `json:"timeout,omitempty"`
 	Ban *struct {
-		UserID   string `json:"user_id"`
-		Username string `json:"user_name"`
-		Reason   string `json:"reason"`
+		UserID    string `json:"user_id"`
+		Username  string `json:"user_name"`
+		UserLogin string `json:"user_login"`
+		Reason    string `json:"reason"`
 	} `json:"ban,omitempty"`
 	Unban *struct {
 		UserID string `json:"user_id"`
 	} `json:"unban,omitempty"`
 	Warn *struct {
-		UserID string `json:"user_id"`
-		Reason string `json:"reason"`
+		UserID    string `json:"user_id"`
+		Username  string `json:"user_name"`
+		UserLogin string `json:"user_login"`
+		Reason    string `json:"reason"`
 	} `json:"warn,omitempty"`
+	Delete *struct {
+		UserID    string `json:"user_id"`
+		Username  string `json:"user_name"`
+		UserLogin string `json:"user_login"`
+	} `json:"delete,omitempty"`
 }
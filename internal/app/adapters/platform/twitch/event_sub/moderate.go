@@ -4,6 +4,8 @@ import (
 	"log/slog"
 )
 
+// checkModerate учитывает действия модераторов; наказания чаттеров записываются по логину,
+// так как отображаемое имя может отличаться от логина не только регистром.
 func (es *EventSub) checkModerate(modEvent ChannelModerateEvent) {
 	c, ok := es.channels[modEvent.BroadcasterUserID]
 	if !ok {
@@ -14,14 +16,28 @@ func (es *EventSub) checkModerate(modEvent ChannelModerateEvent) {
 	case "delete":
 		es.log.Info("The moderator deleted the user's message", slog.String("mod_username", modEvent.ModeratorUserName))
 		c.stream.Stats().AddDeleted(modEvent.ModeratorUserName)
+		if modEvent.Delete != nil {
+			c.stream.Stats().AddPunishment(modEvent.ModeratorUserName, modEvent.Delete.UserLogin, modEvent.Action)
+		}
 	case "timeout":
+		if modEvent.Timeout == nil {
+			return
+		}
 		es.log.Info("The moderator muted the user", slog.String("mod_username", modEvent.ModeratorUserName), slog.String("username", modEvent.Timeout.Username), slog.Time("expires_at", modEvent.Timeout.ExpiresAt), slog.String("reason", modEvent.Timeout.Reason))
 		c.stream.Stats().AddTimeout(modEvent.ModeratorUserName)
+		c.stream.Stats().AddPunishment(modEvent.ModeratorUserName, modEvent.Timeout.UserLogin, modEvent.Action)
 	case "warn":
 		es.log.Info("The moderator warned the user", slog.String("mod_username", modEvent.ModeratorUserName))
 		c.stream.Stats().AddWarn(modEvent.ModeratorUserName)
+		if modEvent.Warn != nil {
+			c.stream.Stats().AddPunishment(modEvent.ModeratorUserName, modEvent.Warn.UserLogin, modEvent.Action)
+		}
 	case "ban":
+		if modEvent.Ban == nil {
+			return
+		}
 		es.log.Info("The moderator banned the user", slog.String("mod_username", modEvent.ModeratorUserName), slog.String("username", modEvent.Ban.Username), slog.String("reason", modEvent.Ban.Reason))
 		c.stream.Stats().AddBan(modEvent.ModeratorUserName)
+		c.stream.Stats().AddPunishment(modEvent.ModeratorUserName, modEvent.Ban.UserLogin, modEvent.Action)
 	}
 }
@@ -19,7 +19,7 @@ func (es *EventSub) subscribeEvents(ctx context.Context, sessionID, channelID st
 			version: "1",
 			condition: map[string]string{
 				"broadcaster_user_id": channelID,
-				"user_id":             es.cfg.App.UserID,
+				"user_id":             es.manager.Get().App.UserID,
 			},
 		},
 		{
@@ -27,7 +27,7 @@ func (es *EventSub) subscribeEvents(ctx context.Context, sessionID, channelID st
 			version: "1",
 			condition: map[string]string{
 				"broadcaster_user_id": channelID,
-				"moderator_user_id":   es.cfg.App.UserID,
+				"moderator_user_id":   es.manager.Get().App.UserID,
 			},
 		},
 		{
@@ -56,7 +56,7 @@ func (es *EventSub) subscribeEvents(ctx context.Context, sessionID, channelID st
 			version: "2",
 			condition: map[string]string{
 				"broadcaster_user_id": channelID,
-				"moderator_user_id":   es.cfg.App.UserID,
+				"moderator_user_id":   es.manager.Get().App.UserID,
 			},
 		},
 	}
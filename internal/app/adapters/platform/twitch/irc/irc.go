@@ -15,8 +15,8 @@ import (
 )
 
 type IRC struct {
-	log logger.Logger
-	cfg *config.Config
+	log     logger.Logger
+	manager *config.Manager
 
 	mu       sync.Mutex
 	channels map[string]bool
@@ -28,10 +28,10 @@ type IRC struct {
 	reader *bufio.Reader
 }
 
-func New(log logger.Logger, cfg *config.Config, ttl time.Duration, client *http.Client) *IRC {
+func New(log logger.Logger, manager *config.Manager, ttl time.Duration, client *http.Client) *IRC {
 	i := &IRC{
 		log:      log,
-		cfg:      cfg,
+		manager:  manager,
 		channels: make(map[string]bool),
 		chans:    make(map[string]chan bool),
 		ttl:      ttl,
@@ -106,8 +106,8 @@ func (i *IRC) connectAndListen() error {
 	i.conn = conn
 	i.reader = bufio.NewReader(i.conn)
 
-	i.write("PASS oauth:" + i.cfg.App.OAuth)
-	i.write("NICK " + i.cfg.App.Username)
+	i.write("PASS oauth:" + i.manager.Get().App.OAuth)
+	i.write("NICK " + i.manager.Get().App.Username)
 	i.write("CAP REQ :twitch.tv/tags")
 	i.write("CAP REQ :twitch.tv/membership")
 	i.write("CAP REQ :twitch.tv/commands")
@@ -13,7 +13,6 @@ import (
 
 type Twitch struct {
 	log    logger.Logger
-	cfg    *config.Config
 	client *http.Client
 
 	api      ports.APIPort
@@ -22,16 +21,13 @@ type Twitch struct {
 }
 
 func New(log logger.Logger, manager *config.Manager, client *http.Client) *Twitch {
-	cfg := manager.Get()
-
 	t := &Twitch{
 		log:    log,
-		cfg:    cfg,
 		client: client,
 	}
 	t.api = api.NewTwitch(log, manager, client, 5)
-	t.irc = irc.New(log, cfg, 1*time.Second, client)
-	t.eventSub = event_sub.NewTwitch(t.log, t.cfg, t.api, t.irc, t.client)
+	t.irc = irc.New(log, manager, 1*time.Second, client)
+	t.eventSub = event_sub.NewTwitch(t.log, manager, t.api, t.irc, t.client)
 
 	return t
 }
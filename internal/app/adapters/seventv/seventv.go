@@ -14,21 +14,21 @@ import (
 )
 
 type SevenTV struct {
-	log    logger.Logger
-	cfg    *config.Config
-	stream ports.StreamPort
-	client *http.Client
+	log     logger.Logger
+	manager *config.Manager
+	stream  ports.StreamPort
+	client  *http.Client
 
 	setID    string
 	emoteSet map[string]struct{}
 }
 
-func New(log logger.Logger, cfg *config.Config, stream ports.StreamPort, client *http.Client) *SevenTV {
+func New(log logger.Logger, manager *config.Manager, stream ports.StreamPort, client *http.Client) *SevenTV {
 	log.Trace("Initializing SevenTV instance")
 
 	s := &SevenTV{
 		log:      log,
-		cfg:      cfg,
+		manager:  manager,
 		stream:   stream,
 		emoteSet: make(map[string]struct{}),
 		client:   client,
@@ -148,15 +148,15 @@ func (sv *SevenTV) EmoteStats(words []string) (count int, onlyEmotes bool) {
 		slog.Int("emote_chars", emoteChars),
 		slog.Int("text_chars", textChars),
 		slog.Float64("ratio", ratio),
-		slog.Float64("threshold", sv.cfg.Channels[sv.stream.ChannelName()].Spam.SettingsEmotes.EmoteThreshold),
+		slog.Float64("threshold", sv.manager.Get().Channels[sv.stream.ChannelName()].Spam.SettingsEmotes.EmoteThreshold),
 	)
 
-	if ratio >= sv.cfg.Channels[sv.stream.ChannelName()].Spam.SettingsEmotes.EmoteThreshold {
+	if ratio >= sv.manager.Get().Channels[sv.stream.ChannelName()].Spam.SettingsEmotes.EmoteThreshold {
 		onlyEmotes = true
 		sv.log.Debug("Message detected as emote-only",
 			slog.Float64("ratio", ratio),
 			slog.Int("emote_count", count),
-			slog.Float64("threshold", sv.cfg.Channels[sv.stream.ChannelName()].Spam.SettingsEmotes.EmoteThreshold),
+			slog.Float64("threshold", sv.manager.Get().Channels[sv.stream.ChannelName()].Spam.SettingsEmotes.EmoteThreshold),
 		)
 	} else {
 		sv.log.Trace("Message contains mixed content",
@@ -0,0 +1,185 @@
+package stream
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"twitchspam/internal/app/domain"
+	"twitchspam/internal/app/ports"
+)
+
+// archiveLimit - сколько последних сессий хранится в архиве канала.
+const archiveLimit = 200
+
+func (s *Stats) SetStreamID(id string) {
+	s.mu.Lock()
+	s.stats.StreamID = id
+	s.mu.Unlock()
+}
+
+// archiveKey - ключ сессии в архиве: <канал>/id:<идентификатор стрима>, а если он неизвестен - <канал>/t:<время начала>.
+func archiveKey(channel string, ss *SessionStats) string {
+	if ss.StreamID != "" {
+		return channel + "/id:" + ss.StreamID
+	}
+	return archiveTimeKey(channel, ss.StartStreamTime)
+}
+
+func archiveTimeKey(channel string, start time.Time) string {
+	return channel + "/t:" + start.UTC().Format(time.RFC3339Nano)
+}
+
+// archiveLocked сохраняет текущую сессию в архив отдельной записью, поэтому повторное сохранение
+// перезаписывает только её. Если идентификатор стрима стал известен позже, запись по времени начала удаляется.
+func (s *Stats) archiveLocked() {
+	if s.archive == nil || s.stats.StartStreamTime.IsZero() {
+		return
+	}
+
+	s.archive.Set(archiveKey(s.channelName, &s.stats), s.stats.clone())
+	if s.stats.StreamID != "" {
+		s.archive.ClearKey(archiveTimeKey(s.channelName, s.stats.StartStreamTime))
+	}
+
+	keys := s.archive.Keys(s.channelName + "/")
+	if len(keys) <= archiveLimit {
+		return
+	}
+
+	for _, ss := range s.archived()[:len(keys)-archiveLimit] {
+		s.archive.ClearKey(archiveKey(s.channelName, &ss))
+	}
+}
+
+// archived возвращает завершённые сессии канала от старых к новым.
+func (s *Stats) archived() []SessionStats {
+	if s.archive == nil {
+		return nil
+	}
+
+	keys := s.archive.Keys(s.channelName + "/")
+	sessions := make([]SessionStats, 0, len(keys))
+	for _, key := range keys {
+		if ss, ok := s.archive.Get(key); ok {
+			sessions = append(sessions, ss)
+		}
+	}
+
+	slices.SortFunc(sessions, func(a, b SessionStats) int {
+		return a.StartStreamTime.Compare(b.StartStreamTime)
+	})
+	return sessions
+}
+
+// MigrateArchive переносит архив канала из прежнего формата (все сессии одной записью) в отдельные записи.
+func MigrateArchive(channel string, legacy ports.CachePort[[]SessionStats], archive ports.CachePort[SessionStats]) int {
+	sessions, ok := legacy.Get(channel)
+	if !ok {
+		return 0
+	}
+
+	for _, ss := range sessions {
+		archive.Set(archiveKey(channel, &ss), ss)
+	}
+	legacy.ClearKey(channel)
+	return len(sessions)
+}
+
+func (s *Stats) GetLastStats() *ports.AnswerType {
+	sessions := s.archived()
+	if len(sessions) == 0 {
+		return &ports.AnswerType{Text: []string{"архив стримов пуст!"}, IsReply: false}
+	}
+
+	last := sessions[len(sessions)-1]
+	return &ports.AnswerType{Text: []string{last.archiveSummary()}, IsReply: false}
+}
+
+// GetStatsByDate возвращает статистику стримов, начавшихся в указанный день (по местному времени).
+func (s *Stats) GetStatsByDate(date time.Time) *ports.AnswerType {
+	sessions := sessionsByDate(s.archived(), date)
+	if len(sessions) == 0 {
+		return &ports.AnswerType{Text: []string{fmt.Sprintf("нет стримов за %s!", date.Format("02.01.2006"))}, IsReply: false}
+	}
+
+	text := make([]string, 0, len(sessions))
+	for _, ss := range sessions {
+		text = append(text, ss.archiveSummary())
+	}
+	return &ports.AnswerType{Text: text, IsReply: false}
+}
+
+// GetCompareStats сравнивает два стрима: последний с предыдущим или первые стримы указанных дней.
+func (s *Stats) GetCompareStats(dates ...time.Time) *ports.AnswerType {
+	sessions := s.archived()
+
+	var a, b SessionStats
+	switch len(dates) {
+	case 0:
+		if len(sessions) < 2 {
+			return &ports.AnswerType{Text: []string{"для сравнения нужно хотя бы два стрима в архиве!"}, IsReply: false}
+		}
+		a, b = sessions[len(sessions)-2], sessions[len(sessions)-1]
+	case 2:
+		first, second := sessionsByDate(sessions, dates[0]), sessionsByDate(sessions, dates[1])
+		if len(first) == 0 || len(second) == 0 {
+			return &ports.AnswerType{Text: []string{"стрим за указанную дату не найден!"}, IsReply: false}
+		}
+		a, b = first[0], second[0]
+	default:
+		return &ports.AnswerType{Text: []string{"не указан параметр!"}, IsReply: true}
+	}
+
+	countA, deletesA, timeoutsA, bansA, _ := a.aggregate()
+	countB, deletesB, timeoutsB, bansB, _ := b.aggregate()
+	durA, durB := a.EndStreamTime.Sub(a.StartStreamTime), b.EndStreamTime.Sub(b.StartStreamTime)
+
+	parts := []string{
+		fmt.Sprintf("длительность: %s → %s%s", domain.FormatDuration(durA), domain.FormatDuration(durB), formatDelta(durA.Seconds(), durB.Seconds())),
+		fmt.Sprintf("средний онлайн: %.0f → %.0f%s", a.avgViewers(), b.avgViewers(), formatDelta(a.avgViewers(), b.avgViewers())),
+		fmt.Sprintf("максимальный онлайн: %d → %d%s", a.Online.MaxViewers, b.Online.MaxViewers, formatDelta(float64(a.Online.MaxViewers), float64(b.Online.MaxViewers))),
+		fmt.Sprintf("всего сообщений: %d → %d%s", countA, countB, formatDelta(float64(countA), float64(countB))),
+		fmt.Sprintf("кол-во чаттеров: %d → %d%s", len(a.CountMessages), len(b.CountMessages), formatDelta(float64(len(a.CountMessages)), float64(len(b.CountMessages)))),
+		fmt.Sprintf("кол-во банов: %d → %d", bansA, bansB),
+		fmt.Sprintf("кол-во мутов: %d → %d", timeoutsA, timeoutsB),
+		fmt.Sprintf("кол-во удаленных сообщений: %d → %d", deletesA, deletesB),
+	}
+
+	msg := fmt.Sprintf("сравнение стримов %s и %s: %s",
+		a.StartStreamTime.In(time.Local).Format("02.01.2006"),
+		b.StartStreamTime.In(time.Local).Format("02.01.2006"),
+		strings.Join(parts, " • "),
+	)
+	return &ports.AnswerType{Text: []string{msg}, IsReply: false}
+}
+
+// archiveSummary - статистика сессии из архива с датой и категориями.
+func (ss *SessionStats) archiveSummary() string {
+	msg := fmt.Sprintf("стрим %s - %s", ss.StartStreamTime.In(time.Local).Format("02.01.2006 15:04"), ss.summary())
+	if gaps := ss.formatGaps(); gaps != "" {
+		msg += fmt.Sprintf(" (статистика отсутствовала %s)", gaps)
+	}
+	return msg
+}
+
+func sessionsByDate(sessions []SessionStats, date time.Time) []SessionStats {
+	y, m, d := date.Date()
+
+	var out []SessionStats
+	for _, ss := range sessions {
+		sy, sm, sd := ss.StartStreamTime.In(time.Local).Date()
+		if sy == y && sm == m && sd == d {
+			out = append(out, ss)
+		}
+	}
+	return out
+}
+
+// formatDelta возвращает изменение в процентах, например " (+12%)".
+func formatDelta(before, after float64) string {
+	if before == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%+.0f%%)", (after-before)/before*100)
+}
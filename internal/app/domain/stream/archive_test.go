@@ -0,0 +1,129 @@
+package stream_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+	"time"
+	"twitchspam/internal/app/domain/stream"
+	"twitchspam/internal/app/infrastructure/storage"
+)
+
+func newTestStream(name string) *stream.Stream {
+	return stream.NewStream(name,
+		nil,
+		storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0),
+		storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0),
+		storage.NewCache[map[string]*stream.ChatterStats](0, 0, false, false, "", 0),
+	)
+}
+
+func TestArchive_SessionArchivedOnceByStreamID(t *testing.T) {
+	t.Parallel()
+
+	st := newTestStream("archive_once")
+	stats := st.Stats()
+
+	start := time.Date(2025, 10, 18, 19, 0, 0, 0, time.Local)
+	stats.Reset()
+	stats.SetStartTime(start)
+	stats.SetStreamID("1")
+	stats.AddMessage("user1")
+	stats.SetEndTime(start.Add(2 * time.Hour))
+
+	// повторный online без offline не должен дублировать сессию
+	stats.Reset()
+	stats.SetStartTime(start.Add(24 * time.Hour))
+	stats.SetStreamID("2")
+	stats.SetEndTime(start.Add(26 * time.Hour))
+
+	answer := stats.GetStatsByDate(start)
+	assert.Len(t, answer.Text, 1)
+	assert.Contains(t, answer.Text[0], "стрим 18.10.2025 19:00")
+	assert.Contains(t, answer.Text[0], "всего сообщений: 1")
+
+	assert.Contains(t, stats.GetLastStats().Text[0], "стрим 19.10.2025 19:00")
+	assert.Contains(t, stats.GetCompareStats().Text[0], "сравнение стримов 18.10.2025 и 19.10.2025")
+}
+
+func TestArchive_Empty(t *testing.T) {
+	t.Parallel()
+
+	stats := newTestStream("archive_empty").Stats()
+	assert.Equal(t, "архив стримов пуст!", stats.GetLastStats().Text[0])
+	assert.Equal(t, "для сравнения нужно хотя бы два стрима в архиве!", stats.GetCompareStats().Text[0])
+}
+
+func TestArchive_StoresEachSessionSeparately(t *testing.T) {
+	t.Parallel()
+
+	archive := storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0)
+	st := stream.NewStream("archive_keys", nil, storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0), archive, nil)
+	stats := st.Stats()
+
+	start := time.Date(2025, 10, 18, 19, 0, 0, 0, time.Local)
+
+	// сессия без идентификатора стрима сохраняется по времени начала
+	stats.SetStartTime(start)
+	stats.SetEndTime(start.Add(time.Hour))
+	assert.Equal(t, []string{"archive_keys/t:" + start.UTC().Format(time.RFC3339Nano)}, archive.Keys("archive_keys/"))
+
+	// идентификатор, ставший известным позже, заменяет запись по времени начала
+	stats.SetStreamID("0")
+	for i := 1; i <= 2; i++ {
+		stats.Reset()
+		stats.SetStartTime(start.Add(time.Duration(i) * 24 * time.Hour))
+		stats.SetStreamID(strconv.Itoa(i))
+		stats.SetEndTime(start.Add(time.Duration(i)*24*time.Hour + time.Hour))
+	}
+
+	assert.Equal(t, []string{"archive_keys/id:0", "archive_keys/id:1", "archive_keys/id:2"}, archive.Keys("archive_keys/"))
+	assert.Contains(t, stats.GetLastStats().Text[0], "стрим 20.10.2025 19:00")
+}
+
+func TestArchive_Limit(t *testing.T) {
+	t.Parallel()
+
+	archive := storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0)
+	stats := stream.NewStream("archive_limit", nil, storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0), archive, nil).Stats()
+
+	start := time.Date(2025, 1, 1, 19, 0, 0, 0, time.Local)
+	for i := range 205 {
+		stats.Reset()
+		stats.SetStartTime(start.Add(time.Duration(i) * 24 * time.Hour))
+		stats.SetStreamID(strconv.Itoa(i))
+		stats.SetEndTime(start.Add(time.Duration(i)*24*time.Hour + time.Hour))
+	}
+
+	// хранятся последние 200 сессий, самые старые удаляются
+	assert.Len(t, archive.Keys("archive_limit/"), 200)
+	_, ok := archive.Get("archive_limit/id:4")
+	assert.False(t, ok)
+	_, ok = archive.Get("archive_limit/id:5")
+	assert.True(t, ok)
+}
+
+func TestMigrateArchive(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 10, 18, 19, 0, 0, 0, time.Local)
+	legacy := storage.NewCache[[]stream.SessionStats](0, 0, false, false, "", 0)
+	legacy.Set("archive_migrate", []stream.SessionStats{
+		{StreamID: "1", StartStreamTime: start, EndStreamTime: start.Add(time.Hour)},
+		{StartStreamTime: start.Add(24 * time.Hour), EndStreamTime: start.Add(25 * time.Hour)},
+	})
+	legacy.Set("other", []stream.SessionStats{{StreamID: "9", StartStreamTime: start}})
+
+	archive := storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0)
+	assert.Equal(t, 2, stream.MigrateArchive("archive_migrate", legacy, archive))
+	assert.Zero(t, stream.MigrateArchive("archive_migrate", legacy, archive))
+
+	_, ok := legacy.Get("archive_migrate")
+	assert.False(t, ok)
+	_, ok = legacy.Get("other")
+	assert.True(t, ok)
+
+	stats := stream.NewStream("archive_migrate", nil, storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0), archive, nil).Stats()
+	assert.Len(t, archive.Keys("archive_migrate/"), 2)
+	assert.Contains(t, stats.GetLastStats().Text[0], "стрим 19.10.2025 19:00")
+}
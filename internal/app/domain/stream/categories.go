@@ -0,0 +1,131 @@
+package stream
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"twitchspam/internal/app/domain"
+	"twitchspam/internal/app/ports"
+)
+
+// CategoryTotals - итоги по категории за стрим или за весь архив.
+type CategoryTotals struct {
+	Name          string
+	Duration      time.Duration
+	Messages      int
+	SumViewers    int64
+	ViewerSamples int
+	Streams       int
+}
+
+func (c CategoryTotals) avgViewers() float64 {
+	if c.ViewerSamples == 0 {
+		return 0
+	}
+	return math.Round(float64(c.SumViewers) / float64(c.ViewerSamples))
+}
+
+// categoryTotals суммирует интервалы истории категорий по названию; незакрытый интервал длится до конца стрима.
+func (ss *SessionStats) categoryTotals() []CategoryTotals {
+	byName := make(map[string]*CategoryTotals)
+	var order []string
+	for _, c := range ss.CategoryHistory {
+		if c.Name == "" {
+			continue
+		}
+
+		end := c.EndTime
+		if end.IsZero() {
+			end = ss.EndStreamTime
+			if end.Before(c.StartTime) {
+				end = time.Now()
+			}
+		}
+
+		total, ok := byName[c.Name]
+		if !ok {
+			total = &CategoryTotals{Name: c.Name, Streams: 1}
+			byName[c.Name] = total
+			order = append(order, c.Name)
+		}
+
+		if d := end.Sub(c.StartTime); d > 0 {
+			total.Duration += d
+		}
+		total.Messages += c.Messages
+		total.SumViewers += c.SumViewers
+		total.ViewerSamples += c.ViewerSamples
+	}
+
+	totals := make([]CategoryTotals, 0, len(order))
+	for _, name := range order {
+		totals = append(totals, *byName[name])
+	}
+	sortCategoryTotals(totals)
+	return totals
+}
+
+func sortCategoryTotals(totals []CategoryTotals) {
+	sort.SliceStable(totals, func(i, j int) bool { return totals[i].Duration > totals[j].Duration })
+}
+
+func formatCategories(totals []CategoryTotals) string {
+	parts := make([]string, 0, len(totals))
+	for _, c := range totals {
+		parts = append(parts, fmt.Sprintf("%s - %s, %d сообщений, средний онлайн %.0f",
+			c.Name, domain.FormatDuration(c.Duration.Truncate(time.Minute)), c.Messages, c.avgViewers()))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// GetGamesStats возвращает время, сообщения и средний онлайн по категориям за все стримы архива.
+func (s *Stats) GetGamesStats() *ports.AnswerType {
+	sessions := s.archived()
+
+	byName := make(map[string]*CategoryTotals)
+	for _, ss := range sessions {
+		for _, c := range ss.categoryTotals() {
+			total, ok := byName[c.Name]
+			if !ok {
+				total = &CategoryTotals{Name: c.Name}
+				byName[c.Name] = total
+			}
+
+			total.Duration += c.Duration
+			total.Messages += c.Messages
+			total.SumViewers += c.SumViewers
+			total.ViewerSamples += c.ViewerSamples
+			total.Streams++
+		}
+	}
+
+	if len(byName) == 0 {
+		return &ports.AnswerType{Text: []string{"нет данных о категориях в архиве стримов!"}, IsReply: false}
+	}
+
+	totals := make([]CategoryTotals, 0, len(byName))
+	for _, c := range byName {
+		totals = append(totals, *c)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Name < totals[j].Name })
+	sortCategoryTotals(totals)
+
+	parts := make([]string, 0, len(totals))
+	for _, c := range totals {
+		parts = append(parts, fmt.Sprintf("%s - %s за %d стр., %d сообщений, средний онлайн %.0f",
+			c.Name, domain.FormatDuration(c.Duration.Truncate(time.Minute)), c.Streams, c.Messages, c.avgViewers()))
+	}
+
+	msg := fmt.Sprintf("категории за %d стримов: %s", len(sessions), strings.Join(parts, "; "))
+	if len(totals) > 10 {
+		key, err := s.fs.UploadToHaste(strings.Join(parts, "\n"))
+		if err != nil {
+			return &ports.AnswerType{Text: []string{"неизвестная ошибка!"}, IsReply: true}
+		}
+		msg = fmt.Sprintf("категории за %d стримов: %s", len(sessions), s.fs.GetURL(key))
+	}
+
+	return &ports.AnswerType{Text: []string{msg}, IsReply: false}
+}
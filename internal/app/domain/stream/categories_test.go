@@ -0,0 +1,33 @@
+package stream_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCategories_BreakdownInStatsAndArchive(t *testing.T) {
+	t.Parallel()
+
+	stats := newTestStream("categories").Stats()
+
+	start := time.Now().Add(-3 * time.Hour)
+	stats.Reset()
+	stats.SetStartTime(start)
+	stats.SetStreamID("1")
+	stats.AddCategoryChange("Just Chatting", start)
+	stats.AddMessage("user1")
+	stats.SetOnline(100)
+	stats.AddCategoryChange("Dota 2", start.Add(time.Hour))
+	stats.AddCategoryChange("Dota 2", start.Add(time.Hour+time.Minute))
+	stats.AddMessage("user1")
+	stats.AddMessage("user2")
+	stats.SetOnline(300)
+	stats.SetEndTime(start.Add(3 * time.Hour))
+
+	text := stats.GetStats().Text[0]
+	assert.Contains(t, text, "категории: Dota 2 - 2 часа, 2 сообщений, средний онлайн 300; Just Chatting - 1 час, 1 сообщений, средний онлайн 100")
+
+	assert.Equal(t, "категории за 1 стримов: Dota 2 - 2 часа за 1 стр., 2 сообщений, средний онлайн 300; Just Chatting - 1 час за 1 стр., 1 сообщений, средний онлайн 100",
+		stats.GetGamesStats().Text[0])
+}
@@ -0,0 +1,188 @@
+package stream
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"twitchspam/internal/app/ports"
+)
+
+// modLogLimit - сколько отдельных действий модераторов хранится за один стрим.
+const modLogLimit = 5000
+
+// CurrentSummary возвращает итоги текущего (или последнего незавершённого) стрима.
+func (s *Stats) CurrentSummary() (ports.StreamSummary, bool) {
+	ss := s.getStatsCopy()
+	if ss.StartStreamTime.IsZero() {
+		return ports.StreamSummary{}, false
+	}
+	return ss.export(), true
+}
+
+// HistorySummaries возвращает итоги стримов из архива, начавшихся в [from, to); нулевая граница не ограничивает.
+func (s *Stats) HistorySummaries(from, to time.Time) []ports.StreamSummary {
+	sessions := s.archived()
+
+	out := make([]ports.StreamSummary, 0, len(sessions))
+	for _, ss := range sessions {
+		if !from.IsZero() && ss.StartStreamTime.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !ss.StartStreamTime.Before(to) {
+			continue
+		}
+		out = append(out, ss.export())
+	}
+	return out
+}
+
+func (s *Stats) ChatterSummary(login string) (ports.ChatterSummary, bool) {
+	login = strings.ToLower(strings.TrimPrefix(login, "@"))
+
+	s.mu.RLock()
+	streamMessages := s.stats.CountMessages[login]
+	s.mu.RUnlock()
+
+	s.lifetime.mu.RLock()
+	defer s.lifetime.mu.RUnlock()
+
+	cs, ok := s.lifetime.chatters[login]
+	if !ok && streamMessages == 0 {
+		return ports.ChatterSummary{}, false
+	}
+
+	summary := ports.ChatterSummary{Login: login, StreamMessages: streamMessages}
+	if ok {
+		summary.Messages = cs.Messages
+		summary.Streams = cs.Streams
+		summary.FirstSeen = cs.FirstSeen
+		summary.LastSeen = cs.LastSeen
+		summary.Punishments = cs.Punishments.export()
+		summary.ModActions = cs.ModActions.export()
+	}
+	return summary, true
+}
+
+// ModeratorActions возвращает действия модераторов по убыванию их числа; период - как в GetModStats.
+func (s *Stats) ModeratorActions(days int) []ports.ModeratorActions {
+	mods, _ := s.modActions(days)
+
+	out := make([]ports.ModeratorActions, 0, len(mods))
+	for login, counts := range mods {
+		if counts.total() == 0 {
+			continue
+		}
+		out = append(out, ports.ModeratorActions{Login: login, Actions: counts.export()})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		ti, tj := mods[out[i].Login].total(), mods[out[j].Login].total()
+		if ti != tj {
+			return ti > tj
+		}
+		return out[i].Login < out[j].Login
+	})
+	return out
+}
+
+// ModerationLog возвращает отдельные действия модераторов от старых к новым; период - как в GetModStats.
+// Действия за прошлые стримы берутся из архива сессий.
+func (s *Stats) ModerationLog(days int) []ports.ModerationAction {
+	current := s.getStatsCopy()
+	if days == 0 {
+		return current.ModLog
+	}
+
+	var border time.Time
+	if days > 0 {
+		y, m, d := time.Now().AddDate(0, 0, -days+1).Date()
+		border = time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	}
+
+	sessions := s.archived()
+	// завершённая сессия уже лежит в архиве
+	if !current.StartStreamTime.IsZero() && !slices.ContainsFunc(sessions, func(ss SessionStats) bool {
+		return ss.StartStreamTime.Equal(current.StartStreamTime)
+	}) {
+		sessions = append(sessions, current)
+	}
+
+	var out []ports.ModerationAction
+	for _, ss := range sessions {
+		for _, a := range ss.ModLog {
+			if !a.Time.Before(border) {
+				out = append(out, a)
+			}
+		}
+	}
+
+	slices.SortStableFunc(out, func(a, b ports.ModerationAction) int {
+		return a.Time.Compare(b.Time)
+	})
+	return out
+}
+
+// addModLog записывает действие модератора в журнал текущего стрима.
+func (s *Stats) addModLog(moderator, target, action string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats.StartStreamTime.IsZero() || len(s.stats.ModLog) >= modLogLimit {
+		return
+	}
+
+	s.stats.ModLog = append(s.stats.ModLog, ports.ModerationAction{
+		Time:      t,
+		Moderator: strings.ToLower(moderator),
+		Action:    action,
+		Target:    strings.ToLower(target),
+	})
+}
+
+func (ss *SessionStats) export() ports.StreamSummary {
+	countMessages, countDeletes, countTimeouts, countBans, _ := ss.aggregate()
+
+	var countWarns int
+	for _, v := range ss.CountWarns {
+		countWarns += v
+	}
+
+	summary := ports.StreamSummary{
+		StreamID:   ss.StreamID,
+		Start:      ss.StartStreamTime,
+		MaxViewers: ss.Online.MaxViewers,
+		AvgViewers: math.Round(ss.avgViewers()),
+		Messages:   countMessages,
+		Chatters:   len(ss.CountMessages),
+		Viewers:    ss.viewerMetrics(),
+		Actions: ports.ActionSummary{
+			Deletes:  countDeletes,
+			Timeouts: countTimeouts,
+			Warns:    countWarns,
+			Bans:     countBans,
+		},
+	}
+
+	end := time.Now()
+	if ss.EndStreamTime.After(ss.StartStreamTime) {
+		summary.End = ss.EndStreamTime
+		end = ss.EndStreamTime
+	}
+	summary.Duration = int64(end.Sub(ss.StartStreamTime).Seconds())
+
+	for _, c := range ss.categoryTotals() {
+		summary.Categories = append(summary.Categories, ports.CategorySummary{
+			Name:       c.Name,
+			Duration:   int64(c.Duration.Seconds()),
+			Messages:   c.Messages,
+			AvgViewers: c.avgViewers(),
+		})
+	}
+	return summary
+}
+
+func (a ActionCounts) export() ports.ActionSummary {
+	return ports.ActionSummary{Deletes: a.Deletes, Timeouts: a.Timeouts, Warns: a.Warns, Bans: a.Bans}
+}
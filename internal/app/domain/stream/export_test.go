@@ -0,0 +1,47 @@
+package stream_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestExport_Summaries(t *testing.T) {
+	t.Parallel()
+
+	stats := newTestStream("export").Stats()
+	_, ok := stats.CurrentSummary()
+	assert.False(t, ok)
+
+	start := time.Date(2025, 10, 18, 19, 0, 0, 0, time.Local)
+	stats.SetStreamID("1")
+	stats.SetStartTime(start)
+	stats.AddMessage("Alice")
+	stats.AddMessage("bob")
+	stats.AddTimeout("mod")
+	stats.AddWarn("mod")
+	stats.SetEndTime(start.Add(2 * time.Hour))
+
+	current, ok := stats.CurrentSummary()
+	assert.True(t, ok)
+	assert.Equal(t, "1", current.StreamID)
+	assert.Equal(t, int64(7200), current.Duration)
+	assert.Equal(t, 2, current.Messages)
+	assert.Equal(t, 1, current.Actions.Timeouts)
+	assert.Equal(t, 1, current.Actions.Warns)
+
+	assert.Len(t, stats.HistorySummaries(time.Time{}, time.Time{}), 1)
+	assert.Empty(t, stats.HistorySummaries(start.Add(time.Minute), time.Time{}))
+
+	chatter, ok := stats.ChatterSummary("@alice")
+	assert.True(t, ok)
+	assert.Equal(t, 1, chatter.StreamMessages)
+	_, ok = stats.ChatterSummary("nobody")
+	assert.False(t, ok)
+
+	actions := stats.ModeratorActions(0)
+	if assert.Len(t, actions, 1) {
+		assert.Equal(t, "mod", actions[0].Login)
+		assert.Equal(t, 1, actions[0].Actions.Timeouts)
+	}
+}
@@ -0,0 +1,302 @@
+package stream
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"twitchspam/internal/app/ports"
+)
+
+// lifetimeDays - сколько суток хранится подневная статистика сообщений для топа за период.
+const lifetimeDays = 366
+
+// ChatterStats - статистика чаттера на канале за всё время.
+type ChatterStats struct {
+	Messages    int
+	Streams     int
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	LastSession string         // сессия, в которой чаттер писал последний раз, чтобы считать стримы один раз
+	Daily       map[string]int // ключ - дата 2006-01-02, значение - кол-во сообщений
+
+	Punishments ActionCounts            // наказания, полученные чаттером
+	ModActions  ActionCounts            // действия, выполненные чаттером как модератором
+	ModDaily    map[string]ActionCounts // ключ - дата 2006-01-02, действия модератора за день
+}
+
+type ActionCounts struct {
+	Deletes  int
+	Timeouts int
+	Warns    int
+	Bans     int
+}
+
+func (a *ActionCounts) add(action string) {
+	switch action {
+	case "delete":
+		a.Deletes++
+	case "timeout":
+		a.Timeouts++
+	case "warn":
+		a.Warns++
+	case "ban":
+		a.Bans++
+	}
+}
+
+func (a ActionCounts) total() int {
+	return a.Deletes + a.Timeouts + a.Warns + a.Bans
+}
+
+// lifetime - накопительная статистика чаттеров канала. Хранится в памяти и раз в минуту
+// сохраняется в кэш, как и статистика текущего стрима.
+type lifetime struct {
+	mu       sync.RWMutex
+	chatters map[string]*ChatterStats
+	changed  bool
+	cache    ports.CachePort[map[string]*ChatterStats]
+	key      string
+}
+
+func newLifetime(channelName string, cache ports.CachePort[map[string]*ChatterStats]) *lifetime {
+	l := &lifetime{
+		chatters: make(map[string]*ChatterStats),
+		cache:    cache,
+		key:      channelName,
+	}
+
+	if cache == nil {
+		return l
+	}
+
+	if chatters, ok := cache.Get(channelName); ok {
+		for login, cs := range chatters {
+			c := *cs
+			c.Daily = maps.Clone(cs.Daily)
+			c.ModDaily = maps.Clone(cs.ModDaily)
+			l.chatters[login] = &c
+		}
+	}
+	return l
+}
+
+func (l *lifetime) get(login string) *ChatterStats {
+	cs, ok := l.chatters[login]
+	if !ok {
+		cs = &ChatterStats{Daily: make(map[string]int)}
+		l.chatters[login] = cs
+	}
+	if cs.Daily == nil {
+		cs.Daily = make(map[string]int)
+	}
+	return cs
+}
+
+func (l *lifetime) addMessage(username, session string, t time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cs := l.get(strings.ToLower(username))
+	cs.Messages++
+	if cs.FirstSeen.IsZero() {
+		cs.FirstSeen = t
+	}
+	cs.LastSeen = t
+
+	if session != "" && cs.LastSession != session {
+		cs.LastSession = session
+		cs.Streams++
+	}
+
+	cs.Daily[t.Format(time.DateOnly)]++
+	l.changed = true
+}
+
+func (l *lifetime) addPunishment(username, action string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.get(strings.ToLower(username)).Punishments.add(action)
+	l.changed = true
+}
+
+func (l *lifetime) addModAction(username, action string, t time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cs := l.get(strings.ToLower(username))
+	cs.ModActions.add(action)
+
+	if cs.ModDaily == nil {
+		cs.ModDaily = make(map[string]ActionCounts)
+	}
+	day := t.Format(time.DateOnly)
+	counts := cs.ModDaily[day]
+	counts.add(action)
+	cs.ModDaily[day] = counts
+
+	l.changed = true
+}
+
+// save сохраняет статистику в кэш, если она изменилась, и удаляет устаревшие дни.
+func (l *lifetime) save(now time.Time) {
+	if l.cache == nil {
+		return
+	}
+
+	border := now.AddDate(0, 0, -lifetimeDays).Format(time.DateOnly)
+
+	l.mu.Lock()
+	if !l.changed {
+		l.mu.Unlock()
+		return
+	}
+	l.changed = false
+
+	chatters := make(map[string]*ChatterStats, len(l.chatters))
+	for login, cs := range l.chatters {
+		maps.DeleteFunc(cs.Daily, func(day string, _ int) bool {
+			return day < border
+		})
+		maps.DeleteFunc(cs.ModDaily, func(day string, _ ActionCounts) bool {
+			return day < border
+		})
+
+		c := *cs
+		c.Daily = maps.Clone(cs.Daily)
+		c.ModDaily = maps.Clone(cs.ModDaily)
+		chatters[login] = &c
+	}
+	l.mu.Unlock()
+
+	l.cache.Set(l.key, chatters)
+}
+
+// AddPunishment учитывает наказание чаттера и записывает действие модератора в журнал стрима;
+// login - логин Twitch, а не отображаемое имя.
+func (s *Stats) AddPunishment(moderator, login, action string) {
+	if login == "" {
+		return
+	}
+	s.lifetime.addPunishment(login, action)
+	s.addModLog(moderator, login, action, time.Now())
+}
+
+// GetUserLifetimeStats возвращает статистику чаттера за всё время.
+func (s *Stats) GetUserLifetimeStats(username string) *ports.AnswerType {
+	username = strings.ToLower(strings.TrimPrefix(username, "@"))
+
+	s.lifetime.mu.RLock()
+	defer s.lifetime.mu.RUnlock()
+
+	cs, ok := s.lifetime.chatters[username]
+	if !ok {
+		return &ports.AnswerType{Text: []string{fmt.Sprintf("нет данных о %s!", username)}, IsReply: false}
+	}
+
+	position := 1
+	for _, other := range s.lifetime.chatters {
+		if other.Messages > cs.Messages {
+			position++
+		}
+	}
+
+	msg := fmt.Sprintf("статистика %s за всё время - кол-во сообщений: %d • топ-%d чаттер • стримов посещено: %d",
+		username, cs.Messages, position, cs.Streams)
+	if !cs.FirstSeen.IsZero() {
+		msg += fmt.Sprintf(" • впервые замечен: %s • последний раз: %s",
+			cs.FirstSeen.In(time.Local).Format("02.01.2006"), cs.LastSeen.In(time.Local).Format("02.01.2006"))
+	}
+
+	if p := cs.Punishments; p.total() > 0 {
+		msg += fmt.Sprintf(" • получено банов: %d • мутов: %d • предупреждений: %d • удалено сообщений: %d",
+			p.Bans, p.Timeouts, p.Warns, p.Deletes)
+	}
+
+	if m := cs.ModActions; m.total() > 0 {
+		msg += fmt.Sprintf(" • как модератор выдал банов: %d • мутов: %d • предупреждений: %d • удалил сообщений: %d",
+			m.Bans, m.Timeouts, m.Warns, m.Deletes)
+	}
+
+	return &ports.AnswerType{Text: []string{msg}, IsReply: false}
+}
+
+// GetTopLifetimeStats возвращает топ чаттеров по кол-ву сообщений за последние days суток; 0 - за всё время.
+func (s *Stats) GetTopLifetimeStats(count, days int) *ports.AnswerType {
+	switch {
+	case count <= 0:
+		count = 10
+	case count > 100:
+		count = 100
+	}
+
+	var border string
+	if days > 0 {
+		border = time.Now().AddDate(0, 0, -days+1).Format(time.DateOnly)
+	}
+
+	type pair struct {
+		Key string
+		Val int
+	}
+
+	s.lifetime.mu.RLock()
+	pairs := make([]pair, 0, len(s.lifetime.chatters))
+	for login, cs := range s.lifetime.chatters {
+		val := cs.Messages
+		if days > 0 {
+			val = 0
+			for day, n := range cs.Daily {
+				if day >= border {
+					val += n
+				}
+			}
+		}
+
+		if val > 0 {
+			pairs = append(pairs, pair{login, val})
+		}
+	}
+	s.lifetime.mu.RUnlock()
+
+	if len(pairs) == 0 {
+		return &ports.AnswerType{Text: []string{"нет данных за выбранный период!"}, IsReply: false}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Val != pairs[j].Val {
+			return pairs[i].Val > pairs[j].Val
+		}
+		return pairs[i].Key < pairs[j].Key
+	})
+
+	period := "за всё время"
+	if days > 0 {
+		period = fmt.Sprintf("за %d дн.", days)
+	}
+
+	sep := ", "
+	if count > 10 {
+		sep = "\n"
+	}
+
+	msg := fmt.Sprintf("топ-%d чаттеров по кол-ву сообщений %s: ", count, period)
+	for i := 0; i < count && i < len(pairs); i++ {
+		if i > 0 {
+			msg += sep
+		}
+		msg += fmt.Sprintf("%s (%d)", pairs[i].Key, pairs[i].Val)
+	}
+
+	if count > 10 {
+		key, err := s.fs.UploadToHaste(msg)
+		if err != nil {
+			return &ports.AnswerType{Text: []string{"неизвестная ошибка!"}, IsReply: true}
+		}
+		msg = s.fs.GetURL(key)
+	}
+
+	return &ports.AnswerType{Text: []string{msg}, IsReply: false}
+}
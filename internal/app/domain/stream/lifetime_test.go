@@ -0,0 +1,40 @@
+package stream_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLifetime_CountsStreamsOncePerSession(t *testing.T) {
+	t.Parallel()
+
+	stats := newTestStream("lifetime_streams").Stats()
+
+	for i, id := range []string{"1", "2"} {
+		stats.Reset()
+		stats.SetStartTime(time.Now().Add(time.Duration(i) * time.Hour))
+		stats.SetStreamID(id)
+		stats.AddMessage("User1")
+		stats.AddMessage("user1")
+	}
+	stats.AddMessage("user2")
+	stats.AddPunishment("user2", "User1", "timeout")
+	stats.AddBan("user2")
+
+	text := stats.GetUserLifetimeStats("@user1").Text[0]
+	assert.Contains(t, text, "кол-во сообщений: 4")
+	assert.Contains(t, text, "стримов посещено: 2")
+	assert.Contains(t, text, "мутов: 1")
+
+	assert.Contains(t, stats.GetUserLifetimeStats("user2").Text[0], "как модератор выдал банов: 1")
+	assert.Equal(t, "топ-10 чаттеров по кол-ву сообщений за 30 дн.: user1 (4), user2 (1)", stats.GetTopLifetimeStats(0, 30).Text[0])
+}
+
+func TestLifetime_UnknownUser(t *testing.T) {
+	t.Parallel()
+
+	stats := newTestStream("lifetime_unknown").Stats()
+	assert.Equal(t, "нет данных о user!", stats.GetUserLifetimeStats("user").Text[0])
+	assert.Equal(t, "нет данных за выбранный период!", stats.GetTopLifetimeStats(10, 0).Text[0])
+}
@@ -0,0 +1,168 @@
+package stream
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"twitchspam/internal/app/ports"
+)
+
+// GetModStats возвращает действия модераторов по типам и сравнение действий бота с действиями людей.
+// days == 0 - текущий стрим, days > 0 - последние days суток, days < 0 - за всё время.
+func (s *Stats) GetModStats(days int, botLogin string) *ports.AnswerType {
+	period, parts, summary := s.modStatsParts(days, botLogin)
+	if len(parts) == 0 {
+		return &ports.AnswerType{Text: []string{fmt.Sprintf("нет действий модераторов %s!", period)}, IsReply: false}
+	}
+
+	msg := fmt.Sprintf("модераторы %s: %s • %s", period, strings.Join(parts, ", "), summary)
+	if len(parts) > 10 {
+		key, err := s.fs.UploadToHaste(strings.Join(parts, "\n") + "\n\n" + summary)
+		if err != nil {
+			return &ports.AnswerType{Text: []string{"неизвестная ошибка!"}, IsReply: true}
+		}
+		msg = fmt.Sprintf("модераторы %s: %s • %s", period, s.fs.GetURL(key), summary)
+	}
+
+	return &ports.AnswerType{Text: []string{msg}, IsReply: false}
+}
+
+// GetEndStats возвращает итог стрима; при modReport к нему дописывается отчёт по действиям модераторов за стрим.
+func (s *Stats) GetEndStats(modReport bool, botLogin string) *ports.AnswerType {
+	answer := s.GetStats()
+	if !modReport || len(answer.Text) == 0 {
+		return answer
+	}
+
+	_, parts, summary := s.modStatsParts(0, botLogin)
+	if len(parts) == 0 {
+		return answer
+	}
+
+	mods := strings.Join(parts, ", ")
+	if len(parts) > 10 {
+		key, err := s.fs.UploadToHaste(strings.Join(parts, "\n") + "\n\n" + summary)
+		if err != nil {
+			answer.Text[0] += " • модераторы за стрим: " + summary
+			return answer
+		}
+		mods = s.fs.GetURL(key)
+	}
+
+	answer.Text[0] += fmt.Sprintf(" • модераторы за стрим: %s • %s", mods, summary)
+	return answer
+}
+
+// modStatsParts возвращает описание периода, строки по модераторам (по убыванию числа действий) и итог.
+func (s *Stats) modStatsParts(days int, botLogin string) (period string, parts []string, summary string) {
+	mods, period := s.modActions(days)
+
+	var total, bot ActionCounts
+	type pair struct {
+		login  string
+		counts ActionCounts
+	}
+	pairs := make([]pair, 0, len(mods))
+	for login, counts := range mods {
+		if counts.total() == 0 {
+			continue
+		}
+
+		pairs = append(pairs, pair{login, counts})
+		total = total.plus(counts)
+		if strings.EqualFold(login, botLogin) {
+			bot = bot.plus(counts)
+		}
+	}
+
+	if len(pairs) == 0 {
+		return period, nil, ""
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].counts.total() != pairs[j].counts.total() {
+			return pairs[i].counts.total() > pairs[j].counts.total()
+		}
+		return pairs[i].login < pairs[j].login
+	})
+
+	parts = make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		parts = append(parts, fmt.Sprintf("%s - %d (%s)", p.login, p.counts.total(), p.counts.format()))
+	}
+
+	humans := total.total() - bot.total()
+	summary = fmt.Sprintf("всего действий: %d (%s) • бот: %d (%.0f%%) • модераторы: %d (%.0f%%)",
+		total.total(), total.format(),
+		bot.total(), float64(bot.total())/float64(total.total())*100,
+		humans, float64(humans)/float64(total.total())*100,
+	)
+	return period, parts, summary
+}
+
+// modActions собирает действия модераторов за период из статистики стрима или накопительной статистики.
+func (s *Stats) modActions(days int) (map[string]ActionCounts, string) {
+	mods := make(map[string]ActionCounts)
+
+	if days == 0 {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		for _, src := range []struct {
+			counts map[string]int
+			add    func(a *ActionCounts, n int)
+		}{
+			{s.stats.CountDeletes, func(a *ActionCounts, n int) { a.Deletes += n }},
+			{s.stats.CountTimeouts, func(a *ActionCounts, n int) { a.Timeouts += n }},
+			{s.stats.CountWarns, func(a *ActionCounts, n int) { a.Warns += n }},
+			{s.stats.CountBans, func(a *ActionCounts, n int) { a.Bans += n }},
+		} {
+			for login, n := range src.counts {
+				counts := mods[login]
+				src.add(&counts, n)
+				mods[login] = counts
+			}
+		}
+		return mods, "за стрим"
+	}
+
+	var border string
+	period := "за всё время"
+	if days > 0 {
+		border = time.Now().AddDate(0, 0, -days+1).Format(time.DateOnly)
+		period = fmt.Sprintf("за %d дн.", days)
+	}
+
+	s.lifetime.mu.RLock()
+	defer s.lifetime.mu.RUnlock()
+
+	for login, cs := range s.lifetime.chatters {
+		if days < 0 {
+			mods[login] = cs.ModActions
+			continue
+		}
+
+		var counts ActionCounts
+		for day, c := range cs.ModDaily {
+			if day >= border {
+				counts = counts.plus(c)
+			}
+		}
+		mods[login] = counts
+	}
+	return mods, period
+}
+
+func (a ActionCounts) plus(b ActionCounts) ActionCounts {
+	return ActionCounts{
+		Deletes:  a.Deletes + b.Deletes,
+		Timeouts: a.Timeouts + b.Timeouts,
+		Warns:    a.Warns + b.Warns,
+		Bans:     a.Bans + b.Bans,
+	}
+}
+
+func (a ActionCounts) format() string {
+	return fmt.Sprintf("баны %d, муты %d, варны %d, удаления %d", a.Bans, a.Timeouts, a.Warns, a.Deletes)
+}
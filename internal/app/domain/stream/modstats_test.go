@@ -0,0 +1,106 @@
+package stream_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+	"twitchspam/internal/app/domain/stream"
+	"twitchspam/internal/app/infrastructure/storage"
+	"twitchspam/internal/app/ports"
+)
+
+func TestModStats_BotVersusHumans(t *testing.T) {
+	t.Parallel()
+
+	stats := newTestStream("modstats").Stats()
+	stats.AddBan("Bot")
+	stats.AddTimeout("bot")
+	stats.AddTimeout("bot")
+	stats.AddDeleted("mod1")
+
+	assert.Equal(t,
+		"модераторы за стрим: bot - 3 (баны 1, муты 2, варны 0, удаления 0), mod1 - 1 (баны 0, муты 0, варны 0, удаления 1) • всего действий: 4 (баны 1, муты 2, варны 0, удаления 1) • бот: 3 (75%) • модераторы: 1 (25%)",
+		stats.GetModStats(0, "bot").Text[0])
+	assert.Contains(t, stats.GetModStats(7, "bot").Text[0], "модераторы за 7 дн.: bot - 3")
+	assert.Contains(t, stats.GetModStats(-1, "bot").Text[0], "модераторы за всё время: bot - 3")
+}
+
+func TestModStats_WarnsResetWithStream(t *testing.T) {
+	t.Parallel()
+
+	stats := newTestStream("modstats_reset").Stats()
+	stats.AddWarn("mod1")
+	stats.AddWarn("mod1")
+	require.Contains(t, stats.GetModStats(0, "bot").Text[0], "mod1 - 2 (баны 0, муты 0, варны 2, удаления 0)")
+
+	// варны прошлого стрима не переносятся в новый
+	stats.Reset()
+	stats.AddWarn("mod1")
+	assert.Contains(t, stats.GetModStats(0, "bot").Text[0], "mod1 - 1 (баны 0, муты 0, варны 1, удаления 0)")
+}
+
+func TestEndStats_ModReport(t *testing.T) {
+	t.Parallel()
+
+	stats := newTestStream("endstats").Stats()
+	start := time.Now().Add(-time.Hour)
+	stats.SetStartTime(start)
+	stats.AddMessage("alice")
+	stats.AddTimeout("bot")
+	stats.AddTimeout("bot")
+	stats.AddDeleted("mod1")
+	stats.SetEndTime(start.Add(time.Hour))
+
+	// отчёт модераторов дописывается к итогу стрима одним сообщением
+	end := stats.GetEndStats(true, "bot")
+	require.Len(t, end.Text, 1)
+	assert.True(t, strings.HasPrefix(end.Text[0], stats.GetStats().Text[0]))
+	assert.Contains(t, end.Text[0], " • модераторы за стрим: bot - 2 (баны 0, муты 2, варны 0, удаления 0), mod1 - 1")
+	assert.Contains(t, end.Text[0], "• бот: 2 (67%) • модераторы: 1 (33%)")
+
+	assert.Equal(t, stats.GetStats().Text, stats.GetEndStats(false, "bot").Text)
+}
+
+func TestModerationLog(t *testing.T) {
+	t.Parallel()
+
+	// прошлый стрим десятидневной давности уже в архиве
+	old := time.Now().AddDate(0, 0, -10)
+	archive := storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0)
+	archive.Set("modlog/id:1", stream.SessionStats{
+		StreamID:        "1",
+		StartStreamTime: old,
+		EndStreamTime:   old.Add(time.Hour),
+		ModLog:          []ports.ModerationAction{{Time: old, Moderator: "mod1", Action: "ban", Target: "spammer"}},
+	})
+	stats := stream.NewStream("modlog", nil,
+		storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0),
+		archive,
+		storage.NewCache[map[string]*stream.ChatterStats](0, 0, false, false, "", 0),
+	).Stats()
+
+	stats.Reset()
+	stats.SetStartTime(time.Now().Add(-time.Hour))
+	stats.SetStreamID("2")
+	stats.AddPunishment("Bot", "User1", "timeout")
+	stats.AddPunishment("mod1", "user2", "delete")
+	stats.AddPunishment("mod1", "", "delete")
+
+	current := stats.ModerationLog(0)
+	require.Len(t, current, 2)
+	assert.Equal(t, "bot", current[0].Moderator)
+	assert.Equal(t, "timeout", current[0].Action)
+	assert.Equal(t, "user1", current[0].Target)
+	assert.False(t, current[0].Time.IsZero())
+
+	assert.Len(t, stats.ModerationLog(7), 2)
+
+	// за всё время - от старых к новым, завершённый стрим не дублируется
+	stats.SetEndTime(time.Now())
+	all := stats.ModerationLog(-1)
+	require.Len(t, all, 3)
+	assert.Equal(t, []string{"spammer", "user1", "user2"}, []string{all[0].Target, all[1].Target, all[2].Target})
+	assert.Equal(t, "mod1", all[0].Moderator)
+}
@@ -0,0 +1,251 @@
+package stream
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"twitchspam/internal/app/domain"
+	"twitchspam/internal/app/ports"
+)
+
+const (
+	reportChartWidth  = 60
+	reportChartHeight = 10
+	reportTopChatters = 20
+)
+
+// StreamID возвращает идентификатор текущего стрима Twitch.
+func (s *Stats) StreamID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.stats.StreamID
+}
+
+// AddModuleAction учитывает наказание, выданное ботом по решению модуля антиспама.
+func (s *Stats) AddModuleAction(module, action string) {
+	if module == "" {
+		module = "-"
+	}
+
+	s.mu.Lock()
+	if s.stats.ModuleActions == nil {
+		s.stats.ModuleActions = make(map[string]ActionCounts)
+	}
+	counts := s.stats.ModuleActions[module]
+	counts.add(action)
+	s.stats.ModuleActions[module] = counts
+	s.mu.Unlock()
+}
+
+// AddEvent добавляет событие стрима (массбан, опрос, ставка) для отчёта.
+func (s *Stats) AddEvent(kind, text string) {
+	s.mu.Lock()
+	s.stats.Events = append(s.stats.Events, StreamEvent{Time: time.Now(), Kind: kind, Text: text})
+	s.mu.Unlock()
+}
+
+// OnStreamEnd регистрирует обработчик окончания стрима; вызывается после сохранения времени окончания.
+func (s *Stats) OnStreamEnd(fn func()) {
+	s.mu.Lock()
+	s.onEnd = append(s.onEnd, fn)
+	s.mu.Unlock()
+}
+
+// Report формирует полный отчёт по текущему стриму. markers - таймкоды маркеров стрима по названиям.
+func (s *Stats) Report(botLogin string, markers map[string][]time.Duration) string {
+	ss := s.getStatsCopy()
+	if ss.StartStreamTime.IsZero() {
+		return ""
+	}
+
+	var sb strings.Builder
+	section := func(title string) {
+		fmt.Fprintf(&sb, "\n== %s ==\n", title)
+	}
+
+	fmt.Fprintf(&sb, "отчёт по стриму %s - %s", ss.StartStreamTime.In(time.Local).Format("02.01.2006 15:04"), ss.EndStreamTime.In(time.Local).Format("02.01.2006 15:04"))
+	if ss.StreamID != "" {
+		fmt.Fprintf(&sb, " (id %s)", ss.StreamID)
+	}
+	sb.WriteString("\n\n")
+	for _, part := range strings.Split(ss.summary(), " • ") {
+		sb.WriteString(part + "\n")
+	}
+
+	if viewers, from, to := ss.viewerChart(); len(viewers) > 0 {
+		section("онлайн")
+		sb.WriteString(asciiChart(viewers, from, to))
+		sb.WriteString(formatViewerMetrics(ss.viewerMetrics()))
+	}
+
+	if len(ss.Timeline) > 0 {
+		messages := make([]float64, 0, len(ss.Timeline))
+		for _, m := range ss.Timeline {
+			messages = append(messages, float64(m.Messages))
+		}
+
+		section("сообщения в минуту")
+		sb.WriteString(asciiChart(messages, ss.Timeline[0].Minute, ss.Timeline[len(ss.Timeline)-1].Minute))
+	}
+
+	section("топ чаттеров")
+	type pair struct {
+		key string
+		val int
+	}
+	chatters := make([]pair, 0, len(ss.CountMessages))
+	for k, v := range ss.CountMessages {
+		chatters = append(chatters, pair{k, v})
+	}
+	sort.Slice(chatters, func(i, j int) bool {
+		if chatters[i].val != chatters[j].val {
+			return chatters[i].val > chatters[j].val
+		}
+		return chatters[i].key < chatters[j].key
+	})
+	for i := 0; i < reportTopChatters && i < len(chatters); i++ {
+		fmt.Fprintf(&sb, "%d. %s - %d\n", i+1, chatters[i].key, chatters[i].val)
+	}
+
+	section("модерация по модулям")
+	for _, module := range slices.Sorted(maps.Keys(ss.ModuleActions)) {
+		counts := ss.ModuleActions[module]
+		fmt.Fprintf(&sb, "%s - %d (%s)\n", module, counts.total(), counts.format())
+	}
+
+	section("модерация по модераторам")
+	if _, parts, summary := s.modStatsParts(0, botLogin); len(parts) > 0 {
+		for _, part := range parts {
+			sb.WriteString(part + "\n")
+		}
+		sb.WriteString(strings.ReplaceAll(summary, " • ", "\n") + "\n")
+	}
+
+	var nukes, votes []StreamEvent
+	for _, e := range ss.Events {
+		if e.Kind == "nuke" {
+			nukes = append(nukes, e)
+		} else {
+			votes = append(votes, e)
+		}
+	}
+
+	section("массбаны")
+	for _, e := range nukes {
+		fmt.Fprintf(&sb, "%s %s\n", e.Time.In(time.Local).Format("15:04"), e.Text)
+	}
+
+	section("опросы и ставки")
+	for _, e := range votes {
+		fmt.Fprintf(&sb, "%s %s\n", e.Time.In(time.Local).Format("15:04"), e.Text)
+	}
+
+	section("категории")
+	for _, c := range ss.CategoryHistory {
+		if c.Name == "" {
+			continue
+		}
+
+		end := c.EndTime
+		if end.IsZero() {
+			end = ss.EndStreamTime
+		}
+
+		var avg float64
+		if c.ViewerSamples > 0 {
+			avg = float64(c.SumViewers) / float64(c.ViewerSamples)
+		}
+		fmt.Fprintf(&sb, "%s - %s %s (%s, %d сообщений, средний онлайн %.0f)\n",
+			c.StartTime.In(time.Local).Format("15:04"), end.In(time.Local).Format("15:04"), c.Name,
+			domain.FormatDuration(end.Sub(c.StartTime).Truncate(time.Minute)), c.Messages, avg)
+	}
+
+	section("маркеры")
+	for _, name := range slices.Sorted(maps.Keys(markers)) {
+		for _, timecode := range markers[name] {
+			fmt.Fprintf(&sb, "%s - %s\n", name, formatTimecode(timecode))
+		}
+	}
+	for _, m := range ss.Moments {
+		fmt.Fprintf(&sb, "всплеск (%s) - %s\n", m.Kind, formatTimecode(m.Timecode))
+	}
+
+	section("пропуски статистики")
+	if gaps := ss.formatGaps(); gaps != "" {
+		sb.WriteString(gaps + "\n")
+	}
+
+	return sb.String()
+}
+
+// asciiChart рисует столбчатый график; если точек больше ширины графика, соседние точки усредняются.
+func asciiChart(values []float64, from, to time.Time) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	columns := values
+	if len(values) > reportChartWidth {
+		columns = make([]float64, reportChartWidth)
+		for i := range columns {
+			lo, hi := i*len(values)/reportChartWidth, (i+1)*len(values)/reportChartWidth
+			var sum float64
+			for _, v := range values[lo:hi] {
+				sum += v
+			}
+			columns[i] = sum / float64(hi-lo)
+		}
+	}
+
+	maxValue := slices.Max(columns)
+	if maxValue <= 0 {
+		return "нет данных\n"
+	}
+
+	var sb strings.Builder
+	for row := reportChartHeight; row >= 1; row-- {
+		threshold := maxValue * float64(row) / reportChartHeight
+		fmt.Fprintf(&sb, "%7.0f |", threshold)
+		for _, v := range columns {
+			// столбец закрашивается, если значение доходит до середины строки
+			if v >= threshold-maxValue/reportChartHeight/2 {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+
+	fmt.Fprintf(&sb, "%7s +%s\n", "", strings.Repeat("-", len(columns)))
+	start, end := from.In(time.Local).Format("15:04"), to.In(time.Local).Format("15:04")
+	fmt.Fprintf(&sb, "%7s  %s%s%s\n", "", start, strings.Repeat(" ", max(len(columns)-len(start)-len(end), 1)), end)
+	return sb.String()
+}
+
+// formatViewerMetrics - строки отчёта с производными метриками онлайна.
+func formatViewerMetrics(m ports.ViewerMetrics) string {
+	var sb strings.Builder
+	if !m.PeakTime.IsZero() {
+		fmt.Fprintf(&sb, "пик онлайна: %d в %s\n", m.PeakViewers, m.PeakTime.In(time.Local).Format("15:04"))
+	}
+	fmt.Fprintf(&sb, "средний онлайн за первый час: %.0f • после первого часа: %.0f", m.AvgFirstHour, m.AvgAfterFirstHour)
+	if m.Retention > 0 {
+		fmt.Fprintf(&sb, " (удержание %.0f%%)", m.Retention*100)
+	}
+	sb.WriteString("\n")
+
+	for _, c := range m.CategorySwitches {
+		fmt.Fprintf(&sb, "%s %s → %s: онлайн %.0f → %.0f%s\n",
+			c.Time.In(time.Local).Format("15:04"), c.From, c.To, c.Before, c.After, formatDelta(c.Before, c.After))
+	}
+	return sb.String()
+}
+
+func formatTimecode(d time.Duration) string {
+	return fmt.Sprintf("%02dh%02dm%02ds", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
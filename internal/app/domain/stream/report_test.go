@@ -0,0 +1,32 @@
+package stream_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReport_Sections(t *testing.T) {
+	t.Parallel()
+
+	stats := newTestStream("report").Stats()
+	assert.Empty(t, stats.Report("bot", nil))
+
+	stats.SetStartTime(time.Now().Add(-time.Hour))
+	stats.AddMessage("alice")
+	stats.AddMessage("alice")
+	stats.AddMessage("bob")
+	stats.AddModuleAction("links", "timeout")
+	stats.AddModuleAction("links", "delete")
+	stats.AddEvent("nuke", "казино - совпадений: 3")
+	stats.AddEvent("poll", "опрос: какую игру?")
+	stats.SetEndTime(time.Now())
+
+	report := stats.Report("bot", map[string][]time.Duration{"mod: клип": {90 * time.Second}})
+	assert.Contains(t, report, "1. alice - 2\n2. bob - 1\n")
+	assert.Contains(t, report, "links - 2 (баны 0, муты 1, варны 0, удаления 1)")
+	assert.Contains(t, report, "== массбаны ==\n")
+	assert.Contains(t, report, "казино - совпадений: 3")
+	assert.Contains(t, report, "опрос: какую игру?")
+	assert.Contains(t, report, "mod: клип - 00h01m30s")
+}
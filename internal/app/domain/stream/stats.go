@@ -3,8 +3,10 @@ package stream
 import (
 	"fmt"
 	"github.com/prometheus/client_golang/prometheus"
+	"maps"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -19,18 +21,25 @@ type Stats struct {
 	stats       SessionStats
 	fs          ports.FileServerPort
 	cache       ports.CachePort[SessionStats]
+	archive     ports.CachePort[SessionStats]
+	lifetime    *lifetime
+	onSpike     []func(moment ports.ActivityMoment)
+	onEnd       []func()
 	mu          sync.RWMutex
 	lastActive  atomic.Int64
 }
 
 type SessionStats struct {
+	StreamID        string
 	StartStreamTime time.Time
 	EndStreamTime   time.Time
 	Online          struct {
 		MaxViewers int
+		PeakTime   time.Time
 		SumViewers int64
 		Count      int
 	}
+	Viewers         []ViewerBucket
 	CountMessages   map[string]int
 	CountDeletes    map[string]int
 	CountTimeouts   map[string]int
@@ -38,12 +47,27 @@ type SessionStats struct {
 	CountBans       map[string]int
 	CategoryHistory []CategoryInterval
 	StatIntervals   []TimeInterval
+	Timeline        []ActivityMinute
+	Moments         []ports.ActivityMoment
+	ModuleActions   map[string]ActionCounts // действия бота по модулям антиспама
+	Events          []StreamEvent
+	ModLog          []ports.ModerationAction // отдельные действия модераторов, не больше modLogLimit
+}
+
+// StreamEvent - событие стрима для отчёта: массбан, опрос или ставка.
+type StreamEvent struct {
+	Time time.Time
+	Kind string
+	Text string
 }
 
 type CategoryInterval struct {
-	Name      string
-	StartTime time.Time
-	EndTime   time.Time
+	Name          string
+	StartTime     time.Time
+	EndTime       time.Time
+	Messages      int
+	SumViewers    int64
+	ViewerSamples int
 }
 
 type TimeInterval struct {
@@ -51,11 +75,13 @@ type TimeInterval struct {
 	End   time.Time
 }
 
-func newStats(channelName string, fs ports.FileServerPort, cache ports.CachePort[SessionStats]) *Stats {
+func newStats(channelName string, fs ports.FileServerPort, cache ports.CachePort[SessionStats], archive ports.CachePort[SessionStats], lifetimeCache ports.CachePort[map[string]*ChatterStats]) *Stats {
 	s := &Stats{
 		channelName: channelName,
 		fs:          fs,
 		cache:       cache,
+		archive:     archive,
+		lifetime:    newLifetime(channelName, lifetimeCache),
 		stats: SessionStats{
 			CountMessages:   make(map[string]int),
 			CountDeletes:    make(map[string]int),
@@ -70,22 +96,10 @@ func newStats(channelName string, fs ports.FileServerPort, cache ports.CachePort
 	if stats, ok := cache.Get(channelName); ok {
 		s.stats = stats
 
-		var countBans, countWarns, countTimeouts, countDeletes, countMessages int
+		var countMessages int
 		for _, v := range stats.CountMessages {
 			countMessages += v
 		}
-		for _, v := range stats.CountDeletes {
-			countDeletes += v
-		}
-		for _, v := range stats.CountTimeouts {
-			countTimeouts += v
-		}
-		for _, v := range stats.CountWarns {
-			countWarns += v
-		}
-		for _, v := range stats.CountBans {
-			countBans += v
-		}
 
 		var avgViewers float64
 		if stats.Online.Count > 0 {
@@ -96,16 +110,13 @@ func newStats(channelName string, fs ports.FileServerPort, cache ports.CachePort
 		metrics.StreamEndTime.With(prometheus.Labels{"channel": s.channelName}).Set(float64(stats.EndStreamTime.Unix()))
 		metrics.OnlineViewers.With(prometheus.Labels{"channel": s.channelName}).Set(avgViewers)
 		metrics.MessagesPerStream.With(prometheus.Labels{"channel": s.channelName}).Add(float64(countMessages))
-		metrics.ModerationActions.With(prometheus.Labels{"channel": s.channelName, "action": "delete"}).Add(float64(countDeletes))
-		metrics.ModerationActions.With(prometheus.Labels{"channel": s.channelName, "action": "timeout"}).Add(float64(countTimeouts))
-		metrics.ModerationActions.With(prometheus.Labels{"channel": s.channelName, "action": "warn"}).Add(float64(countWarns))
-		metrics.ModerationActions.With(prometheus.Labels{"channel": s.channelName, "action": "ban"}).Add(float64(countBans))
 	}
 
 	go func() {
 		ticker := time.NewTicker(1 * time.Minute)
 		for range ticker.C {
 			s.cache.Set(s.channelName, s.getStatsCopy())
+			s.lifetime.save(time.Now())
 		}
 	}()
 
@@ -115,27 +126,35 @@ func newStats(channelName string, fs ports.FileServerPort, cache ports.CachePort
 func (s *Stats) Reset() {
 	s.mu.Lock()
 
+	// offline мог не прийти, поэтому прошлая сессия архивируется и здесь
+	s.archiveLocked()
+
+	s.stats.StreamID = ""
 	s.stats.StartStreamTime = time.Time{}
 	s.stats.EndStreamTime = time.Time{}
 	s.stats.Online.MaxViewers = 0
+	s.stats.Online.PeakTime = time.Time{}
 	s.stats.Online.SumViewers = 0
 	s.stats.Online.Count = 0
+	s.stats.Viewers = nil
 	s.stats.CountMessages = make(map[string]int)
 	s.stats.CountDeletes = make(map[string]int)
 	s.stats.CountTimeouts = make(map[string]int)
+	s.stats.CountWarns = make(map[string]int)
 	s.stats.CountBans = make(map[string]int)
 	s.stats.CategoryHistory = make([]CategoryInterval, 0)
 	s.stats.StatIntervals = make([]TimeInterval, 0)
+	s.stats.Timeline = nil
+	s.stats.Moments = nil
+	s.stats.ModuleActions = nil
+	s.stats.Events = nil
+	s.stats.ModLog = nil
 
-	s.cache.Set(s.channelName, s.stats)
+	s.cache.Set(s.channelName, s.stats.clone())
 	metrics.StreamStartTime.With(prometheus.Labels{"channel": s.channelName}).Set(float64(s.stats.StartStreamTime.Unix()))
 	metrics.StreamEndTime.With(prometheus.Labels{"channel": s.channelName}).Set(float64(s.stats.EndStreamTime.Unix()))
 	metrics.OnlineViewers.With(prometheus.Labels{"channel": s.channelName}).Set(0)
 	metrics.MessagesPerStream.Delete(prometheus.Labels{"channel": s.channelName})
-	metrics.ModerationActions.With(prometheus.Labels{"channel": s.channelName, "action": "delete"}).Set(0)
-	metrics.ModerationActions.With(prometheus.Labels{"channel": s.channelName, "action": "timeout"}).Set(0)
-	metrics.ModerationActions.With(prometheus.Labels{"channel": s.channelName, "action": "warn"}).Set(0)
-	metrics.ModerationActions.With(prometheus.Labels{"channel": s.channelName, "action": "ban"}).Set(0)
 	metrics.UserCommands.Delete(prometheus.Labels{"channel": s.channelName})
 
 	s.mu.Unlock()
@@ -159,7 +178,21 @@ func (s *Stats) SetEndTime(t time.Time) {
 	s.mu.Lock()
 	s.stats.EndStreamTime = t
 	metrics.StreamEndTime.With(prometheus.Labels{"channel": s.channelName}).Set(float64(s.stats.EndStreamTime.Unix()))
+	if n := len(s.stats.CategoryHistory); n > 0 && s.stats.CategoryHistory[n-1].EndTime.IsZero() {
+		s.stats.CategoryHistory[n-1].EndTime = t
+	}
+
+	// последнюю минуту закрывает не следующая минута, а окончание стрима
+	if len(s.stats.Timeline) > 0 {
+		s.detectSpikeLocked()
+	}
+	s.archiveLocked()
+	subscribers := s.onEnd
 	s.mu.Unlock()
+
+	for _, fn := range subscribers {
+		go fn()
+	}
 }
 
 func (s *Stats) GetEndTime() time.Time {
@@ -174,15 +207,22 @@ func (s *Stats) SetOnline(viewers int) {
 		return
 	}
 
+	now := time.Now()
 	s.mu.Lock()
 	if viewers > s.stats.Online.MaxViewers {
 		s.stats.Online.MaxViewers = viewers
+		s.stats.Online.PeakTime = now
 	}
 
 	s.stats.Online.SumViewers += int64(viewers)
 	s.stats.Online.Count++
+	if n := len(s.stats.CategoryHistory); n > 0 {
+		s.stats.CategoryHistory[n-1].SumViewers += int64(viewers)
+		s.stats.CategoryHistory[n-1].ViewerSamples++
+	}
+	s.addViewersLocked(now, viewers)
 
-	s.markActive(time.Now())
+	s.markActive(now)
 	metrics.OnlineViewers.With(prometheus.Labels{"channel": s.channelName}).Set(float64(viewers))
 	s.mu.Unlock()
 }
@@ -195,8 +235,14 @@ func (s *Stats) AddMessage(username string) {
 		return
 	}
 
-	s.markActive(time.Now())
+	now := time.Now()
+	s.markActive(now)
 	s.stats.CountMessages[strings.ToLower(username)]++
+	s.lifetime.addMessage(username, s.stats.sessionKey(), now)
+	s.addActivityLocked(now, 1, 0)
+	if n := len(s.stats.CategoryHistory); n > 0 {
+		s.stats.CategoryHistory[n-1].Messages++
+	}
 	metrics.MessagesPerStream.With(prometheus.Labels{"channel": s.channelName}).Inc()
 }
 
@@ -210,6 +256,7 @@ func (s *Stats) AddDeleted(username string) {
 
 	s.markActive(time.Now())
 	s.stats.CountDeletes[strings.ToLower(username)]++
+	s.lifetime.addModAction(username, "delete", time.Now())
 }
 
 func (s *Stats) AddTimeout(username string) {
@@ -222,6 +269,7 @@ func (s *Stats) AddTimeout(username string) {
 
 	s.markActive(time.Now())
 	s.stats.CountTimeouts[strings.ToLower(username)]++
+	s.lifetime.addModAction(username, "timeout", time.Now())
 }
 
 func (s *Stats) AddWarn(username string) {
@@ -234,6 +282,7 @@ func (s *Stats) AddWarn(username string) {
 
 	s.markActive(time.Now())
 	s.stats.CountWarns[strings.ToLower(username)]++
+	s.lifetime.addModAction(username, "warn", time.Now())
 }
 
 func (s *Stats) AddBan(username string) {
@@ -246,11 +295,17 @@ func (s *Stats) AddBan(username string) {
 
 	s.markActive(time.Now())
 	s.stats.CountBans[strings.ToLower(username)]++
+	s.lifetime.addModAction(username, "ban", time.Now())
 }
 
 func (s *Stats) AddCategoryChange(category string, t time.Time) {
 	s.mu.Lock()
 	if n := len(s.stats.CategoryHistory); n > 0 {
+		// категория не сменилась, например после перезапуска бота
+		if last := s.stats.CategoryHistory[n-1]; last.Name == category && last.EndTime.IsZero() {
+			s.mu.Unlock()
+			return
+		}
 		s.stats.CategoryHistory[n-1].EndTime = t
 	}
 
@@ -273,32 +328,54 @@ func (s *Stats) GetStats() *ports.AnswerType {
 		}
 	}
 
-	countMessages, countDeletes, countTimeouts, countBans, combined := s.aggregateStats()
-
-	avgViewers := 0.0
-	if s.stats.Online.Count > 0 {
-		avgViewers = math.Round(float64(s.stats.Online.SumViewers) / float64(s.stats.Online.Count))
+	msg := s.stats.summary() + " • посмотреть свою стату - !stats"
+	if gaps := s.stats.formatGaps(); gaps != "" {
+		msg += fmt.Sprintf(" (статистика отсутствовала %s)", gaps)
 	}
 
+	return &ports.AnswerType{Text: []string{msg}, IsReply: false}
+}
+
+// summary возвращает общую статистику сессии без подсказок и пропусков.
+func (ss *SessionStats) summary() string {
+	countMessages, countDeletes, countTimeouts, countBans, combined := ss.aggregate()
+
 	msg := fmt.Sprintf(
-		"длительность стрима: %s • средний онлайн: %.0f • максимальный онлайн: %d • всего сообщений: %d • кол-во чаттеров: %d • скорость сообщений: %.1f/сек • кол-во банов: %d • кол-во мутов: %d • кол-во удаленных сообщений: %d • топ 3 модератора за стрим: %s • посмотреть свою стату - !stats",
-		domain.FormatDuration(s.stats.EndStreamTime.Sub(s.stats.StartStreamTime)),
-		avgViewers,
-		s.stats.Online.MaxViewers,
+		"длительность стрима: %s • средний онлайн: %.0f • максимальный онлайн: %d • всего сообщений: %d • кол-во чаттеров: %d • скорость сообщений: %.1f/сек • кол-во банов: %d • кол-во мутов: %d • кол-во удаленных сообщений: %d • топ 3 модератора за стрим: %s",
+		domain.FormatDuration(ss.EndStreamTime.Sub(ss.StartStreamTime)),
+		ss.avgViewers(),
+		ss.Online.MaxViewers,
 		countMessages,
-		len(s.stats.CountMessages),
-		float64(countMessages)/s.stats.EndStreamTime.Sub(s.stats.StartStreamTime).Seconds(),
+		len(ss.CountMessages),
+		float64(countMessages)/ss.EndStreamTime.Sub(ss.StartStreamTime).Seconds(),
 		countBans,
 		countTimeouts,
 		countDeletes,
 		topN(combined, 3),
 	)
 
-	if gaps := s.formatGaps(); gaps != "" {
-		msg += fmt.Sprintf(" (статистика отсутствовала %s)", gaps)
+	if categories := formatCategories(ss.categoryTotals()); categories != "" {
+		msg += " • категории: " + categories
 	}
+	return msg
+}
 
-	return &ports.AnswerType{Text: []string{msg}, IsReply: false}
+// sessionKey - идентификатор сессии: идентификатор стрима Twitch или время начала, если он неизвестен.
+func (ss *SessionStats) sessionKey() string {
+	if ss.StreamID != "" {
+		return ss.StreamID
+	}
+	if ss.StartStreamTime.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(ss.StartStreamTime.Unix(), 10)
+}
+
+func (ss *SessionStats) avgViewers() float64 {
+	if ss.Online.Count == 0 {
+		return 0
+	}
+	return math.Round(float64(ss.Online.SumViewers) / float64(ss.Online.Count))
 }
 
 func (s *Stats) GetUserStats(username string) *ports.AnswerType {
@@ -327,7 +404,7 @@ func (s *Stats) GetUserStats(username string) *ports.AnswerType {
 			s.stats.CountBans[username], s.stats.CountTimeouts[username], s.stats.CountDeletes[username])
 	}
 
-	if gaps := s.formatGaps(); gaps != "" {
+	if gaps := s.stats.formatGaps(); gaps != "" {
 		msg += fmt.Sprintf(" (статистика отсутствовала %s)", gaps)
 	}
 
@@ -374,7 +451,7 @@ func (s *Stats) GetTopStats(count int) *ports.AnswerType {
 		msg += fmt.Sprintf("%s (%d)", pairs[i].Key, pairs[i].Val)
 	}
 
-	if gaps := s.formatGaps(); gaps != "" {
+	if gaps := s.stats.formatGaps(); gaps != "" {
 		msg += fmt.Sprintf(" (статистика отсутствовала %s)", gaps)
 	}
 
@@ -389,33 +466,33 @@ func (s *Stats) GetTopStats(count int) *ports.AnswerType {
 	return &ports.AnswerType{Text: []string{msg}, IsReply: false}
 }
 
-func (s *Stats) aggregateStats() (countMessages, countDeletes, countTimeouts, countBans int, combined map[string]int) {
+func (ss *SessionStats) aggregate() (countMessages, countDeletes, countTimeouts, countBans int, combined map[string]int) {
 	combined = make(map[string]int)
-	for _, v := range s.stats.CountMessages {
+	for _, v := range ss.CountMessages {
 		countMessages += v
 	}
-	for k, v := range s.stats.CountDeletes {
+	for k, v := range ss.CountDeletes {
 		countDeletes += v
 		combined[k] += v
 	}
-	for k, v := range s.stats.CountTimeouts {
+	for k, v := range ss.CountTimeouts {
 		countTimeouts += v
 		combined[k] += v
 	}
-	for k, v := range s.stats.CountBans {
+	for k, v := range ss.CountBans {
 		countBans += v
 		combined[k] += v
 	}
 	return
 }
 
-func (s *Stats) formatGaps() string {
-	if len(s.stats.StatIntervals) <= 1 {
+func (ss *SessionStats) formatGaps() string {
+	if len(ss.StatIntervals) <= 1 {
 		return ""
 	}
 	var gaps []string
-	start := s.stats.StartStreamTime
-	for _, interval := range s.stats.StatIntervals {
+	start := ss.StartStreamTime
+	for _, interval := range ss.StatIntervals {
 		if interval.Start.Sub(start) >= 5*time.Minute {
 			gaps = append(gaps, fmt.Sprintf("с %s по %s", start.Format("15:04"), interval.Start.Format("15:04")))
 		}
@@ -455,23 +532,31 @@ func (s *Stats) getStatsCopy() SessionStats {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	return s.stats.clone()
+}
+
+func (ss *SessionStats) clone() SessionStats {
 	copyMap := func(orig map[string]int) map[string]int {
 		newMap := make(map[string]int, len(orig))
-		for k, v := range orig {
-			newMap[k] = v
-		}
+		maps.Copy(newMap, orig)
 		return newMap
 	}
 
-	statsCopy := s.stats
-	statsCopy.CountMessages = copyMap(s.stats.CountMessages)
-	statsCopy.CountDeletes = copyMap(s.stats.CountDeletes)
-	statsCopy.CountTimeouts = copyMap(s.stats.CountTimeouts)
-	statsCopy.CountWarns = copyMap(s.stats.CountWarns)
-	statsCopy.CountBans = copyMap(s.stats.CountBans)
-
-	statsCopy.CategoryHistory = append([]CategoryInterval(nil), s.stats.CategoryHistory...)
-	statsCopy.StatIntervals = append([]TimeInterval(nil), s.stats.StatIntervals...)
+	statsCopy := *ss
+	statsCopy.CountMessages = copyMap(ss.CountMessages)
+	statsCopy.CountDeletes = copyMap(ss.CountDeletes)
+	statsCopy.CountTimeouts = copyMap(ss.CountTimeouts)
+	statsCopy.CountWarns = copyMap(ss.CountWarns)
+	statsCopy.CountBans = copyMap(ss.CountBans)
+
+	statsCopy.CategoryHistory = append([]CategoryInterval(nil), ss.CategoryHistory...)
+	statsCopy.StatIntervals = append([]TimeInterval(nil), ss.StatIntervals...)
+	statsCopy.Viewers = append([]ViewerBucket(nil), ss.Viewers...)
+	statsCopy.Timeline = append([]ActivityMinute(nil), ss.Timeline...)
+	statsCopy.Moments = append([]ports.ActivityMoment(nil), ss.Moments...)
+	statsCopy.ModuleActions = maps.Clone(ss.ModuleActions)
+	statsCopy.Events = append([]StreamEvent(nil), ss.Events...)
+	statsCopy.ModLog = append([]ports.ModerationAction(nil), ss.ModLog...)
 
 	return statsCopy
 }
@@ -18,12 +18,15 @@ type Stream struct {
 	category    string
 	isLive      atomic.Bool
 
+	onLive     []func(isLive bool)
+	onCategory []func(category string)
+
 	stats ports.StatsPort
 }
 
-func NewStream(channelName string, fs ports.FileServerPort, cache ports.CachePort[SessionStats]) *Stream {
+func NewStream(channelName string, fs ports.FileServerPort, cache ports.CachePort[SessionStats], archive ports.CachePort[SessionStats], lifetime ports.CachePort[map[string]*ChatterStats]) *Stream {
 	s := &Stream{
-		stats: newStats(channelName, fs, cache),
+		stats: newStats(channelName, fs, cache, archive, lifetime),
 	}
 
 	s.SetChannelName(channelName)
@@ -39,8 +42,35 @@ func (s *Stream) IsLive() bool {
 }
 
 func (s *Stream) SetIslive(v bool) {
-	s.isLive.Store(v)
+	old := s.isLive.Swap(v)
 	metrics.StreamActive.With(prometheus.Labels{"channel": s.channelName}).Set(map[bool]float64{true: 1, false: 0}[v])
+
+	if old == v {
+		return
+	}
+
+	s.mu.RLock()
+	subscribers := s.onLive
+	s.mu.RUnlock()
+
+	// обработчики могут изменять конфиг, поэтому не выполняем их под блокировками вызывающего
+	for _, fn := range subscribers {
+		go fn(v)
+	}
+}
+
+// OnLiveChange регистрирует обработчик начала и окончания стрима.
+func (s *Stream) OnLiveChange(fn func(isLive bool)) {
+	s.mu.Lock()
+	s.onLive = append(s.onLive, fn)
+	s.mu.Unlock()
+}
+
+// OnCategoryChange регистрирует обработчик смены категории.
+func (s *Stream) OnCategoryChange(fn func(category string)) {
+	s.mu.Lock()
+	s.onCategory = append(s.onCategory, fn)
+	s.mu.Unlock()
 }
 
 func (s *Stream) ChannelID() string {
@@ -74,11 +104,16 @@ func (s *Stream) SetCategory(category string) {
 		return
 	}
 	s.category = category
+	subscribers := s.onCategory
 	s.mu.Unlock()
 
 	if s.stats != nil {
 		s.stats.AddCategoryChange(category, time.Now())
 	}
+
+	for _, fn := range subscribers {
+		go fn(category)
+	}
 }
 
 func (s *Stream) Category() string {
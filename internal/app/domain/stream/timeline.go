@@ -0,0 +1,157 @@
+package stream
+
+import (
+	"slices"
+	"time"
+	"twitchspam/internal/app/ports"
+)
+
+const (
+	spikeWindow      = 10              // сколько предыдущих минут берётся для базового уровня
+	spikeMinHistory  = 5 * time.Minute // до этого времени от начала записи всплески не ищутся
+	spikeFactor      = 2.5             // во сколько раз минута должна превышать базовый уровень
+	spikeMinMessages = 20
+	spikeMinEmotes   = 30
+	spikeCooldown    = 5 * time.Minute // минимальный интервал между моментами
+)
+
+// ActivityMinute - активность чата за одну минуту стрима.
+type ActivityMinute struct {
+	Minute   time.Time
+	Messages int
+	Emotes   int
+}
+
+func (s *Stats) AddEmotes(count int) {
+	if count <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	s.addActivityLocked(time.Now(), 0, count)
+	s.mu.Unlock()
+}
+
+// OnSpike регистрирует обработчик всплеска активности чата.
+func (s *Stats) OnSpike(fn func(moment ports.ActivityMoment)) {
+	s.mu.Lock()
+	s.onSpike = append(s.onSpike, fn)
+	s.mu.Unlock()
+}
+
+// TopMoments возвращает до n самых сильных всплесков текущего стрима в хронологическом порядке.
+func (s *Stats) TopMoments(n int) []ports.ActivityMoment {
+	s.mu.RLock()
+	moments := slices.Clone(s.stats.Moments)
+	s.mu.RUnlock()
+
+	slices.SortStableFunc(moments, func(a, b ports.ActivityMoment) int {
+		return -compareIntensity(a, b)
+	})
+	if len(moments) > n {
+		moments = moments[:n]
+	}
+
+	slices.SortFunc(moments, func(a, b ports.ActivityMoment) int {
+		return a.Time.Compare(b.Time)
+	})
+	return moments
+}
+
+// addActivityLocked добавляет активность в минуту t; при переходе на новую минуту
+// закрытая минута проверяется на всплеск.
+func (s *Stats) addActivityLocked(t time.Time, messages, emotes int) {
+	if minute := s.minuteLocked(t); minute != nil {
+		minute.Messages += messages
+		minute.Emotes += emotes
+	}
+}
+
+// minuteLocked возвращает минуту t в ленте активности, добавляя её при необходимости.
+func (s *Stats) minuteLocked(t time.Time) *ActivityMinute {
+	if s.stats.StartStreamTime.IsZero() {
+		return nil
+	}
+
+	minute := t.Truncate(time.Minute)
+	n := len(s.stats.Timeline)
+	if n == 0 || s.stats.Timeline[n-1].Minute.Before(minute) {
+		if n > 0 {
+			s.detectSpikeLocked()
+		}
+		s.stats.Timeline = append(s.stats.Timeline, ActivityMinute{Minute: minute})
+		n++
+	}
+	return &s.stats.Timeline[n-1]
+}
+
+// detectSpikeLocked сравнивает последнюю минуту со средним за spikeWindow предыдущих минут;
+// минуты без сообщений считаются нулевыми.
+func (s *Stats) detectSpikeLocked() {
+	timeline := s.stats.Timeline
+	last := timeline[len(timeline)-1]
+	if last.Minute.Sub(timeline[0].Minute) < spikeMinHistory {
+		return
+	}
+
+	if n := len(s.stats.Moments); n > 0 && last.Minute.Sub(s.stats.Moments[n-1].Time) < spikeCooldown {
+		return
+	}
+
+	from := last.Minute.Add(-spikeWindow * time.Minute)
+	var sumMessages, sumEmotes int
+	for _, m := range timeline[:len(timeline)-1] {
+		if !m.Minute.Before(from) {
+			sumMessages += m.Messages
+			sumEmotes += m.Emotes
+		}
+	}
+	baseMessages, baseEmotes := float64(sumMessages)/spikeWindow, float64(sumEmotes)/spikeWindow
+
+	moment := ports.ActivityMoment{
+		Time:     last.Minute,
+		Timecode: last.Minute.Sub(s.stats.StartStreamTime),
+		StreamID: s.stats.StreamID,
+		Messages: last.Messages,
+		Emotes:   last.Emotes,
+	}
+
+	switch {
+	case last.Messages >= spikeMinMessages && float64(last.Messages) >= baseMessages*spikeFactor:
+		moment.Kind, moment.Baseline = "messages", baseMessages
+	case last.Emotes >= spikeMinEmotes && float64(last.Emotes) >= baseEmotes*spikeFactor:
+		moment.Kind, moment.Baseline = "emotes", baseEmotes
+	default:
+		return
+	}
+
+	if moment.Timecode < 0 {
+		moment.Timecode = 0
+	}
+
+	s.stats.Moments = append(s.stats.Moments, moment)
+	for _, fn := range s.onSpike {
+		go fn(moment)
+	}
+}
+
+// compareIntensity сравнивает всплески по отношению к базовому уровню.
+func compareIntensity(a, b ports.ActivityMoment) int {
+	intensity := func(m ports.ActivityMoment) float64 {
+		value := m.Messages
+		if m.Kind == "emotes" {
+			value = m.Emotes
+		}
+		return float64(value) / max(m.Baseline, 1)
+	}
+
+	ia, ib := intensity(a), intensity(b)
+	switch {
+	case ia < ib:
+		return -1
+	case ia > ib:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,176 @@
+package stream_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"twitchspam/internal/app/domain/stream"
+	"twitchspam/internal/app/infrastructure/storage"
+	"twitchspam/internal/app/ports"
+)
+
+// timelineSession - сессия с лентой активности: по одной минуте на значение messages, последняя минута - lastMinute.
+func timelineSession(lastMinute time.Time, messages ...int) stream.SessionStats {
+	first := lastMinute.Add(-time.Duration(len(messages)-1) * time.Minute)
+	session := stream.SessionStats{StreamID: "42", StartStreamTime: first, EndStreamTime: first, CountMessages: make(map[string]int)}
+	for i, n := range messages {
+		session.Timeline = append(session.Timeline, stream.ActivityMinute{Minute: first.Add(time.Duration(i) * time.Minute), Messages: n})
+	}
+	return session
+}
+
+// newSessionStats создаёт статистику стрима, восстановленную из кэша, и канал найденных всплесков.
+func newSessionStats(t *testing.T, name string, session stream.SessionStats) (ports.StatsPort, <-chan ports.ActivityMoment) {
+	t.Helper()
+
+	cache := storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0)
+	cache.Set(name, session)
+	stats := stream.NewStream(name, nil, cache, nil, nil).Stats()
+
+	spikes := make(chan ports.ActivityMoment, 10)
+	stats.OnSpike(func(moment ports.ActivityMoment) {
+		spikes <- moment
+	})
+	return stats, spikes
+}
+
+func repeat(n, count int) []int {
+	out := make([]int, count)
+	for i := range out {
+		out[i] = n
+	}
+	return out
+}
+
+func waitSpike(t *testing.T, spikes <-chan ports.ActivityMoment) ports.ActivityMoment {
+	t.Helper()
+
+	select {
+	case m := <-spikes:
+		return m
+	case <-time.After(time.Second):
+		require.FailNow(t, "spike not detected")
+		return ports.ActivityMoment{}
+	}
+}
+
+func TestDetectSpike_ClosedMinute(t *testing.T) {
+	t.Parallel()
+
+	// минута со всплеском закрывается первым сообщением следующей минуты
+	last := time.Now().Truncate(time.Minute).Add(-time.Minute)
+	stats, spikes := newSessionStats(t, "spike_closed", timelineSession(last, append(repeat(5, 10), 60)...))
+
+	stats.AddMessage("alice")
+
+	m := waitSpike(t, spikes)
+	assert.Equal(t, "messages", m.Kind)
+	assert.Equal(t, 60, m.Messages)
+	assert.Equal(t, float64(5), m.Baseline)
+	assert.Equal(t, last, m.Time)
+	assert.Equal(t, 10*time.Minute, m.Timecode)
+	assert.Equal(t, "42", m.StreamID)
+}
+
+func TestDetectSpike_FinalMinute(t *testing.T) {
+	t.Parallel()
+
+	// всплеск в последнюю минуту стрима находится при окончании стрима
+	last := time.Now().Truncate(time.Minute)
+	stats, spikes := newSessionStats(t, "spike_final", timelineSession(last, append(repeat(5, 10), 60)...))
+
+	stats.SetEndTime(time.Now())
+
+	m := waitSpike(t, spikes)
+	assert.Equal(t, last, m.Time)
+	assert.Len(t, stats.TopMoments(10), 1)
+
+	// повторное окончание не дублирует момент
+	stats.SetEndTime(time.Now())
+	assert.Len(t, stats.TopMoments(10), 1)
+}
+
+func TestDetectSpike_NoSpike(t *testing.T) {
+	t.Parallel()
+
+	last := time.Now().Truncate(time.Minute)
+	tests := []struct {
+		name     string
+		messages []int
+	}{
+		{name: "below minimum", messages: append(repeat(1, 10), 15)},
+		{name: "below factor", messages: append(repeat(20, 10), 40)},
+		{name: "short history", messages: []int{0, 0, 60}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stats, spikes := newSessionStats(t, "spike_none_"+tt.name, timelineSession(last, tt.messages...))
+			stats.SetEndTime(time.Now())
+			assertNoSpike(t, spikes)
+		})
+	}
+}
+
+func TestDetectSpike_Cooldown(t *testing.T) {
+	t.Parallel()
+
+	// всплеск тремя минутами раньше уже отмечен, новый момент не ставится
+	last := time.Now().Truncate(time.Minute)
+	session := timelineSession(last, append(repeat(5, 10), 60, 5, 5, 80)...)
+	session.Moments = []ports.ActivityMoment{{Time: last.Add(-3 * time.Minute), Kind: "messages", Messages: 60, Baseline: 5}}
+
+	stats, spikes := newSessionStats(t, "spike_cooldown", session)
+	stats.SetEndTime(time.Now())
+	assertNoSpike(t, spikes)
+	assert.Len(t, stats.TopMoments(10), 1)
+}
+
+func assertNoSpike(t *testing.T, spikes <-chan ports.ActivityMoment) {
+	t.Helper()
+
+	select {
+	case m := <-spikes:
+		assert.Fail(t, "unexpected spike", "%+v", m)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestTopMoments(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 10, 18, 19, 0, 0, 0, time.Local)
+	moment := func(minute int, kind string, value int, baseline float64) ports.ActivityMoment {
+		m := ports.ActivityMoment{Time: start.Add(time.Duration(minute) * time.Minute), Kind: kind, Baseline: baseline}
+		if kind == "emotes" {
+			m.Emotes = value
+		} else {
+			m.Messages = value
+		}
+		return m
+	}
+
+	cache := storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0)
+	cache.Set("top_moments", stream.SessionStats{
+		StartStreamTime: start,
+		Moments: []ports.ActivityMoment{
+			moment(10, "messages", 30, 10), // x3
+			moment(20, "emotes", 100, 10),  // x10
+			moment(30, "messages", 50, 0),  // x50, нулевой базовый уровень считается единицей
+			moment(40, "messages", 40, 10), // x4
+		},
+	})
+	stats := stream.NewStream("top_moments", nil, cache, nil, nil).Stats()
+
+	// самые сильные всплески в хронологическом порядке
+	top := stats.TopMoments(3)
+	require.Len(t, top, 3)
+	assert.Equal(t, []time.Time{start.Add(20 * time.Minute), start.Add(30 * time.Minute), start.Add(40 * time.Minute)},
+		[]time.Time{top[0].Time, top[1].Time, top[2].Time})
+
+	assert.Len(t, stats.TopMoments(10), 4)
+	assert.Empty(t, stream.NewStream("top_moments_empty", nil, storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0), nil, nil).Stats().TopMoments(3))
+}
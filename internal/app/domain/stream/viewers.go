@@ -0,0 +1,167 @@
+package stream
+
+import (
+	"math"
+	"time"
+	"twitchspam/internal/app/ports"
+)
+
+const (
+	viewerBucket       = 5 * time.Minute  // шаг временного ряда онлайна
+	viewerFirstHour    = time.Hour        // граница для среднего онлайна после первого часа
+	viewerSwitchWindow = 15 * time.Minute // окно до и после смены категории
+)
+
+// ViewerBucket - значения онлайна, полученные за один интервал временного ряда.
+type ViewerBucket struct {
+	Start time.Time
+	Min   int
+	Max   int
+	Sum   int64
+	Count int
+}
+
+func (b ViewerBucket) avg() float64 {
+	if b.Count == 0 {
+		return 0
+	}
+	return float64(b.Sum) / float64(b.Count)
+}
+
+// addViewersLocked добавляет значение онлайна в интервал временного ряда, которому принадлежит t.
+func (s *Stats) addViewersLocked(t time.Time, viewers int) {
+	if s.stats.StartStreamTime.IsZero() {
+		return
+	}
+
+	start := t.Truncate(viewerBucket)
+	n := len(s.stats.Viewers)
+	if n == 0 || s.stats.Viewers[n-1].Start.Before(start) {
+		s.stats.Viewers = append(s.stats.Viewers, ViewerBucket{Start: start, Min: viewers, Max: viewers})
+		n++
+	}
+
+	b := &s.stats.Viewers[n-1]
+	b.Min = min(b.Min, viewers)
+	b.Max = max(b.Max, viewers)
+	b.Sum += int64(viewers)
+	b.Count++
+}
+
+// ViewerSeries возвращает временной ряд онлайна и метрики по нему. Пустой streamID или "current" -
+// текущий стрим, иначе стрим из архива с этим идентификатором.
+func (s *Stats) ViewerSeries(streamID string) ([]ports.ViewerPoint, ports.ViewerMetrics, bool) {
+	var ss SessionStats
+	if current := s.getStatsCopy(); streamID == "" || streamID == "current" || streamID == current.StreamID {
+		ss = current
+	} else {
+		found := false
+		for _, archived := range s.archived() {
+			if archived.StreamID == streamID {
+				ss, found = archived, true
+			}
+		}
+		if !found {
+			return nil, ports.ViewerMetrics{}, false
+		}
+	}
+
+	if ss.StartStreamTime.IsZero() {
+		return nil, ports.ViewerMetrics{}, false
+	}
+
+	points := make([]ports.ViewerPoint, 0, len(ss.Viewers))
+	for _, b := range ss.Viewers {
+		points = append(points, ports.ViewerPoint{
+			Time:    b.Start,
+			Min:     b.Min,
+			Max:     b.Max,
+			Avg:     math.Round(b.avg()),
+			Samples: b.Count,
+		})
+	}
+	return points, ss.viewerMetrics(), true
+}
+
+// viewerChart возвращает средний онлайн по интервалам ряда для графика отчёта.
+// Онлайн мог не обновляться весь интервал, такие пропуски заполняются предыдущим значением.
+func (ss *SessionStats) viewerChart() (values []float64, from, to time.Time) {
+	if len(ss.Viewers) == 0 {
+		return nil, time.Time{}, time.Time{}
+	}
+
+	from, to = ss.Viewers[0].Start, ss.Viewers[len(ss.Viewers)-1].Start
+	values = make([]float64, 0, int(to.Sub(from)/viewerBucket)+1)
+	for i, b := range ss.Viewers {
+		if i > 0 {
+			for gap := ss.Viewers[i-1].Start.Add(viewerBucket); gap.Before(b.Start); gap = gap.Add(viewerBucket) {
+				values = append(values, values[len(values)-1])
+			}
+		}
+		values = append(values, math.Round(b.avg()))
+	}
+	return values, from, to
+}
+
+// viewerMetrics считает пик онлайна, средний онлайн за первый час и после него,
+// а также изменение онлайна вокруг смен категорий.
+func (ss *SessionStats) viewerMetrics() ports.ViewerMetrics {
+	m := ports.ViewerMetrics{
+		PeakViewers: ss.Online.MaxViewers,
+		PeakTime:    ss.Online.PeakTime,
+	}
+
+	border := ss.StartStreamTime.Add(viewerFirstHour)
+	var first, after ViewerBucket
+	for _, b := range ss.Viewers {
+		if m.PeakTime.IsZero() && b.Max == m.PeakViewers {
+			m.PeakTime = b.Start
+		}
+
+		if b.Start.Before(border) {
+			first.Sum += b.Sum
+			first.Count += b.Count
+		} else {
+			after.Sum += b.Sum
+			after.Count += b.Count
+		}
+	}
+
+	m.AvgFirstHour = math.Round(first.avg())
+	m.AvgAfterFirstHour = math.Round(after.avg())
+	if first.Count > 0 && after.Count > 0 {
+		m.Retention = math.Round(after.avg()/first.avg()*100) / 100
+	}
+
+	var prev string
+	for _, c := range ss.CategoryHistory {
+		if c.Name == "" {
+			continue
+		}
+
+		if prev != "" && prev != c.Name {
+			m.CategorySwitches = append(m.CategorySwitches, ports.CategorySwitch{
+				Time:   c.StartTime,
+				From:   prev,
+				To:     c.Name,
+				Before: math.Round(ss.avgViewersBetween(c.StartTime.Add(-viewerSwitchWindow), c.StartTime)),
+				After:  math.Round(ss.avgViewersBetween(c.StartTime, c.StartTime.Add(viewerSwitchWindow))),
+			})
+		}
+		prev = c.Name
+	}
+	return m
+}
+
+// avgViewersBetween - средний онлайн по интервалам ряда, начавшимся в [from, to).
+// Интервал, в который попала граница, относится к периоду по времени своего начала.
+func (ss *SessionStats) avgViewersBetween(from, to time.Time) float64 {
+	var total ViewerBucket
+	for _, b := range ss.Viewers {
+		if !b.Start.Before(from) && b.Start.Before(to) {
+			total.Sum += b.Sum
+			total.Count += b.Count
+		}
+	}
+	return total.avg()
+}
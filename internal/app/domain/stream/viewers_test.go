@@ -0,0 +1,150 @@
+package stream_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+	"twitchspam/internal/app/domain/stream"
+	"twitchspam/internal/app/infrastructure/storage"
+	"twitchspam/internal/app/ports"
+)
+
+func TestViewers_SeriesAndMetrics(t *testing.T) {
+	t.Parallel()
+
+	stats := newTestStream("viewers").Stats()
+	_, _, ok := stats.ViewerSeries("")
+	assert.False(t, ok)
+
+	stats.SetStreamID("42")
+	stats.SetStartTime(time.Now().Add(-2 * time.Hour))
+	stats.SetOnline(100)
+	stats.SetOnline(300)
+	stats.SetOnline(200)
+
+	points, metrics, ok := stats.ViewerSeries("current")
+	assert.True(t, ok)
+	// значения могут попасть в соседние интервалы, если тест идёт на границе
+	minViewers, maxViewers, samples := 0, 0, 0
+	for i, p := range points {
+		if i == 0 || p.Min < minViewers {
+			minViewers = p.Min
+		}
+		maxViewers = max(maxViewers, p.Max)
+		samples += p.Samples
+	}
+	assert.Equal(t, 100, minViewers)
+	assert.Equal(t, 300, maxViewers)
+	assert.Equal(t, 3, samples)
+	assert.Equal(t, 300, metrics.PeakViewers)
+	assert.False(t, metrics.PeakTime.IsZero())
+	assert.Equal(t, float64(200), metrics.AvgAfterFirstHour)
+	assert.Zero(t, metrics.AvgFirstHour)
+
+	stats.SetEndTime(time.Now())
+	_, archived, ok := stats.ViewerSeries("42")
+	assert.True(t, ok)
+	assert.Equal(t, metrics.PeakViewers, archived.PeakViewers)
+
+	_, _, ok = stats.ViewerSeries("unknown")
+	assert.False(t, ok)
+}
+
+// viewerSession - сессия с рядом онлайна: по одному значению на интервал, ключ - минуты от начала стрима.
+func viewerSession(start time.Time, viewers map[int]int, categories ...stream.CategoryInterval) stream.SessionStats {
+	ss := stream.SessionStats{StreamID: "42", StartStreamTime: start, EndStreamTime: start.Add(2 * time.Hour), CategoryHistory: categories}
+	for _, minute := range slices.Sorted(maps.Keys(viewers)) {
+		v := viewers[minute]
+		ss.Viewers = append(ss.Viewers, stream.ViewerBucket{Start: start.Add(time.Duration(minute) * time.Minute), Min: v, Max: v, Sum: int64(v), Count: 1})
+		ss.Online.MaxViewers = max(ss.Online.MaxViewers, v)
+	}
+	return ss
+}
+
+func viewerStats(t *testing.T, name string, ss stream.SessionStats) ports.StatsPort {
+	t.Helper()
+
+	cache := storage.NewCache[stream.SessionStats](0, 0, false, false, "", 0)
+	cache.Set(name, ss)
+	return stream.NewStream(name, nil, cache, nil, nil).Stats()
+}
+
+func TestViewers_CategorySwitches(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 10, 18, 19, 0, 0, 0, time.Local)
+	category := func(name string, minute int) stream.CategoryInterval {
+		return stream.CategoryInterval{Name: name, StartTime: start.Add(time.Duration(minute) * time.Minute)}
+	}
+
+	stats := viewerStats(t, "viewers_switches", viewerSession(start,
+		map[int]int{0: 100, 10: 120, 15: 200, 60: 300},
+		category("Just Chatting", 0),
+		category("Dota 2", 15),
+		category("", 30),       // категория неизвестна - не смена
+		category("Dota 2", 40), // та же категория после пропуска - не смена
+		category("Just Chatting", 60),
+	))
+
+	_, metrics, ok := stats.ViewerSeries("current")
+	require.True(t, ok)
+	assert.Equal(t, []ports.CategorySwitch{
+		// онлайн за 15 минут до смены и 15 минут после неё
+		{Time: start.Add(15 * time.Minute), From: "Just Chatting", To: "Dota 2", Before: 110, After: 200},
+		{Time: start.Add(60 * time.Minute), From: "Dota 2", To: "Just Chatting", Before: 0, After: 300},
+	}, metrics.CategorySwitches)
+	assert.Equal(t, 300, metrics.PeakViewers)
+	assert.Equal(t, start.Add(time.Hour), metrics.PeakTime)
+}
+
+func TestViewers_Retention(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 10, 18, 19, 0, 0, 0, time.Local)
+	tests := []struct {
+		name      string
+		viewers   map[int]int
+		first     float64
+		after     float64
+		retention float64
+	}{
+		{name: "grew", viewers: map[int]int{0: 100, 30: 200, 60: 300, 90: 150}, first: 150, after: 225, retention: 1.5},
+		{name: "dropped", viewers: map[int]int{0: 300, 55: 300, 65: 100}, first: 300, after: 100, retention: 0.33},
+		{name: "first hour only", viewers: map[int]int{0: 100, 30: 200}, first: 150},
+		{name: "after first hour only", viewers: map[int]int{60: 100, 90: 200}, after: 150},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stats := viewerStats(t, "viewers_retention_"+tt.name, viewerSession(start, tt.viewers))
+			_, metrics, ok := stats.ViewerSeries("42")
+			require.True(t, ok)
+			assert.Equal(t, tt.first, metrics.AvgFirstHour)
+			assert.Equal(t, tt.after, metrics.AvgAfterFirstHour)
+			assert.Equal(t, tt.retention, metrics.Retention)
+		})
+	}
+}
+
+func TestReport_ViewerChart(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 10, 18, 19, 0, 0, 0, time.Local)
+
+	// график онлайна строится по тому же ряду, что и ViewerSeries
+	report := viewerStats(t, "viewers_report", viewerSession(start, map[int]int{0: 100, 30: 200})).Report("bot", nil)
+	assert.Contains(t, report, "== онлайн ==\n")
+	assert.Contains(t, report, "средний онлайн за первый час: 150 • после первого часа: 0")
+	assert.NotContains(t, report, "== сообщения в минуту ==")
+	// интервалы без значений заполнены предыдущим: 7 столбцов по 5 минут
+	assert.Contains(t, report, "    100 |#######\n")
+	assert.Contains(t, report, "    200 |      #\n")
+
+	report = viewerStats(t, "viewers_report_empty", viewerSession(start, nil)).Report("bot", nil)
+	assert.NotContains(t, report, "== онлайн ==")
+}
@@ -14,6 +14,18 @@ type BanwordsTemplate struct {
 }
 
 func NewBanwords(banwords config.Banwords) *BanwordsTemplate {
+	bt := &BanwordsTemplate{
+		trieWords:    trie.NewTrie(map[string]struct{}{}, trie.CharMode),
+		trieCase:     trie.NewTrie(map[string]struct{}{}, trie.CharMode),
+		trieContains: trie.NewTrie(map[string]struct{}{}, trie.CharMode),
+		trieExclude:  trie.NewTrie(map[string]struct{}{}, trie.CharMode),
+	}
+	bt.Update(banwords)
+
+	return bt
+}
+
+func (bt *BanwordsTemplate) Update(banwords config.Banwords) {
 	mWords := make(map[string]struct{})
 	mCase := make(map[string]struct{})
 	mContains := make(map[string]struct{})
@@ -39,14 +51,10 @@ func NewBanwords(banwords config.Banwords) *BanwordsTemplate {
 		mExclude[transliterateRuToEnKeyboard(word)] = struct{}{}
 	}
 
-	bt := &BanwordsTemplate{
-		trieWords:    trie.NewTrie(mWords, trie.CharMode),
-		trieCase:     trie.NewTrie(mCase, trie.CharMode),
-		trieContains: trie.NewTrie(mContains, trie.CharMode),
-		trieExclude:  trie.NewTrie(mExclude, trie.CharMode),
-	}
-
-	return bt
+	bt.trieWords.Update(mWords)
+	bt.trieCase.Update(mCase)
+	bt.trieContains.Update(mContains)
+	bt.trieExclude.Update(mExclude)
 }
 
 func (bt *BanwordsTemplate) CheckMessage(wordsOriginal, wordsLower []string) bool {
@@ -4,125 +4,505 @@ import (
 	"context"
 	"errors"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"twitchspam/internal/app/domain"
 	"twitchspam/internal/app/domain/message"
-	"twitchspam/internal/app/infrastructure/config"
 	"twitchspam/internal/app/ports"
 )
 
+// finishedLimit - сколько завершённых массбанов хранится для отмены наказаний и отчётов.
+const finishedLimit = 20
+
 type NukeTemplate struct {
-	nuke    *Nuke
-	oldNuke *Nuke
-	timer   *time.Timer
-	mu      sync.Mutex
+	nukes    map[string]*Nuke // ключ - название массбана
+	finished map[string]*Nuke // завершённые массбаны по названию
+	last     *Nuke            // последний завершённый массбан
+	nextID   int
+	onFinish func(report ports.NukeReport)
+	mu       sync.RWMutex
+
+	cache    ports.CachePort[[]ports.NukeState]
+	cacheKey string
 }
 
 type Nuke struct {
-	expiresAt     time.Time
-	duration      time.Duration
-	punishment    config.Punishment
-	containsWords []string
-	words         []string
-	regexp        *regexp.Regexp
+	name      string
+	startedAt time.Time
+	endedAt   time.Time
+	expiresAt time.Time
+	rule      ports.NukeRule
+	hits      []ports.NukeHit
+	targets   map[string]ports.NukeTarget // ключ - user id
+	timer     *time.Timer
+	pending   sync.WaitGroup // scrollback и его запросы наказаний, отчёт ждёт их завершения
 
 	cancel  context.CancelFunc
-	startFn func(ctx context.Context)
+	startFn func(ctx context.Context, name string)
 }
 
 func NewNuke() *NukeTemplate {
-	return &NukeTemplate{}
+	return &NukeTemplate{
+		nukes:    make(map[string]*Nuke),
+		finished: make(map[string]*Nuke),
+	}
 }
 
-func (n *NukeTemplate) Start(punishment config.Punishment, duration time.Duration, containsWords, words []string, regexp *regexp.Regexp, startFn func(ctx context.Context)) {
+// NewPersistentNuke создаёт массбаны, сохраняемые в кэш под ключом key,
+// и восстанавливает ещё не истёкшие массбаны с оставшейся длительностью.
+func NewPersistentNuke(cache ports.CachePort[[]ports.NukeState], key string) *NukeTemplate {
+	n := &NukeTemplate{
+		nukes:    make(map[string]*Nuke),
+		finished: make(map[string]*Nuke),
+		cache:    cache,
+		cacheKey: key,
+	}
+
+	states, ok := cache.Get(key)
+	if !ok {
+		return n
+	}
+
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	if n.timer != nil {
-		n.timer.Stop()
-		n.timer = nil
+	now := time.Now()
+	for _, state := range states {
+		if !state.ExpiresAt.After(now) {
+			continue
+		}
+
+		rule := ports.NukeRule{
+			Punishment:    state.Punishment,
+			Duration:      state.ExpiresAt.Sub(state.StartedAt),
+			ContainsWords: state.ContainsWords,
+			Words:         state.Words,
+			Sample:        state.Sample,
+			Threshold:     state.Threshold,
+		}
+		if state.Regexp != "" {
+			re, err := regexp.Compile(state.Regexp)
+			if err != nil {
+				continue
+			}
+			rule.Regexp = re
+		}
+
+		n.startLocked(state.Name, rule, state.StartedAt, state.ExpiresAt, nil)
 	}
+	n.persistLocked()
+
+	return n
+}
 
-	if n.nuke != nil && n.nuke.cancel != nil {
-		n.nuke.cancel()
+func (n *NukeTemplate) Start(name string, rule ports.NukeRule, startFn func(ctx context.Context, name string)) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now()
+	name = n.startLocked(name, rule, now, now.Add(rule.Duration), startFn)
+	n.persistLocked()
+	return name
+}
+
+func (n *NukeTemplate) startLocked(name string, rule ports.NukeRule, startedAt, expiresAt time.Time, startFn func(ctx context.Context, name string)) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		for {
+			n.nextID++
+			name = strconv.Itoa(n.nextID)
+			if _, ok := n.nukes[name]; !ok {
+				break
+			}
+		}
+	}
+
+	if prev, ok := n.nukes[name]; ok {
+		n.stopLocked(prev)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	n.nuke = &Nuke{
-		expiresAt:     time.Now().Add(duration),
-		duration:      duration,
-		punishment:    punishment,
-		containsWords: containsWords,
-		words:         words,
-		regexp:        regexp,
-		cancel:        cancel,
-		startFn:       startFn,
-	}
-
-	n.timer = time.AfterFunc(duration, func() {
+	nuke := &Nuke{
+		name:      name,
+		startedAt: startedAt,
+		expiresAt: expiresAt,
+		rule:      rule,
+		targets:   make(map[string]ports.NukeTarget),
+		cancel:    cancel,
+		startFn:   startFn,
+	}
+	n.nukes[name] = nuke
+
+	nuke.timer = time.AfterFunc(time.Until(expiresAt), func() {
 		n.mu.Lock()
 		defer n.mu.Unlock()
 
-		n.oldNuke = n.nuke
-		n.nuke = nil
-		n.timer = nil
+		if cur, ok := n.nukes[name]; !ok || cur != nuke {
+			return
+		}
+
+		delete(n.nukes, name)
+		n.stopLocked(nuke)
+		n.persistLocked()
 	})
 
 	if startFn != nil {
-		go startFn(ctx)
+		nuke.pending.Add(1)
+		go func() {
+			defer nuke.pending.Done()
+			startFn(ctx, name)
+		}()
 	}
+	return name
 }
 
-func (n *NukeTemplate) Restart() error {
-	if n.oldNuke == nil {
-		return errors.New("a repeat of the previous nuke is not possible")
+func (n *NukeTemplate) Restart() (string, error) {
+	n.mu.RLock()
+	old := n.last
+	n.mu.RUnlock()
+
+	if old == nil {
+		return "", errors.New("a repeat of the previous nuke is not possible")
+	}
+
+	return n.Start(old.name, old.rule, old.startFn), nil
+}
+
+// Cancel останавливает массбан с указанным названием, при пустом названии - все активные массбаны.
+func (n *NukeTemplate) Cancel(name string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		for key, nuke := range n.nukes {
+			n.stopLocked(nuke)
+			delete(n.nukes, key)
+		}
+		n.persistLocked()
+		return nil
+	}
+
+	nuke, ok := n.nukes[name]
+	if !ok {
+		return errors.New("nuke not found")
 	}
 
-	n.Start(n.oldNuke.punishment, n.oldNuke.duration, n.oldNuke.containsWords, n.oldNuke.words, n.oldNuke.regexp, n.oldNuke.startFn)
+	n.stopLocked(nuke)
+	delete(n.nukes, name)
+	n.persistLocked()
 	return nil
 }
 
-func (n *NukeTemplate) Cancel() {
+func (n *NukeTemplate) persistLocked() {
+	if n.cache == nil {
+		return
+	}
+
+	states := make([]ports.NukeState, 0, len(n.nukes))
+	for _, nuke := range n.nukes {
+		state := ports.NukeState{
+			Name:          nuke.name,
+			Punishment:    nuke.rule.Punishment,
+			StartedAt:     nuke.startedAt,
+			ExpiresAt:     nuke.expiresAt,
+			ContainsWords: nuke.rule.ContainsWords,
+			Words:         nuke.rule.Words,
+			Sample:        nuke.rule.Sample,
+			Threshold:     nuke.rule.Threshold,
+		}
+		if nuke.rule.Regexp != nil {
+			state.Regexp = nuke.rule.Regexp.String()
+		}
+		states = append(states, state)
+	}
+
+	if len(states) == 0 {
+		n.cache.ClearKey(n.cacheKey)
+		return
+	}
+	n.cache.Set(n.cacheKey, states)
+}
+
+func (n *NukeTemplate) stopLocked(nuke *Nuke) {
+	if nuke.timer != nil {
+		nuke.timer.Stop()
+		nuke.timer = nil
+	}
+
+	if nuke.cancel != nil {
+		nuke.cancel()
+	}
+
+	nuke.endedAt = time.Now()
+	n.last = nuke
+	n.finished[nuke.name] = nuke
+	if len(n.finished) > finishedLimit {
+		var oldest *Nuke
+		for _, f := range n.finished {
+			if oldest == nil || f.endedAt.Before(oldest.endedAt) {
+				oldest = f
+			}
+		}
+		delete(n.finished, oldest.name)
+	}
+
+	if n.onFinish != nil {
+		onFinish := n.onFinish
+		go func() {
+			// в отчёт попадают совпадения и ошибки запросов, завершившихся уже после остановки
+			nuke.pending.Wait()
+
+			n.mu.RLock()
+			report := nuke.report()
+			n.mu.RUnlock()
+			onFinish(report)
+		}()
+	}
+}
+
+// Acquire отмечает запрос наказания активного массбана; отчёт по завершении массбана
+// отправляется только после release всех запросов. ok == false - массбан уже остановлен.
+func (n *NukeTemplate) Acquire(name string) (release func(), ok bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	nuke, ok := n.nukes[name]
+	if !ok {
+		return func() {}, false
+	}
+
+	nuke.pending.Add(1)
+	return nuke.pending.Done, true
+}
+
+// OnFinish задаёт обработчик, вызываемый по завершении каждого массбана.
+func (n *NukeTemplate) OnFinish(fn func(report ports.NukeReport)) {
+	n.mu.Lock()
+	n.onFinish = fn
+	n.mu.Unlock()
+}
+
+// Report возвращает отчёт по активному массбану с указанным названием; при пустом названии -
+// по единственному активному массбану или, если активных нет, по последнему завершённому.
+func (n *NukeTemplate) Report(name string) (ports.NukeReport, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if nuke, ok := n.nukes[name]; ok {
+		return nuke.report(), true
+	}
+
+	if name == "" && len(n.nukes) == 1 {
+		for _, nuke := range n.nukes {
+			return nuke.report(), true
+		}
+	}
+
+	if name == "" && n.last != nil {
+		return n.last.report(), true
+	}
+	if nuke, ok := n.finished[name]; ok {
+		return nuke.report(), true
+	}
+	return ports.NukeReport{}, false
+}
+
+func (nuke *Nuke) report() ports.NukeReport {
+	return ports.NukeReport{
+		Name:      nuke.name,
+		Rule:      nuke.rule,
+		StartedAt: nuke.startedAt,
+		EndedAt:   nuke.endedAt,
+		Hits:      append([]ports.NukeHit(nil), nuke.hits...),
+	}
+}
+
+func (n *NukeTemplate) List() []ports.NukeInfo {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	list := make([]ports.NukeInfo, 0, len(n.nukes))
+	for _, nuke := range n.nukes {
+		list = append(list, ports.NukeInfo{
+			Name:      nuke.name,
+			Rule:      nuke.rule,
+			ExpiresAt: nuke.expiresAt,
+			Targets:   len(nuke.targets),
+		})
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ExpiresAt.Before(list[j].ExpiresAt) })
+	return list
+}
+
+// Record сохраняет совпадение массбана; неудачные запросы к API попадают только в отчёт.
+func (n *NukeTemplate) Record(name string, hit ports.NukeHit) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	if n.nuke == nil {
+	nuke, ok := n.nukes[name]
+	if !ok {
+		// запросы из пула могут завершиться уже после остановки массбана
+		nuke, ok = n.finished[name]
+	}
+	if !ok {
+		return
+	}
+
+	if hit.Time.IsZero() {
+		hit.Time = time.Now()
+	}
+	nuke.hits = append(nuke.hits, hit)
+
+	if hit.Error != "" || hit.UserID == "" {
+		return
+	}
+
+	// бан перекрывает таймаут, а таймаут - удаление сообщения
+	if prev, ok := nuke.targets[hit.UserID]; ok && actionWeight[prev.Action] >= actionWeight[hit.Action] {
 		return
 	}
 
-	if n.timer != nil {
-		n.timer.Stop()
-		n.timer = nil
+	nuke.targets[hit.UserID] = ports.NukeTarget{
+		UserID:   hit.UserID,
+		Username: hit.Username,
+		Action:   hit.Action,
+		Time:     hit.Time,
+	}
+}
+
+// Targets возвращает пользователей, наказанных активными и завершёнными массбанами.
+func (n *NukeTemplate) Targets() []ports.NukeTarget {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	merged := make(map[string]ports.NukeTarget)
+	for _, nuke := range n.all() {
+		for userID, t := range nuke.targets {
+			if prev, ok := merged[userID]; ok && actionWeight[prev.Action] >= actionWeight[t.Action] {
+				continue
+			}
+			merged[userID] = t
+		}
+	}
+
+	targets := make([]ports.NukeTarget, 0, len(merged))
+	for _, t := range merged {
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+func (n *NukeTemplate) ClearTargets() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, nuke := range n.all() {
+		nuke.targets = make(map[string]ports.NukeTarget)
+	}
+}
+
+// RemoveTargets убирает пользователей, с которых сняты наказания. Наказание, выданное
+// позже снятого, остаётся.
+func (n *NukeTemplate) RemoveTargets(targets []ports.NukeTarget) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, nuke := range n.all() {
+		for _, t := range targets {
+			if cur, ok := nuke.targets[t.UserID]; ok && !cur.Time.After(t.Time) {
+				delete(nuke.targets, t.UserID)
+			}
+		}
+	}
+}
+
+func (n *NukeTemplate) all() []*Nuke {
+	nukes := make([]*Nuke, 0, len(n.nukes)+len(n.finished))
+	for _, nuke := range n.nukes {
+		nukes = append(nukes, nuke)
 	}
 
-	if n.nuke.cancel != nil {
-		n.nuke.cancel()
+	for name, nuke := range n.finished {
+		if cur, ok := n.nukes[name]; !ok || cur != nuke {
+			nukes = append(nukes, nuke)
+		}
 	}
+	return nukes
+}
 
-	n.oldNuke = n.nuke
-	n.nuke = nil
+var actionWeight = map[string]int{
+	"delete":  1,
+	"warn":    2,
+	"timeout": 3,
+	"ban":     4,
 }
 
-func (n *NukeTemplate) Check(text *message.Text, ignoreNuke bool) *ports.CheckerAction {
-	if n.nuke == nil || ignoreNuke {
+// Check проверяет сообщение всеми активными массбанами и возвращает самое строгое наказание.
+func (n *NukeTemplate) Check(text *message.Text, ignoreNuke bool) (string, *ports.CheckerAction) {
+	if ignoreNuke {
+		return "", nil
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	var name string
+	var result *ports.CheckerAction
+	for _, nuke := range n.nukes {
+		action := checkRule(nuke.rule, text)
+		if action == nil {
+			continue
+		}
+		action.Rule = nuke.name
+
+		if result == nil || actionWeight[action.Type] > actionWeight[result.Type] {
+			name, result = nuke.name, action
+		}
+	}
+
+	return name, result
+}
+
+func (n *NukeTemplate) CheckOne(name string, text *message.Text, ignoreNuke bool) *ports.CheckerAction {
+	if ignoreNuke {
 		return nil
 	}
 
+	n.mu.RLock()
+	nuke, ok := n.nukes[name]
+	n.mu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+	return checkRule(nuke.rule, text)
+}
+
+func checkRule(rule ports.NukeRule, text *message.Text) *ports.CheckerAction {
 	apply := func() *ports.CheckerAction {
 		return &ports.CheckerAction{
-			Type:       n.nuke.punishment.Action,
+			Type:       rule.Punishment.Action,
 			ReasonMod:  "массбан",
 			ReasonUser: "Не используй запрещенное слово!",
-			Duration:   time.Duration(n.nuke.punishment.Duration) * time.Second,
+			Duration:   time.Duration(rule.Punishment.Duration) * time.Second,
 		}
 	}
 
-	if n.nuke.regexp != nil && n.nuke.regexp.MatchString(text.Text()) {
+	if rule.Regexp != nil && rule.Regexp.MatchString(text.Text()) {
+		return apply()
+	}
+
+	if len(rule.Sample) > 0 && domain.JaccardHashSimilarity(text.Words(message.RemovePunctuationOption), rule.Sample) >= rule.Threshold {
 		return apply()
 	}
 
-	for _, w := range n.nuke.containsWords {
+	for _, w := range rule.ContainsWords {
 		if strings.Contains(
 			text.Text(message.LowerOption, message.RemoveDuplicateLettersOption),
 			(&message.Text{Original: w}).Text(message.LowerOption, message.RemoveDuplicateLettersOption),
@@ -131,7 +511,7 @@ func (n *NukeTemplate) Check(text *message.Text, ignoreNuke bool) *ports.Checker
 		}
 	}
 
-	for _, w := range n.nuke.words {
+	for _, w := range rule.Words {
 		if strings.Contains(
 			text.Text(message.LowerOption, message.RemoveDuplicateLettersOption),
 			(&message.Text{Original: w}).Text(message.LowerOption, message.RemoveDuplicateLettersOption),
@@ -0,0 +1,277 @@
+package template_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	"twitchspam/internal/app/domain/message"
+	"twitchspam/internal/app/domain/template"
+	"twitchspam/internal/app/infrastructure/config"
+	"twitchspam/internal/app/infrastructure/storage"
+	"twitchspam/internal/app/ports"
+)
+
+func TestNukeTargets_KeepStrongestAction(t *testing.T) {
+	t.Parallel()
+
+	nuke := template.NewNuke()
+	name := nuke.Start("", ports.NukeRule{
+		Punishment:    config.Punishment{Action: "timeout", Duration: 60},
+		Duration:      time.Minute,
+		ContainsWords: []string{"спам"},
+	}, nil)
+
+	nuke.Record(name, ports.NukeHit{UserID: "1", Username: "user1", Action: "delete"})
+	nuke.Record(name, ports.NukeHit{UserID: "1", Username: "user1", Action: "ban"})
+	nuke.Record(name, ports.NukeHit{UserID: "1", Username: "user1", Action: "timeout"})
+	nuke.Record(name, ports.NukeHit{UserID: "2", Username: "user2", Action: "timeout"})
+	nuke.Record(name, ports.NukeHit{UserID: "3", Username: "user3", Action: "timeout", Error: "api error"})
+
+	targets := nuke.Targets()
+	assert.Len(t, targets, 2)
+
+	actions := make(map[string]string, len(targets))
+	for _, target := range targets {
+		actions[target.UserID] = target.Action
+	}
+	assert.Equal(t, "ban", actions["1"])
+	assert.Equal(t, "timeout", actions["2"])
+}
+
+func TestNukeTargets_AvailableAfterCancel(t *testing.T) {
+	t.Parallel()
+
+	nuke := template.NewNuke()
+	name := nuke.Start("links", ports.NukeRule{
+		Punishment:    config.Punishment{Action: "ban"},
+		Duration:      time.Minute,
+		ContainsWords: []string{"спам"},
+	}, nil)
+	nuke.Record(name, ports.NukeHit{UserID: "1", Username: "user1", Action: "ban"})
+	assert.NoError(t, nuke.Cancel(name))
+
+	assert.Len(t, nuke.Targets(), 1)
+
+	nuke.ClearTargets()
+	assert.Empty(t, nuke.Targets())
+}
+
+func TestNukeTargets_KeptForEachCancelledNuke(t *testing.T) {
+	t.Parallel()
+
+	nuke := template.NewNuke()
+	rule := ports.NukeRule{
+		Punishment:    config.Punishment{Action: "ban"},
+		Duration:      time.Minute,
+		ContainsWords: []string{"спам"},
+	}
+	nuke.Start("links", rule, nil)
+	nuke.Start("caps", rule, nil)
+	nuke.Record("links", ports.NukeHit{UserID: "1", Username: "user1", Action: "ban"})
+	nuke.Record("caps", ports.NukeHit{UserID: "2", Username: "user2", Action: "ban"})
+
+	assert.NoError(t, nuke.Cancel(""))
+	assert.Len(t, nuke.Targets(), 2)
+
+	_, ok := nuke.Report("links")
+	assert.True(t, ok)
+	_, ok = nuke.Report("caps")
+	assert.True(t, ok)
+}
+
+func TestNukeTargets_RemoveOnlyUnbanned(t *testing.T) {
+	t.Parallel()
+
+	nuke := template.NewNuke()
+	name := nuke.Start("links", ports.NukeRule{
+		Punishment:    config.Punishment{Action: "ban"},
+		Duration:      time.Minute,
+		ContainsWords: []string{"спам"},
+	}, nil)
+	nuke.Record(name, ports.NukeHit{UserID: "1", Username: "user1", Action: "ban"})
+	nuke.Record(name, ports.NukeHit{UserID: "2", Username: "user2", Action: "ban"})
+	assert.NoError(t, nuke.Cancel(name))
+
+	var unbanned []ports.NukeTarget
+	for _, target := range nuke.Targets() {
+		if target.UserID == "1" {
+			unbanned = append(unbanned, target)
+		}
+	}
+	nuke.RemoveTargets(unbanned)
+
+	targets := nuke.Targets()
+	if assert.Len(t, targets, 1) {
+		assert.Equal(t, "2", targets[0].UserID)
+	}
+}
+
+func TestNukeCheck_Sample(t *testing.T) {
+	t.Parallel()
+
+	sample := &message.Text{Original: "заходите на мой канал там раздача скинов"}
+	nuke := template.NewNuke()
+	nuke.Start("", ports.NukeRule{
+		Punishment: config.Punishment{Action: "ban"},
+		Duration:   time.Minute,
+		Sample:     sample.Words(message.RemovePunctuationOption),
+		Threshold:  0.7,
+	}, nil)
+
+	_, action := nuke.Check(&message.Text{Original: "заходите на мой канал, там раздача скинов!"}, false)
+	assert.NotNil(t, action)
+
+	_, action = nuke.Check(&message.Text{Original: "привет чат как дела"}, false)
+	assert.Nil(t, action)
+}
+
+func TestNukeCheck_MultipleNamed(t *testing.T) {
+	t.Parallel()
+
+	nuke := template.NewNuke()
+	nuke.Start("phrase", ports.NukeRule{
+		Punishment: config.Punishment{Action: "timeout", Duration: 60},
+		Duration:   time.Minute,
+		Words:      []string{"купи подписчиков"},
+	}, nil)
+	nuke.Start("links", ports.NukeRule{
+		Punishment:    config.Punishment{Action: "ban"},
+		Duration:      time.Minute,
+		ContainsWords: []string{"bit.ly"},
+	}, nil)
+	assert.Len(t, nuke.List(), 2)
+
+	name, action := nuke.Check(&message.Text{Original: "купи подписчиков тут bit.ly/abc"}, false)
+	assert.Equal(t, "links", name)
+	assert.Equal(t, "ban", action.Type)
+
+	assert.NoError(t, nuke.Cancel("links"))
+	name, action = nuke.Check(&message.Text{Original: "купи подписчиков тут bit.ly/abc"}, false)
+	assert.Equal(t, "phrase", name)
+	assert.Equal(t, "timeout", action.Type)
+
+	assert.Error(t, nuke.Cancel("links"))
+	assert.NoError(t, nuke.Cancel(""))
+	assert.Empty(t, nuke.List())
+}
+
+func TestNukeReport_OnFinish(t *testing.T) {
+	t.Parallel()
+
+	nuke := template.NewNuke()
+	reports := make(chan ports.NukeReport, 1)
+	nuke.OnFinish(func(report ports.NukeReport) { reports <- report })
+
+	name := nuke.Start("links", ports.NukeRule{
+		Punishment:    config.Punishment{Action: "ban"},
+		Duration:      time.Minute,
+		ContainsWords: []string{"спам"},
+	}, nil)
+	nuke.Record(name, ports.NukeHit{UserID: "1", Username: "user1", Action: "ban", Source: "live"})
+	nuke.Record(name, ports.NukeHit{UserID: "2", Username: "user2", Action: "ban", Source: "scrollback", Error: "api error"})
+
+	live, ok := nuke.Report(name)
+	assert.True(t, ok)
+	assert.True(t, live.EndedAt.IsZero())
+	assert.Len(t, live.Hits, 2)
+
+	assert.NoError(t, nuke.Cancel(name))
+
+	select {
+	case report := <-reports:
+		assert.Equal(t, "links", report.Name)
+		assert.False(t, report.EndedAt.IsZero())
+		assert.Len(t, report.Hits, 2)
+	case <-time.After(time.Second):
+		t.Fatal("report was not delivered")
+	}
+}
+
+func TestNukePersistent_Restore(t *testing.T) {
+	t.Parallel()
+
+	cache := storage.NewCache[[]ports.NukeState](0, 0, false, false, "", 0)
+	cache.Set("channel", []ports.NukeState{
+		{
+			Name:       "expired",
+			Punishment: config.Punishment{Action: "ban"},
+			StartedAt:  time.Now().Add(-10 * time.Minute),
+			ExpiresAt:  time.Now().Add(-5 * time.Minute),
+			Words:      []string{"спам"},
+		},
+		{
+			Name:       "links",
+			Punishment: config.Punishment{Action: "timeout", Duration: 60},
+			StartedAt:  time.Now().Add(-time.Minute),
+			ExpiresAt:  time.Now().Add(4 * time.Minute),
+			Regexp:     `https?://\S+`,
+		},
+	})
+
+	nuke := template.NewPersistentNuke(cache, "channel")
+
+	list := nuke.List()
+	assert.Len(t, list, 1)
+	assert.Equal(t, "links", list[0].Name)
+	assert.WithinDuration(t, time.Now().Add(4*time.Minute), list[0].ExpiresAt, time.Second)
+
+	name, action := nuke.Check(&message.Text{Original: "заходи http://example.com"}, false)
+	assert.Equal(t, "links", name)
+	assert.NotNil(t, action)
+
+	states, ok := cache.Get("channel")
+	assert.True(t, ok)
+	assert.Len(t, states, 1)
+
+	assert.NoError(t, nuke.Cancel("links"))
+	_, ok = cache.Get("channel")
+	assert.False(t, ok)
+}
+
+func TestNukeReport_WaitsForScrollback(t *testing.T) {
+	t.Parallel()
+
+	nuke := template.NewNuke()
+	reports := make(chan ports.NukeReport, 1)
+	nuke.OnFinish(func(report ports.NukeReport) { reports <- report })
+
+	started, finish := make(chan struct{}), make(chan struct{})
+	name := nuke.Start("links", ports.NukeRule{
+		Punishment:    config.Punishment{Action: "timeout", Duration: 60},
+		Duration:      time.Minute,
+		ContainsWords: []string{"спам"},
+	}, func(ctx context.Context, name string) {
+		release, ok := nuke.Acquire(name)
+		assert.True(t, ok)
+		close(started)
+
+		// запрос к API завершается уже после остановки массбана
+		go func() {
+			defer release()
+			<-finish
+			nuke.Record(name, ports.NukeHit{UserID: "1", Username: "user1", Action: "timeout", Source: "scrollback", Error: "api error"})
+		}()
+	})
+
+	<-started
+	assert.NoError(t, nuke.Cancel(name))
+
+	_, ok := nuke.Acquire(name)
+	assert.False(t, ok)
+
+	select {
+	case <-reports:
+		t.Fatal("report delivered before scrollback finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(finish)
+	select {
+	case report := <-reports:
+		assert.Len(t, report.Hits, 1)
+		assert.Equal(t, "api error", report.Hits[0].Error)
+	case <-time.After(time.Second):
+		t.Fatal("report was not delivered")
+	}
+}
@@ -43,6 +43,12 @@ func WithMword(mwords []config.Mword, mwordGroups map[string]*config.MwordGroup)
 	}
 }
 
+func WithNukeCache(cache ports.CachePort[[]ports.NukeState], channel string) Option {
+	return func(t *Template) {
+		t.nuke = NewPersistentNuke(cache, channel)
+	}
+}
+
 func New(opts ...Option) *Template {
 	t := &Template{
 		options:    NewOptions(),
@@ -39,42 +39,61 @@ type TrustManager struct {
 }
 
 func New(roles, globalRoles map[string][]string, users map[string]*config.Trust) *TrustManager {
-	m := &TrustManager{
-		roles:       roles,
-		globalRoles: globalRoles,
-		users:       users,
-	}
+	m := &TrustManager{}
+	m.Reset(roles, globalRoles, users)
+
+	return m
+}
 
+// Reset полностью пересчитывает роли и права пользователей, например после перезагрузки конфига.
+// Новые маски собираются заранее и подменяют старые поштучно, поэтому HasScope не видит пустого состояния.
+func (m *TrustManager) Reset(roles, globalRoles map[string][]string, users map[string]*config.Trust) {
+	roleMasks := make(map[string]Scope, len(roles)+len(globalRoles))
 	for role, scopes := range globalRoles {
-		var mask Scope
-		for _, s := range scopes {
-			if val, ok := ScopeMap[s]; ok {
-				mask |= val
-			}
-		}
-		m.roleMasks.Store(role, mask)
+		roleMasks[role] |= scopesMask(scopes)
 	}
-
 	for role, scopes := range roles {
-		var mask Scope
-		for _, s := range scopes {
-			if val, ok := ScopeMap[s]; ok {
-				mask |= val
-			}
-		}
+		roleMasks[role] |= scopesMask(scopes)
+	}
 
-		if val, ok := m.roleMasks.Load(role); ok {
-			mask |= val.(Scope)
+	userMasks := make(map[string]Scope, len(users))
+	for user, info := range users {
+		mask := scopesMask(info.Scopes)
+		for _, r := range info.Roles {
+			mask |= roleMasks[r]
 		}
-		m.roleMasks.Store(role, mask)
+		userMasks[user] = mask
 	}
 
-	for user, info := range users {
-		mask := m.calcUserMask(info.Roles, info.Scopes)
-		m.trusts.Store(user, mask)
+	m.roles = roles
+	m.globalRoles = globalRoles
+	m.users = users
+	replaceMasks(&m.roleMasks, roleMasks)
+	replaceMasks(&m.trusts, userMasks)
+}
+
+// replaceMasks записывает новые значения поверх старых и только затем удаляет ключи, которых больше нет.
+func replaceMasks(dst *sync.Map, masks map[string]Scope) {
+	for key, mask := range masks {
+		dst.Store(key, mask)
 	}
 
-	return m
+	dst.Range(func(key, _ any) bool {
+		if _, ok := masks[key.(string)]; !ok {
+			dst.Delete(key)
+		}
+		return true
+	})
+}
+
+func scopesMask(scopes []string) Scope {
+	var mask Scope
+	for _, s := range scopes {
+		if val, ok := ScopeMap[s]; ok {
+			mask |= val
+		}
+	}
+	return mask
 }
 
 func (m *TrustManager) Update(user string, roles, scopes []string) {
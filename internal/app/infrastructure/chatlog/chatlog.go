@@ -0,0 +1,489 @@
+package chatlog
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"twitchspam/internal/app/domain/message"
+	"twitchspam/internal/app/ports"
+	"unicode"
+)
+
+const (
+	segmentExt    = ".jsonl"
+	segmentLayout = "2006-01-02"
+
+	DefaultRetentionDays = 7
+
+	queueSize     = 1024            // сколько сообщений может ждать записи
+	flushInterval = 1 * time.Second // как часто буфер записи сбрасывается на диск
+)
+
+var (
+	ErrClosed    = errors.New("chat log is closed")
+	ErrQueueFull = errors.New("chat log queue is full")
+)
+
+// Log - журнал чата канала: сообщения дописываются в суточные сегменты (JSON Lines) фоновым писателем.
+// Для текущего сегмента в памяти хранится инвертированный индекс по словам и пользователям,
+// более старые сегменты при поиске читаются целиком.
+type Log struct {
+	mu        sync.Mutex
+	dir       string
+	retention int
+
+	file    *os.File
+	w       *bufio.Writer
+	segment string // имя текущего сегмента без расширения
+	size    int64  // размер сегмента вместе с данными в буфере
+	index   *index // индекс текущего сегмента
+
+	queue     chan ports.ChatLogEntry
+	onError   func(err error)
+	closed    atomic.Bool
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+type index struct {
+	words map[string][]int64 // слово в нижнем регистре -> смещения строк в сегменте
+	users map[string][]int64 // логин -> смещения строк в сегменте
+}
+
+// New создаёт журнал в каталоге dir и запускает фоновую запись. Ошибки фоновой записи
+// передаются в onError (может быть nil).
+func New(dir string, retentionDays int, onError func(err error)) *Log {
+	l := &Log{
+		dir:       dir,
+		retention: retentionDays,
+		queue:     make(chan ports.ChatLogEntry, queueSize),
+		onError:   onError,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	go l.run()
+	return l
+}
+
+// SetRetention задаёт, сколько суток хранить сегменты; применяется при следующей ротации.
+func (l *Log) SetRetention(days int) {
+	l.mu.Lock()
+	l.retention = days
+	l.mu.Unlock()
+}
+
+// Add ставит сообщение в очередь записи и не ждёт диска.
+func (l *Log) Add(msg *message.ChatMessage) error {
+	return l.Append(ports.ChatLogEntry{
+		Time:     time.Now(),
+		UserID:   msg.Chatter.UserID,
+		Login:    msg.Chatter.Login,
+		Username: msg.Chatter.Username,
+		Text:     msg.Message.Text.Text(),
+	})
+}
+
+// Append ставит готовую запись в очередь записи; сегмент выбирается по времени записи.
+func (l *Log) Append(e ports.ChatLogEntry) error {
+	if l.closed.Load() {
+		return ErrClosed
+	}
+	e.Login = strings.ToLower(e.Login)
+
+	select {
+	case l.queue <- e:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close дописывает сообщения из очереди, сбрасывает буфер и закрывает текущий сегмент.
+func (l *Log) Close() error {
+	l.closeOnce.Do(func() {
+		l.closed.Store(true)
+		close(l.stop)
+	})
+	<-l.done
+	return l.closeErr
+}
+
+// run - единственный писатель журнала: пишет записи из очереди и раз в flushInterval сбрасывает буфер.
+func (l *Log) run() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-l.queue:
+			l.report(l.write(e))
+		case <-ticker.C:
+			l.mu.Lock()
+			err := l.flushLocked()
+			l.mu.Unlock()
+			l.report(err)
+		case <-l.stop:
+			// очередь читает только эта горутина, поэтому непустая очередь не блокирует
+			for len(l.queue) > 0 {
+				l.report(l.write(<-l.queue))
+			}
+
+			l.mu.Lock()
+			l.closeErr = l.closeLocked()
+			l.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (l *Log) report(err error) {
+	if err != nil && l.onError != nil {
+		l.onError(err)
+	}
+}
+
+func (l *Log) write(e ports.ChatLogEntry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.rotateLocked(e.Time); err != nil {
+		return err
+	}
+
+	if _, err := l.w.Write(line); err != nil {
+		return fmt.Errorf("write chat log: %w", err)
+	}
+
+	l.index.add(e, l.size)
+	l.size += int64(len(line))
+	return nil
+}
+
+func (l *Log) flushLocked() error {
+	if l.w == nil {
+		return nil
+	}
+
+	if err := l.w.Flush(); err != nil {
+		return fmt.Errorf("flush chat log: %w", err)
+	}
+	return nil
+}
+
+func (l *Log) closeLocked() error {
+	if l.file == nil {
+		return nil
+	}
+
+	err := errors.Join(l.flushLocked(), l.file.Close())
+	l.file, l.w, l.segment, l.size, l.index = nil, nil, "", 0, nil
+	return err
+}
+
+// User возвращает последние n сообщений пользователя, начиная с самого нового.
+func (l *Log) User(login string, n int) ([]ports.ChatLogEntry, error) {
+	login = strings.ToLower(strings.TrimPrefix(login, "@"))
+	return l.search(n, func(idx *index) []int64 {
+		return idx.users[login]
+	}, func(e ports.ChatLogEntry) bool {
+		return e.Login == login
+	})
+}
+
+// Grep возвращает до limit сообщений, содержащих text (без учёта регистра), начиная с самого нового.
+func (l *Log) Grep(text string, limit int) ([]ports.ChatLogEntry, error) {
+	query := strings.ToLower(strings.TrimSpace(text))
+	if query == "" {
+		return nil, nil
+	}
+	tokens := tokenize(query)
+
+	return l.search(limit, func(idx *index) []int64 {
+		if len(tokens) == 0 {
+			return nil
+		}
+
+		// слово запроса может быть частью слова в сообщении, поэтому берём все подходящие слова словаря
+		var result []int64
+		for i, token := range tokens {
+			var postings []int64
+			for word, offsets := range idx.words {
+				if strings.Contains(word, token) {
+					postings = append(postings, offsets...)
+				}
+			}
+			slices.Sort(postings)
+			postings = slices.Compact(postings)
+
+			if i == 0 {
+				result = postings
+				continue
+			}
+			result = intersect(result, postings)
+		}
+		return result
+	}, func(e ports.ChatLogEntry) bool {
+		return strings.Contains(strings.ToLower(e.Text), query)
+	})
+}
+
+// search обходит сегменты от нового к старому и оставляет записи, прошедшие match.
+// Текущий сегмент читается по смещениям из индекса, остальные - целиком.
+func (l *Log) search(limit int, lookup func(idx *index) []int64, match func(ports.ChatLogEntry) bool) ([]ports.ChatLogEntry, error) {
+	l.mu.Lock()
+	current := l.segment
+	var offsets []int64
+	if l.index != nil {
+		offsets = slices.Clone(lookup(l.index))
+	}
+	err := l.flushLocked()
+	l.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := l.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []ports.ChatLogEntry
+	for _, segment := range slices.Backward(segments) {
+		var entries []ports.ChatLogEntry
+		if segment == current {
+			entries, err = l.read(segment, offsets)
+		} else {
+			entries, err = l.scan(segment, match)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range slices.Backward(entries) {
+			if !match(e) {
+				continue
+			}
+
+			out = append(out, e)
+			if len(out) >= limit {
+				return out, nil
+			}
+		}
+	}
+	return out, nil
+}
+
+// read читает строки сегмента по смещениям.
+func (l *Log) read(segment string, offsets []int64) ([]ports.ChatLogEntry, error) {
+	if len(offsets) == 0 {
+		return nil, nil
+	}
+
+	f, err := os.Open(filepath.Join(l.dir, segment+segmentExt))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := make([]ports.ChatLogEntry, 0, len(offsets))
+	for _, off := range offsets {
+		line, err := bufio.NewReader(io.NewSectionReader(f, off, 1<<20)).ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		var e ports.ChatLogEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// scan читает сегмент целиком и возвращает записи, прошедшие match.
+func (l *Log) scan(segment string, match func(ports.ChatLogEntry) bool) ([]ports.ChatLogEntry, error) {
+	var entries []ports.ChatLogEntry
+	err := l.forEach(segment, func(e ports.ChatLogEntry, _ int64) {
+		if match(e) {
+			entries = append(entries, e)
+		}
+	})
+	return entries, err
+}
+
+// forEach обходит записи сегмента вместе с их смещениями; отсутствующий сегмент пуст.
+func (l *Log) forEach(segment string, fn func(e ports.ChatLogEntry, off int64)) error {
+	f, err := os.Open(filepath.Join(l.dir, segment+segmentExt))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var off int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			var e ports.ChatLogEntry
+			if json.Unmarshal(line, &e) == nil {
+				fn(e, off)
+			}
+		}
+		off += int64(len(line))
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// rotateLocked открывает сегмент суток записи, строит его индекс и удаляет сегменты старше срока хранения.
+// Индекс прежнего сегмента освобождается.
+func (l *Log) rotateLocked(now time.Time) error {
+	segment := now.UTC().Format(segmentLayout)
+	if l.file != nil && l.segment == segment {
+		return nil
+	}
+
+	if err := l.closeLocked(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(l.dir, 0700); err != nil {
+		return err
+	}
+
+	idx := &index{words: make(map[string][]int64), users: make(map[string][]int64)}
+	if err := l.forEach(segment, idx.add); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(l.dir, segment+segmentExt), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open chat log segment: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	l.file, l.w, l.segment, l.size, l.index = f, bufio.NewWriter(f), segment, info.Size(), idx
+	return l.cleanupLocked(now)
+}
+
+func (l *Log) cleanupLocked(now time.Time) error {
+	retention := l.retention
+	if retention <= 0 {
+		retention = DefaultRetentionDays
+	}
+	border := now.UTC().AddDate(0, 0, -retention).Format(segmentLayout)
+
+	segments, err := l.segments()
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		if segment >= border {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(l.dir, segment+segmentExt)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// segments возвращает имена сегментов по возрастанию даты.
+func (l *Log) segments() ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var segments []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), segmentExt); ok && !e.IsDir() {
+			if _, err := time.Parse(segmentLayout, name); err == nil {
+				segments = append(segments, name)
+			}
+		}
+	}
+	slices.SortFunc(segments, cmp.Compare[string])
+	return segments, nil
+}
+
+func (idx *index) add(e ports.ChatLogEntry, off int64) {
+	idx.users[e.Login] = append(idx.users[e.Login], off)
+
+	seen := make(map[string]struct{})
+	for _, word := range tokenize(strings.ToLower(e.Text)) {
+		if _, ok := seen[word]; ok {
+			continue
+		}
+		seen[word] = struct{}{}
+		idx.words[word] = append(idx.words[word], off)
+	}
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// intersect возвращает общие элементы двух отсортированных списков.
+func intersect(a, b []int64) []int64 {
+	var out []int64
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
@@ -0,0 +1,200 @@
+package chatlog_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"twitchspam/internal/app/infrastructure/chatlog"
+	"twitchspam/internal/app/ports"
+)
+
+func newTestLog(t *testing.T, retention int) (*chatlog.Log, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	l := chatlog.New(dir, retention, func(err error) {
+		t.Errorf("chat log write failed: %v", err)
+	})
+	t.Cleanup(func() { _ = l.Close() })
+	return l, dir
+}
+
+func appendAll(t *testing.T, l *chatlog.Log, entries ...ports.ChatLogEntry) {
+	t.Helper()
+
+	for _, e := range entries {
+		require.NoError(t, l.Append(e))
+	}
+}
+
+func entry(at time.Time, login, text string) ports.ChatLogEntry {
+	return ports.ChatLogEntry{Time: at, UserID: "id-" + login, Login: login, Username: login, Text: text}
+}
+
+func texts(entries []ports.ChatLogEntry) []string {
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e.Text)
+	}
+	return out
+}
+
+func TestLog_User(t *testing.T) {
+	t.Parallel()
+
+	l, _ := newTestLog(t, 7)
+	now := time.Now()
+	appendAll(t, l,
+		entry(now.Add(-3*time.Second), "Alice", "первое"),
+		entry(now.Add(-2*time.Second), "bob", "привет"),
+		entry(now.Add(-time.Second), "alice", "второе"),
+		entry(now, "alice", "третье"),
+	)
+
+	// запись асинхронная, поиск видит её после обработки очереди
+	require.Eventually(t, func() bool {
+		got, err := l.User("@ALICE", 10)
+		return err == nil && len(got) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	got, err := l.User("alice", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"третье", "второе"}, texts(got))
+	assert.Equal(t, "alice", got[0].Login)
+
+	got, err = l.User("nobody", 10)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestLog_Grep(t *testing.T) {
+	t.Parallel()
+
+	l, _ := newTestLog(t, 7)
+	now := time.Now()
+	appendAll(t, l,
+		entry(now.Add(-3*time.Second), "alice", "Заходите на Казино, бонусы"),
+		entry(now.Add(-2*time.Second), "bob", "казиношник снова здесь"),
+		entry(now.Add(-time.Second), "carol", "обычное сообщение"),
+		entry(now, "dave", "казино бонусы каждый день"),
+	)
+
+	require.Eventually(t, func() bool {
+		got, err := l.Grep("обычное", 10)
+		return err == nil && len(got) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// поиск без учёта регистра и по части слова
+	got, err := l.Grep("КАЗИНО", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"казино бонусы каждый день", "казиношник снова здесь", "Заходите на Казино, бонусы"}, texts(got))
+
+	// несколько слов ищутся как фраза
+	got, err = l.Grep("казино бонусы", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"казино бонусы каждый день"}, texts(got))
+
+	got, err = l.Grep("казино", 1)
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+
+	got, err = l.Grep("  ", 10)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestLog_Rotation(t *testing.T) {
+	t.Parallel()
+
+	l, dir := newTestLog(t, 7)
+	today := time.Now().UTC()
+	yesterday := today.AddDate(0, 0, -1)
+	appendAll(t, l,
+		entry(yesterday, "alice", "вчера казино"),
+		entry(today, "alice", "сегодня казино"),
+	)
+	require.NoError(t, l.Close())
+
+	// каждые сутки пишутся в свой сегмент
+	for _, day := range []time.Time{yesterday, today} {
+		assert.FileExists(t, filepath.Join(dir, day.Format("2006-01-02")+".jsonl"))
+	}
+
+	// после перезапуска поиск идёт по всем сегментам от нового к старому
+	reopened := chatlog.New(dir, 7, nil)
+	t.Cleanup(func() { _ = reopened.Close() })
+
+	got, err := reopened.User("alice", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"сегодня казино", "вчера казино"}, texts(got))
+
+	// дописанные в сегмент после перезапуска сообщения попадают в индекс вместе со старыми
+	require.NoError(t, reopened.Append(entry(today, "alice", "снова казино")))
+	require.Eventually(t, func() bool {
+		got, err := reopened.Grep("казино", 10)
+		return err == nil && len(got) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	got, err = reopened.Grep("казино", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"снова казино", "сегодня казино", "вчера казино"}, texts(got))
+}
+
+func TestLog_Retention(t *testing.T) {
+	t.Parallel()
+
+	l, dir := newTestLog(t, 2)
+	today := time.Now().UTC()
+	segment := func(daysAgo int) string {
+		return filepath.Join(dir, today.AddDate(0, 0, -daysAgo).Format("2006-01-02")+".jsonl")
+	}
+
+	appendAll(t, l,
+		entry(today.AddDate(0, 0, -10), "alice", "давно"),
+		entry(today.AddDate(0, 0, -2), "alice", "позавчера"),
+		entry(today, "alice", "сегодня"),
+	)
+	require.NoError(t, l.Close())
+
+	// при ротации удаляются сегменты старше срока хранения
+	assert.NoFileExists(t, segment(10))
+	assert.FileExists(t, segment(2))
+	assert.FileExists(t, segment(0))
+
+	// чужие файлы в каталоге журнала не трогаются
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0600))
+
+	reopened := chatlog.New(dir, 2, nil)
+	reopened.SetRetention(1)
+	require.NoError(t, reopened.Append(entry(today, "bob", "новое")))
+	require.NoError(t, reopened.Close())
+
+	assert.NoFileExists(t, segment(2))
+	assert.FileExists(t, segment(0))
+	assert.FileExists(t, filepath.Join(dir, "notes.txt"))
+}
+
+func TestLog_Close(t *testing.T) {
+	t.Parallel()
+
+	l, dir := newTestLog(t, 7)
+	now := time.Now().UTC()
+	for range 100 {
+		require.NoError(t, l.Append(entry(now, "alice", "сообщение")))
+	}
+
+	// закрытие дописывает всю очередь на диск
+	require.NoError(t, l.Close())
+	require.NoError(t, l.Close())
+	assert.ErrorIs(t, l.Append(entry(now, "alice", "поздно")), chatlog.ErrClosed)
+
+	reopened := chatlog.New(dir, 7, nil)
+	t.Cleanup(func() { _ = reopened.Close() })
+
+	got, err := reopened.User("alice", 1000)
+	require.NoError(t, err)
+	assert.Len(t, got, 100)
+}
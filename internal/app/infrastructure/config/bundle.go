@@ -0,0 +1,290 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+const bundleVersion = 1
+
+// BundleSections - разделы канала, которые можно экспортировать и импортировать (ключ совпадает с командой !am).
+var BundleSections = []string{"ex", "mw", "mwg", "cmd", "al", "alg", "spam"}
+
+// Bundle - переносимый набор настроек канала.
+type Bundle struct {
+	Version     int                            `json:"version"`
+	Channel     string                         `json:"channel"`
+	Exceptions  map[string]*ExceptionsSettings `json:"exceptions,omitempty"`
+	Mword       []Mword                        `json:"mword,omitempty"`
+	MwordGroup  map[string]*MwordGroup         `json:"mword_group,omitempty"`
+	Commands    map[string]*Commands           `json:"commands,omitempty"`
+	Aliases     map[string]string              `json:"aliases,omitempty"`
+	AliasGroups map[string]*AliasGroups        `json:"aliases_group,omitempty"`
+	Spam        *ProfileSpam                   `json:"spam,omitempty"`
+}
+
+// ParseSections разбирает раздел из команды; пустая строка или all - все разделы.
+func ParseSections(section string) ([]string, error) {
+	section = strings.ToLower(strings.TrimSpace(section))
+	if section == "" || section == "all" {
+		return BundleSections, nil
+	}
+
+	if !slices.Contains(BundleSections, section) {
+		return nil, fmt.Errorf("unknown section %q", section)
+	}
+	return []string{section}, nil
+}
+
+// ExportBundle собирает указанные разделы канала в бандл.
+func ExportBundle(ch *Channel, sections []string) ([]byte, error) {
+	b := Bundle{Version: bundleVersion, Channel: ch.Name}
+	for _, s := range sections {
+		switch s {
+		case "ex":
+			b.Exceptions = ch.Spam.Exceptions
+		case "mw":
+			b.Mword = ch.Mword
+		case "mwg":
+			b.MwordGroup = ch.MwordGroup
+		case "cmd":
+			b.Commands = ch.Commands
+		case "al":
+			b.Aliases = ch.Aliases
+		case "alg":
+			b.AliasGroups = ch.AliasGroups
+		case "spam":
+			spam := spamSettings(ch)
+			b.Spam = &spam
+		}
+	}
+
+	data, err := json.Marshal(b, json.OmitZeroStructFields(true))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := (*jsontext.Value)(&data).Indent(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ParseBundle разбирает бандл, полученный через !am import.
+func ParseBundle(data []byte) (*Bundle, error) {
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parse bundle: %w", err)
+	}
+
+	if b.Version < 1 || b.Version > bundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", b.Version)
+	}
+	return &b, nil
+}
+
+// ApplyBundle переносит разделы бандла в канал и возвращает количество элементов по разделам.
+// При merge существующие элементы с теми же ключами перезаписываются, остальные сохраняются;
+// иначе раздел заменяется целиком.
+func ApplyBundle(ch *Channel, b *Bundle, sections []string, merge bool) map[string]int {
+	summary := make(map[string]int)
+	for _, s := range sections {
+		switch s {
+		case "ex":
+			if b.Exceptions == nil {
+				continue
+			}
+			ch.Spam.Exceptions = mergeMap(ch.Spam.Exceptions, b.Exceptions, merge)
+			summary[s] = len(b.Exceptions)
+		case "mw":
+			if b.Mword == nil {
+				continue
+			}
+			ch.Mword = mergeMwords(ch.Mword, b.Mword, merge)
+			summary[s] = len(b.Mword)
+		case "mwg":
+			if b.MwordGroup == nil {
+				continue
+			}
+			ch.MwordGroup = mergeMap(ch.MwordGroup, b.MwordGroup, merge)
+			summary[s] = len(b.MwordGroup)
+		case "cmd":
+			if b.Commands == nil {
+				continue
+			}
+			ch.Commands = mergeMap(ch.Commands, b.Commands, merge)
+			summary[s] = len(b.Commands)
+		case "al":
+			if b.Aliases == nil {
+				continue
+			}
+			ch.Aliases = mergeMap(ch.Aliases, b.Aliases, merge)
+			summary[s] = len(b.Aliases)
+		case "alg":
+			if b.AliasGroups == nil {
+				continue
+			}
+			ch.AliasGroups = mergeMap(ch.AliasGroups, b.AliasGroups, merge)
+			summary[s] = len(b.AliasGroups)
+		case "spam":
+			// настройки спама заменяются целиком независимо от режима
+			if b.Spam == nil {
+				continue
+			}
+			ch.Spam.Mode = b.Spam.Mode
+			ch.Spam.SettingsDefault = b.Spam.SettingsDefault
+			ch.Spam.SettingsVIP = b.Spam.SettingsVIP
+			ch.Spam.SettingsEmotes = b.Spam.SettingsEmotes
+			summary[s] = 1
+		}
+	}
+	return summary
+}
+
+// DiffChannels возвращает отличия двух версий канала в формате "путь: старое -> новое", как в истории конфига.
+func DiffChannels(before, after *Channel) ([]string, error) {
+	oldData, err := json.Marshal(before, json.OmitZeroStructFields(true))
+	if err != nil {
+		return nil, err
+	}
+
+	newData, err := json.Marshal(after, json.OmitZeroStructFields(true))
+	if err != nil {
+		return nil, err
+	}
+	return diffJSON(oldData, newData), nil
+}
+
+// ChangeCounts - количество добавленных, перезаписанных и удалённых элементов разделов.
+type ChangeCounts struct {
+	Added       int
+	Overwritten int
+	Removed     int
+}
+
+// CountChanges сравнивает разделы двух версий канала поэлементно по ключу (слово или регулярка мворда,
+// название алиаса, команды, группы); настройки спама считаются одним элементом.
+func CountChanges(before, after *Channel, sections []string) ChangeCounts {
+	var c ChangeCounts
+	for _, s := range sections {
+		switch s {
+		case "ex":
+			countEntries(&c, before.Spam.Exceptions, after.Spam.Exceptions)
+		case "mw":
+			countEntries(&c, mwordsByKey(before.Mword), mwordsByKey(after.Mword))
+		case "mwg":
+			countEntries(&c, before.MwordGroup, after.MwordGroup)
+		case "cmd":
+			countEntries(&c, before.Commands, after.Commands)
+		case "al":
+			countEntries(&c, before.Aliases, after.Aliases)
+		case "alg":
+			countEntries(&c, before.AliasGroups, after.AliasGroups)
+		case "spam":
+			countEntries(&c, map[string]ProfileSpam{"spam": spamSettings(before)}, map[string]ProfileSpam{"spam": spamSettings(after)})
+		}
+	}
+	return c
+}
+
+func countEntries[V any](c *ChangeCounts, before, after map[string]V) {
+	for key, v := range after {
+		old, ok := before[key]
+		switch {
+		case !ok:
+			c.Added++
+		case !sameValue(old, v):
+			c.Overwritten++
+		}
+	}
+
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			c.Removed++
+		}
+	}
+}
+
+// sameValue сравнивает значения так же, как они сохраняются на диск.
+func sameValue(a, b any) bool {
+	oldData, err := json.Marshal(a, json.OmitZeroStructFields(true), json.Deterministic(true))
+	if err != nil {
+		return false
+	}
+
+	newData, err := json.Marshal(b, json.OmitZeroStructFields(true), json.Deterministic(true))
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(oldData, newData)
+}
+
+func mwordsByKey(mwords []Mword) map[string]Mword {
+	m := make(map[string]Mword, len(mwords))
+	for _, mw := range mwords {
+		m[mwordKey(mw)] = mw
+	}
+	return m
+}
+
+func mwordKey(mw Mword) string {
+	if mw.Word != "" {
+		return mw.Word
+	}
+	return mw.NameRegexp
+}
+
+func spamSettings(ch *Channel) ProfileSpam {
+	return ProfileSpam{
+		Mode:            ch.Spam.Mode,
+		SettingsDefault: ch.Spam.SettingsDefault,
+		SettingsVIP:     ch.Spam.SettingsVIP,
+		SettingsEmotes:  ch.Spam.SettingsEmotes,
+	}
+}
+
+// ValidateChannel проверяет канал теми же правилами, что и весь конфиг, не изменяя текущий конфиг.
+func (m *Manager) ValidateChannel(cfg *Config, ch *Channel) error {
+	cfgCopy := *cfg
+	cfgCopy.Channels = map[string]*Channel{strings.ToLower(ch.Name): ch}
+	return m.validate(&cfgCopy)
+}
+
+// CloneChannel возвращает глубокую копию канала.
+func CloneChannel(ch *Channel) (*Channel, error) {
+	return clone(ch)
+}
+
+func mergeMap[V any](dst, src map[string]V, merge bool) map[string]V {
+	if !merge || dst == nil {
+		return maps.Clone(src)
+	}
+
+	maps.Copy(dst, src)
+	return dst
+}
+
+func mergeMwords(dst, src []Mword, merge bool) []Mword {
+	if !merge {
+		return slices.Clone(src)
+	}
+
+	index := make(map[string]int, len(dst))
+	for i, mw := range dst {
+		index[mwordKey(mw)] = i
+	}
+
+	for _, mw := range src {
+		if i, ok := index[mwordKey(mw)]; ok {
+			dst[i] = mw
+			continue
+		}
+		dst = append(dst, mw)
+	}
+	return dst
+}
@@ -0,0 +1,65 @@
+package config_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"twitchspam/internal/app/infrastructure/config"
+)
+
+func TestDiffChannels_CountChanges(t *testing.T) {
+	t.Parallel()
+
+	before := (&config.Manager{}).GetChannel()
+	before.Aliases = map[string]string{"!a": "!old", "!gone": "!x"}
+
+	after, err := config.CloneChannel(before)
+	require.NoError(t, err)
+
+	config.ApplyBundle(after, &config.Bundle{Aliases: map[string]string{"!a": "!new", "!b": "!added"}}, []string{"al"}, false)
+
+	diff, err := config.DiffChannels(before, after)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`aliases.!a: "!old" -> "!new"`,
+		`aliases.!b: null -> "!added"`,
+		`aliases.!gone: "!x" -> null`,
+	}, diff)
+
+	assert.Equal(t, config.ChangeCounts{Added: 1, Overwritten: 1, Removed: 1}, config.CountChanges(before, after, []string{"al"}))
+}
+
+func TestCountChanges_PerEntry(t *testing.T) {
+	t.Parallel()
+
+	before := (&config.Manager{}).GetChannel()
+	before.Mword = []config.Mword{
+		{Word: "спам", Punishments: []config.Punishment{{Action: "delete"}}},
+		{Word: "флуд", Punishments: []config.Punishment{{Action: "delete"}}},
+	}
+
+	after, err := config.CloneChannel(before)
+	require.NoError(t, err)
+
+	// у мворда меняются все наказания, но это одно перезаписанное слово
+	config.ApplyBundle(after, &config.Bundle{
+		Mword: []config.Mword{
+			{Word: "спам", Punishments: []config.Punishment{{Action: "timeout", Duration: 600}, {Action: "ban"}}},
+			{Word: "реклама", Punishments: []config.Punishment{{Action: "ban"}}},
+		},
+		Spam: &config.ProfileSpam{Mode: config.AlwaysMode, SettingsDefault: before.Spam.SettingsDefault},
+	}, []string{"mw", "spam"}, true)
+
+	diff, err := config.DiffChannels(before, after)
+	require.NoError(t, err)
+	assert.Greater(t, len(diff), 3)
+
+	assert.Equal(t, config.ChangeCounts{Added: 1, Overwritten: 2}, config.CountChanges(before, after, []string{"mw", "spam"}))
+	assert.Equal(t, config.ChangeCounts{Added: 1, Overwritten: 1}, config.CountChanges(before, after, []string{"mw"}))
+
+	// в режиме замены исчезнувшие слова считаются удалёнными
+	replaced, err := config.CloneChannel(before)
+	require.NoError(t, err)
+	config.ApplyBundle(replaced, &config.Bundle{Mword: []config.Mword{{Word: "флуд", Punishments: []config.Punishment{{Action: "delete"}}}}}, []string{"mw"}, false)
+	assert.Equal(t, config.ChangeCounts{Removed: 1}, config.CountChanges(before, replaced, []string{"mw"}))
+}
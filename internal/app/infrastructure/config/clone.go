@@ -0,0 +1,62 @@
+package config
+
+import (
+	"golang.org/x/time/rate"
+	"reflect"
+	"regexp"
+)
+
+// sharedTypes - указатели, которые копия конфига делит с оригиналом: регулярки неизменяемы,
+// а лимитеры потокобезопасны и должны сохранять состояние между изменениями конфига.
+var sharedTypes = map[reflect.Type]bool{
+	reflect.TypeFor[*regexp.Regexp](): true,
+	reflect.TypeFor[*rate.Limiter]():  true,
+}
+
+// clone возвращает глубокую копию конфига: UpdateBy меняет копию и подменяет ею текущий конфиг,
+// поэтому читатели Get никогда не видят конфиг в процессе изменения.
+func (cfg *Config) clone() *Config {
+	return deepCopy(reflect.ValueOf(cfg)).Interface().(*Config)
+}
+
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || sharedTypes[v.Type()] {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(deepCopy(v.Elem()))
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			out.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			out.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return out
+	case reflect.Struct:
+		// неэкспортируемые поля (например, у time.Time) копируются по значению
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := range v.NumField() {
+			if out.Field(i).CanSet() {
+				out.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -9,7 +9,10 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"twitchspam/internal/app/infrastructure/config/migrations"
+	"twitchspam/internal/app/infrastructure/storage"
 )
 
 const configPath = "configs/config.json"
@@ -17,7 +20,16 @@ const channelsDir = "configs/channels"
 
 type Manager struct {
 	mu  sync.RWMutex
-	cfg *Config
+	cfg atomic.Pointer[Config] // при перезагрузке подменяется целиком, поэтому Get не берёт блокировку
+	db  *storage.DB            // каналы хранятся в базе, если storage.backend = bolt
+
+	filesMu  sync.Mutex
+	files    map[string]fileState // состояние файлов после последнего чтения/записи
+	onReload []func(cfg *Config)
+
+	secretsMu   sync.Mutex  // запись файла секретов и смена токенов пользователей, не зависит от mu
+	secretsFile atomic.Bool // секреты хранятся в secretsPath, а не в config.json
+	redactor    atomic.Pointer[strings.Replacer]
 }
 
 func New() (*Manager, error) {
@@ -28,14 +40,14 @@ func New() (*Manager, error) {
 		return nil, err
 	}
 
-	m.cfg, err = m.readParseValidate()
+	cfg, err := m.readParseValidate()
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return nil, fmt.Errorf("read config: %w", err)
 	}
 
 	if errors.Is(err, os.ErrNotExist) {
-		m.cfg = m.GetDefault()
-		data, err := json.Marshal(m.cfg, json.OmitZeroStructFields(true))
+		cfg = m.GetDefault()
+		data, err := json.Marshal(cfg, json.OmitZeroStructFields(true))
 		if err != nil {
 			return nil, fmt.Errorf("marshal config: %w", err)
 		}
@@ -48,35 +60,34 @@ func New() (*Manager, error) {
 			return nil, fmt.Errorf("write config: %w", err)
 		}
 	}
+	m.cfg.Store(cfg)
+	m.rememberFiles()
 
 	return m, nil
 }
 
 func (m *Manager) Get() *Config {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	return m.cfg
+	return m.cfg.Load()
 }
 
 func (m *Manager) Update(modify func(cfg *Config)) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	return m.UpdateBy("", "", "", modify)
+}
 
-	if m.cfg == nil {
-		return errors.New("no config loaded")
+func (m *Manager) readParseValidate() (*Config, error) {
+	cfg, err := m.readParse()
+	if err != nil {
+		return nil, err
 	}
 
-	modify(m.cfg)
-
-	if err := m.validate(m.cfg); err != nil {
-		return fmt.Errorf("invalid config update: %w", err)
+	if err := m.validate(cfg); err != nil {
+		return nil, fmt.Errorf("validate: %w", err)
 	}
-	return m.saveLocked()
+	return cfg, nil
 }
 
-func (m *Manager) readParseValidate() (*Config, error) {
-	raw, err := os.ReadFile(configPath)
+func (m *Manager) readParse() (*Config, error) {
+	raw, err := m.readMigrate(configPath, migrations.Config)
 	if err != nil {
 		return nil, fmt.Errorf("open/read config: %w", err)
 	}
@@ -86,19 +97,40 @@ func (m *Manager) readParseValidate() (*Config, error) {
 		return nil, fmt.Errorf("parse json: %w", err)
 	}
 
+	// смена хранилища применяется только при запуске
+	if cfg.Storage.Backend == StorageBolt && m.db == nil {
+		path := cfg.Storage.Path
+		if path == "" {
+			path = defaultDBPath
+		}
+
+		if m.db, err = storage.OpenDB(path); err != nil {
+			return nil, err
+		}
+	}
+
 	channels, err := m.loadChannels()
 	if err != nil {
 		return nil, fmt.Errorf("load channels: %w", err)
 	}
 	cfg.Channels = channels
 
-	if err := m.validate(&cfg); err != nil {
-		return nil, fmt.Errorf("validate: %w", err)
+	if err := m.applySecrets(&cfg); err != nil {
+		return nil, err
 	}
+	m.updateRedactor(&cfg)
+
 	return &cfg, nil
 }
 
 func (m *Manager) loadChannels() (map[string]*Channel, error) {
+	if m.db != nil {
+		return m.loadChannelsDB()
+	}
+	return m.loadChannelsFiles()
+}
+
+func (m *Manager) loadChannelsFiles() (map[string]*Channel, error) {
 	files, err := os.ReadDir(channelsDir)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -113,32 +145,61 @@ func (m *Manager) loadChannels() (map[string]*Channel, error) {
 			continue
 		}
 
-		data, err := os.ReadFile(filepath.Join(channelsDir, f.Name()))
+		data, err := m.readMigrate(filepath.Join(channelsDir, f.Name()), migrations.Channel)
 		if err != nil {
 			return nil, fmt.Errorf("read %s: %w", f.Name(), err)
 		}
 
-		var ch Channel
-		if err := json.Unmarshal(data, &ch); err != nil {
+		ch, err := parseChannel(data)
+		if err != nil {
 			return nil, fmt.Errorf("parse %s: %w", f.Name(), err)
 		}
 
-		channels[strings.ToLower(ch.Name)] = &ch
+		channels[strings.ToLower(ch.Name)] = ch
 	}
 	return channels, nil
 }
 
-func (m *Manager) saveLocked() error {
-	if m.cfg == nil {
-		return errors.New("no config to save")
+// parseChannel разбирает уже мигрированный конфиг канала и заполняет поля, которые не хранятся на диске.
+func parseChannel(data []byte) (*Channel, error) {
+	var ch Channel
+	if err := json.Unmarshal(data, &ch); err != nil {
+		return nil, err
+	}
+
+	ch.WindowSecs = defaultWindowSecs
+	return &ch, nil
+}
+
+// storeLocked публикует новую версию конфига вместо old. Токены пользователей меняются через
+// SetUserTokens без блокировки mu, поэтому при гонке в новую версию переносятся актуальные токены.
+func (m *Manager) storeLocked(old, cfg *Config) {
+	for !m.cfg.CompareAndSwap(old, cfg) {
+		old = m.cfg.Load()
+		cfg.UsersTokens = old.UsersTokens
 	}
+}
 
-	if err := m.saveChannels(m.cfg.Channels); err != nil {
+func (m *Manager) saveLocked(old, cfg *Config) error {
+	if err := m.saveChannels(cfg.Channels); err != nil {
 		return fmt.Errorf("save channels: %w", err)
 	}
 
-	cfgCopy := *m.cfg
+	// файл секретов переписывается только при смене секретов; без него токены переносятся туда при первом сохранении
+	if m.secretsFile.Load() && secretsChanged(old, cfg) || !m.secretsFile.Load() && len(cfg.UsersTokens) > 0 {
+		m.secretsMu.Lock()
+		cfg.UsersTokens = m.cfg.Load().UsersTokens
+		err := m.saveSecretsLocked(cfg)
+		m.secretsMu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	cfgCopy := *cfg
 	cfgCopy.Channels = nil
+	cfgCopy.SchemaVersion = migrations.Current
+	m.stripSecrets(&cfgCopy)
 
 	data, err := json.Marshal(cfgCopy, json.OmitZeroStructFields(true))
 	if err != nil {
@@ -149,15 +210,26 @@ func (m *Manager) saveLocked() error {
 		return fmt.Errorf("indent: %w", err)
 	}
 
-	return m.writeAtomic(configPath, data, 0644)
+	if err := m.writeAtomic(configPath, data, 0644); err != nil {
+		return err
+	}
+
+	// собственные записи не должны восприниматься как ручные правки
+	m.rememberFiles()
+	return nil
 }
 
 func (m *Manager) saveChannels(channels map[string]*Channel) error {
+	if m.db != nil {
+		return m.saveChannelsDB(channels)
+	}
+
 	if err := os.MkdirAll(channelsDir, 0750); err != nil {
 		return err
 	}
 
 	for name, ch := range channels {
+		ch.SchemaVersion = migrations.Current
 		filename := name + ".json"
 		path := filepath.Join(channelsDir, filename)
 
@@ -178,6 +250,33 @@ func (m *Manager) saveChannels(channels map[string]*Channel) error {
 	return nil
 }
 
+// readMigrate читает файл и при необходимости обновляет его схему до актуальной версии,
+// сохраняя исходный файл рядом с суффиксом .v<версия>.bak.
+func (m *Manager) readMigrate(path string, kind migrations.Kind) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, from, err := migrations.Migrate(raw, kind)
+	if err != nil {
+		return nil, fmt.Errorf("migrate %s: %w", filepath.Base(path), err)
+	}
+
+	if from == migrations.Current {
+		return data, nil
+	}
+
+	if err := m.writeAtomic(fmt.Sprintf("%s.v%d.bak", path, from), raw, 0644); err != nil {
+		return nil, fmt.Errorf("backup %s: %w", filepath.Base(path), err)
+	}
+
+	if err := m.writeAtomic(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("write migrated %s: %w", filepath.Base(path), err)
+	}
+	return data, nil
+}
+
 func (m *Manager) writeAtomic(path string, data []byte, perm os.FileMode) error {
 	dir := filepath.Dir(path)
 	base := filepath.Base(path)
@@ -0,0 +1,39 @@
+package config_test
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"twitchspam/internal/app/infrastructure/config"
+)
+
+const testConfig = `{
+	"app": {
+		"log_level": "info",
+		"gin_mode": "release",
+		"oauth": "oauth-token",
+		"client_id": "client-id",
+		"username": "bot",
+		"user_id": "1",
+		"auth_token": "auth-token"
+	}
+}`
+
+// newTestManager создаёт менеджер во временном каталоге с одним каналом test.
+func newTestManager(t *testing.T) *config.Manager {
+	t.Chdir(t.TempDir())
+
+	require.NoError(t, os.MkdirAll("configs", 0750))
+	require.NoError(t, os.WriteFile(filepath.Join("configs", "config.json"), []byte(testConfig), 0644))
+
+	m, err := config.New()
+	require.NoError(t, err)
+
+	require.NoError(t, m.Update(func(cfg *config.Config) {
+		ch := m.GetChannel()
+		ch.Name = "test"
+		cfg.Channels["test"] = ch
+	}))
+	return m
+}
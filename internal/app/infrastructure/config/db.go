@@ -0,0 +1,122 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"twitchspam/internal/app/infrastructure/config/migrations"
+	"twitchspam/internal/app/infrastructure/storage"
+)
+
+const channelsBucket = "channels"
+
+// DB возвращает встроенную базу или nil, если используется файловое хранилище.
+func (m *Manager) DB() *storage.DB {
+	return m.db
+}
+
+// Close закрывает базу, если она открыта.
+func (m *Manager) Close() error {
+	if m.db == nil {
+		return nil
+	}
+	return m.db.Close()
+}
+
+func (m *Manager) loadChannelsDB() (map[string]*Channel, error) {
+	if err := m.migrateChannelsToDB(); err != nil {
+		return nil, fmt.Errorf("migrate channels to db: %w", err)
+	}
+
+	raw := make(map[string][]byte)
+	if err := m.db.View(func(tx *storage.Tx) error {
+		return tx.ForEach(channelsBucket, func(key string, val []byte) error {
+			raw[key] = bytes.Clone(val)
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	channels := make(map[string]*Channel, len(raw))
+	for key, data := range raw {
+		data, from, err := migrations.Migrate(data, migrations.Channel)
+		if err != nil {
+			return nil, fmt.Errorf("migrate channel %s: %w", key, err)
+		}
+
+		if from != migrations.Current {
+			if err := m.db.Update(func(tx *storage.Tx) error {
+				return tx.Put(channelsBucket, key, data)
+			}); err != nil {
+				return nil, fmt.Errorf("write migrated channel %s: %w", key, err)
+			}
+		}
+
+		ch, err := parseChannel(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse channel %s: %w", key, err)
+		}
+		channels[strings.ToLower(ch.Name)] = ch
+	}
+	return channels, nil
+}
+
+// migrateChannelsToDB однократно переносит файлы каналов в пустую базу одной транзакцией
+// и переименовывает каталог каналов в <каталог>.migrated.
+func (m *Manager) migrateChannelsToDB() error {
+	var empty bool
+	if err := m.db.View(func(tx *storage.Tx) error {
+		empty = tx.Empty(channelsBucket)
+		return nil
+	}); err != nil || !empty {
+		return err
+	}
+
+	channels, err := m.loadChannelsFiles()
+	if err != nil {
+		return err
+	}
+
+	if len(channels) == 0 {
+		return nil
+	}
+
+	if err := m.saveChannelsDB(channels); err != nil {
+		return err
+	}
+	return os.Rename(channelsDir, channelsDir+".migrated")
+}
+
+func (m *Manager) saveChannelsDB(channels map[string]*Channel) error {
+	return m.db.Update(func(tx *storage.Tx) error {
+		for _, name := range slices.Sorted(maps.Keys(channels)) {
+			ch := channels[name]
+			ch.SchemaVersion = migrations.Current
+
+			data, err := json.Marshal(ch, json.OmitZeroStructFields(true))
+			if err != nil {
+				return fmt.Errorf("marshal channel %s: %w", name, err)
+			}
+
+			if err := (*jsontext.Value)(&data).Indent(); err != nil {
+				return fmt.Errorf("indent: %w", err)
+			}
+
+			// записываем только изменившиеся каналы
+			if old, ok := tx.Get(channelsBucket, name); ok && bytes.Equal(old, data) {
+				continue
+			}
+
+			if err := tx.Put(channelsBucket, name, data); err != nil {
+				return fmt.Errorf("write channel %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,48 @@
+package config_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"twitchspam/internal/app/infrastructure/config"
+)
+
+func TestMigrateChannelsToDB_RoundTrip(t *testing.T) {
+	m := newTestManager(t)
+	require.NoError(t, m.Update(func(cfg *config.Config) {
+		ch := cfg.Channels["test"]
+		ch.Aliases = map[string]string{"!tg": "!telegram"}
+		ch.Spam.SettingsDefault.MaxWordLength = 200
+
+		// хранилище меняется только при следующем запуске
+		cfg.Storage = config.Storage{Backend: config.StorageBolt, Path: filepath.Join("data", "test.db")}
+	}))
+	before := m.Get().Channels["test"]
+	require.Nil(t, m.DB())
+
+	migrated, err := config.New()
+	require.NoError(t, err)
+	require.NotNil(t, migrated.DB())
+
+	// файлы каналов перенесены в базу, каталог переименован
+	assert.NoDirExists(t, filepath.Join("configs", "channels"))
+	assert.DirExists(t, filepath.Join("configs", "channels.migrated"))
+
+	diff, err := config.DiffChannels(before, migrated.Get().Channels["test"])
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	// изменения сохраняются в базу и читаются после перезапуска
+	require.NoError(t, migrated.Update(func(cfg *config.Config) {
+		cfg.Channels["test"].Spam.SettingsDefault.MaxWordLength = 300
+	}))
+	require.NoError(t, migrated.Close())
+
+	restarted, err := config.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = restarted.Close() })
+
+	assert.Equal(t, 300, restarted.Get().Channels["test"].Spam.SettingsDefault.MaxWordLength)
+	assert.Equal(t, map[string]string{"!tg": "!telegram"}, restarted.Get().Channels["test"].Aliases)
+}
@@ -1,6 +1,18 @@
 package config
 
-import "time"
+import (
+	"time"
+	"twitchspam/internal/app/infrastructure/config/migrations"
+)
+
+const (
+	StorageJSON = "json"
+	StorageBolt = "bolt"
+
+	defaultDBPath = "data/twitchspam.db"
+
+	defaultWindowSecs = 180 // окно, в котором ищутся похожие сообщения
+)
 
 const (
 	_ = iota
@@ -11,6 +23,7 @@ const (
 
 func (m *Manager) GetDefault() *Config {
 	return &Config{
+		SchemaVersion: migrations.Current,
 		App: App{
 			LogLevel: "info",
 			GinMode:  "release",
@@ -19,6 +32,7 @@ func (m *Manager) GetDefault() *Config {
 			Requests: 3,
 			Per:      30 * time.Second,
 		},
+		UsersTokens:   make(map[string]*UserTokens),
 		Channels:      make(map[string]*Channel),
 		GlobalRoles:   make(map[string][]string),
 		GlobalAliases: make(map[string]string),
@@ -27,7 +41,7 @@ func (m *Manager) GetDefault() *Config {
 
 func (m *Manager) GetChannel() *Channel {
 	return &Channel{
-		WindowSecs: 180,
+		WindowSecs: defaultWindowSecs,
 		Spam: Spam{
 			Mode:       OnlineMode,
 			Exceptions: make(map[string]*ExceptionsSettings),
@@ -92,5 +106,12 @@ func (m *Manager) GetChannel() *Channel {
 		AliasGroups: make(map[string]*AliasGroups),
 		Markers:     make(map[string]map[string][]*Markers),
 		Commands:    make(map[string]*Commands),
+		Roles:       make(map[string][]string),
+		Trusts:      make(map[string]*Trust),
+		Profiles:    make(map[string]*Profile),
+		ProfileAuto: ProfileAuto{Categories: make(map[string]string)},
+		ChatLog:     ChatLog{RetentionDays: 7},
+		Stats:       StatsSettings{TopPeriodDays: 30},
+		Highlights:  Highlights{Enabled: true},
 	}
 }
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"twitchspam/internal/app/infrastructure/config/migrations"
+)
+
+const historyDir = "configs/history"
+const historyLimit = 50
+
+// Snapshot - сохранённая версия конфига канала.
+type Snapshot struct {
+	Author  string         `json:"author"`
+	Command string         `json:"command"`
+	Time    time.Time      `json:"time"`
+	Channel jsontext.Value `json:"channel"`
+
+	Diff []string `json:"-"` // отличия от предыдущей версии
+}
+
+// UpdateBy работает как Update, но дополнительно сохраняет версию конфига канала,
+// если команда его изменила. modify получает копию конфига, которая после проверки
+// и сохранения атомарно подменяет текущий.
+func (m *Manager) UpdateBy(channel, author, command string, modify func(cfg *Config)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cur := m.cfg.Load()
+	if cur == nil {
+		return errors.New("no config loaded")
+	}
+
+	channel = strings.ToLower(channel)
+	before := marshalChannel(cur, channel)
+
+	cfg := cur.clone()
+	modify(cfg)
+
+	if err := m.validate(cfg); err != nil {
+		return fmt.Errorf("invalid config update: %w", err)
+	}
+
+	if err := m.saveLocked(cur, cfg); err != nil {
+		return err
+	}
+	m.storeLocked(cur, cfg)
+
+	after := marshalChannel(cfg, channel)
+	if after == nil || bytes.Equal(before, after) {
+		return nil
+	}
+
+	if err := m.recordSnapshot(channel, before, after, author, command); err != nil {
+		return fmt.Errorf("record snapshot: %w", err)
+	}
+	return nil
+}
+
+// History возвращает версии конфига канала, начиная с самой новой.
+func (m *Manager) History(channel string) ([]Snapshot, error) {
+	files, err := m.historyFiles(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]Snapshot, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var s Snapshot
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", filepath.Base(file), err)
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	for i := range snapshots {
+		if i+1 < len(snapshots) {
+			snapshots[i].Diff = diffJSON(snapshots[i+1].Channel, snapshots[i].Channel)
+		}
+	}
+	return snapshots, nil
+}
+
+// Snapshot возвращает конфиг канала из версии n (1 - самая новая).
+func (m *Manager) Snapshot(channel string, n int) (*Channel, error) {
+	files, err := m.historyFiles(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	if n < 1 || n > len(files) {
+		return nil, fmt.Errorf("snapshot %d not found", n)
+	}
+
+	data, err := os.ReadFile(files[n-1])
+	if err != nil {
+		return nil, err
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+
+	// версия могла быть сохранена до изменения схемы
+	data, _, err = migrations.Migrate(s.Channel, migrations.Channel)
+	if err != nil {
+		return nil, err
+	}
+	return parseChannel(data)
+}
+
+func marshalChannel(cfg *Config, channel string) []byte {
+	ch, ok := cfg.Channels[channel]
+	if !ok || ch == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(ch, json.OmitZeroStructFields(true), json.Deterministic(true))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func (m *Manager) recordSnapshot(channel string, before, after []byte, author, command string) error {
+	dir := filepath.Join(historyDir, channel)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	files, err := m.historyFiles(channel)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if len(files) == 0 && before != nil {
+		// первая версия - состояние до первой изменившей конфиг команды
+		if err := m.writeSnapshot(dir, Snapshot{Command: "исходная версия", Time: now.Add(-time.Nanosecond), Channel: before}); err != nil {
+			return err
+		}
+	}
+
+	if err := m.writeSnapshot(dir, Snapshot{Author: author, Command: command, Time: now, Channel: after}); err != nil {
+		return err
+	}
+
+	files, err = m.historyFiles(channel)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files[min(len(files), historyLimit):] {
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Manager) writeSnapshot(dir string, s Snapshot) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	if err := (*jsontext.Value)(&data).Indent(); err != nil {
+		return err
+	}
+
+	return m.writeAtomic(filepath.Join(dir, strconv.FormatInt(s.Time.UnixNano(), 10)+".json"), data, 0644)
+}
+
+// historyFiles возвращает файлы версий канала, начиная с самой новой.
+func (m *Manager) historyFiles(channel string) ([]string, error) {
+	dir := filepath.Join(historyDir, strings.ToLower(channel))
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		files = append(files, e.Name())
+	}
+
+	// имена файлов - время сохранения в наносекундах
+	slices.SortFunc(files, func(a, b string) int {
+		ta, _ := strconv.ParseInt(strings.TrimSuffix(a, ".json"), 10, 64)
+		tb, _ := strconv.ParseInt(strings.TrimSuffix(b, ".json"), 10, 64)
+		return cmp.Compare(tb, ta)
+	})
+
+	for i, f := range files {
+		files[i] = filepath.Join(dir, f)
+	}
+	return files, nil
+}
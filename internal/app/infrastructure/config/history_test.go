@@ -0,0 +1,139 @@
+package config_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"strconv"
+	"testing"
+	"twitchspam/internal/app/infrastructure/config"
+)
+
+func setMaxWordLength(m *config.Manager, t *testing.T, n int) {
+	t.Helper()
+
+	require.NoError(t, m.UpdateBy("test", "mod", "!am mwl "+strconv.Itoa(n), func(cfg *config.Config) {
+		cfg.Channels["test"].Spam.SettingsDefault.MaxWordLength = n
+	}))
+}
+
+func TestUpdateBy_RecordsOnlyChanges(t *testing.T) {
+	m := newTestManager(t)
+
+	setMaxWordLength(m, t, 200)
+
+	snapshots, err := m.History("test")
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+
+	assert.Equal(t, "mod", snapshots[0].Author)
+	assert.Equal(t, "!am mwl 200", snapshots[0].Command)
+	assert.Equal(t, []string{"spam.settings_default.max_word_length: 100 -> 200"}, snapshots[0].Diff)
+	assert.Equal(t, "исходная версия", snapshots[1].Command)
+	assert.Empty(t, snapshots[1].Diff)
+
+	// команда без изменений не создаёт новую версию, даже если в канале есть map
+	require.NoError(t, m.Update(func(cfg *config.Config) {
+		cfg.Channels["test"].Aliases = map[string]string{"!a": "!1", "!b": "!2", "!c": "!3", "!d": "!4", "!e": "!5"}
+	}))
+	for range 10 {
+		setMaxWordLength(m, t, 200)
+	}
+
+	snapshots, err = m.History("test")
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+}
+
+func TestUpdateBy_CopyOnWrite(t *testing.T) {
+	m := newTestManager(t)
+
+	require.NoError(t, m.Update(func(cfg *config.Config) {
+		cfg.Channels["test"].Mword = []config.Mword{{Word: "тест", Regexp: regexp.MustCompile("тест"), Punishments: []config.Punishment{{Action: "delete"}}}}
+	}))
+	old := m.Get()
+
+	setMaxWordLength(m, t, 200)
+
+	// прежняя версия не меняется, изменения видны только в новой
+	cur := m.Get()
+	require.NotSame(t, old, cur)
+	assert.Equal(t, 100, old.Channels["test"].Spam.SettingsDefault.MaxWordLength)
+	assert.Equal(t, 200, cur.Channels["test"].Spam.SettingsDefault.MaxWordLength)
+
+	// скомпилированные регулярки общие для всех версий
+	assert.Same(t, old.Channels["test"].Mword[0].Regexp, cur.Channels["test"].Mword[0].Regexp)
+
+	// невалидное изменение не затрагивает текущий конфиг
+	require.Error(t, m.Update(func(cfg *config.Config) {
+		cfg.Channels["test"].Spam.SettingsDefault.MaxWordLength = 1000
+	}))
+	assert.Same(t, cur, m.Get())
+	assert.Equal(t, 200, m.Get().Channels["test"].Spam.SettingsDefault.MaxWordLength)
+}
+
+func TestUpdateBy_InvalidChangeNotRecorded(t *testing.T) {
+	m := newTestManager(t)
+
+	err := m.UpdateBy("test", "mod", "!am mwl 1000", func(cfg *config.Config) {
+		cfg.Channels["test"].Spam.SettingsDefault.MaxWordLength = 1000
+	})
+	assert.Error(t, err)
+
+	snapshots, err := m.History("test")
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
+
+func TestUpdateBy_HistoryLimit(t *testing.T) {
+	m := newTestManager(t)
+
+	for i := 1; i <= 60; i++ {
+		setMaxWordLength(m, t, 100+i)
+	}
+
+	snapshots, err := m.History("test")
+	require.NoError(t, err)
+	require.Len(t, snapshots, 50)
+
+	// остаются самые новые версии, исходная удалена
+	assert.Equal(t, "!am mwl 160", snapshots[0].Command)
+	assert.Equal(t, "!am mwl 111", snapshots[49].Command)
+}
+
+func TestSnapshot_Numbering(t *testing.T) {
+	m := newTestManager(t)
+
+	setMaxWordLength(m, t, 200)
+	setMaxWordLength(m, t, 300)
+
+	for n, want := range map[int]int{1: 300, 2: 200, 3: 100} {
+		ch, err := m.Snapshot("test", n)
+		require.NoError(t, err)
+		assert.Equal(t, want, ch.Spam.SettingsDefault.MaxWordLength, "version %d", n)
+	}
+
+	for _, n := range []int{0, 4} {
+		_, err := m.Snapshot("test", n)
+		assert.Error(t, err, "version %d", n)
+	}
+
+	// откат к версии #3 сам становится версией #1, а прежняя #3 сдвигается на #4
+	ch, err := m.Snapshot("test", 3)
+	require.NoError(t, err)
+	require.NoError(t, m.UpdateBy("test", "mod", "!am config rollback 3", func(cfg *config.Config) {
+		*cfg.Channels["test"] = *ch
+	}))
+
+	snapshots, err := m.History("test")
+	require.NoError(t, err)
+	require.Len(t, snapshots, 4)
+	assert.Equal(t, "!am config rollback 3", snapshots[0].Command)
+	assert.Equal(t, []string{"spam.settings_default.max_word_length: 300 -> 100"}, snapshots[0].Diff)
+
+	for n, want := range map[int]int{1: 100, 2: 300, 3: 200, 4: 100} {
+		ch, err := m.Snapshot("test", n)
+		require.NoError(t, err)
+		assert.Equal(t, want, ch.Spam.SettingsDefault.MaxWordLength, "version %d", n)
+	}
+}
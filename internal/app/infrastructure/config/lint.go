@@ -0,0 +1,350 @@
+package config
+
+import (
+	"cmp"
+	"encoding/json/v2"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"twitchspam/internal/app/infrastructure/config/migrations"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue - найденная при проверке конфига проблема.
+type Issue struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	File     string `json:"file,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (i Issue) String() string {
+	var where []string
+	if i.File != "" {
+		where = append(where, i.File)
+	}
+	if i.Channel != "" {
+		where = append(where, "channel "+i.Channel)
+	}
+
+	if len(where) == 0 {
+		return fmt.Sprintf("%s [%s] %s", i.Severity, i.Check, i.Message)
+	}
+	return fmt.Sprintf("%s [%s] %s: %s", i.Severity, i.Check, strings.Join(where, ", "), i.Message)
+}
+
+// Lint проверяет конфиг в каталоге dir (config.json и channels/*.json), ничего не записывая на диск:
+// миграции применяются в памяти, правила проверяются той же валидацией, что и при запуске бота.
+func Lint(dir string) []Issue {
+	var issues []Issue
+	report := func(severity, check, file, channel, format string, args ...any) {
+		issues = append(issues, Issue{Severity: severity, Check: check, File: file, Channel: channel, Message: fmt.Sprintf(format, args...)})
+	}
+
+	cfgFile := filepath.Join(dir, filepath.Base(configPath))
+	var cfg Config
+	if err := lintRead(cfgFile, migrations.Config, &cfg); err != nil {
+		report(SeverityError, "parse", cfgFile, "", "%v", err)
+		return issues
+	}
+
+	// секреты при проверке обычно недоступны: они задаются окружением или файлом секретов
+	for _, f := range secretFields {
+		if *f.field(&cfg) == "" {
+			*f.field(&cfg) = "lint"
+		}
+	}
+
+	cfg.Channels = make(map[string]*Channel)
+	files, _ := filepath.Glob(filepath.Join(dir, filepath.Base(channelsDir), "*.json"))
+	chFiles := make(map[string]string, len(files))
+	for _, file := range files {
+		var ch Channel
+		if err := lintRead(file, migrations.Channel, &ch); err != nil {
+			report(SeverityError, "parse", file, "", "%v", err)
+			continue
+		}
+
+		name := strings.ToLower(ch.Name)
+		if prev, ok := chFiles[name]; ok {
+			report(SeverityError, "duplicate_channel", file, name, "channel is already defined in %s", prev)
+			continue
+		}
+		cfg.Channels[name], chFiles[name] = &ch, file
+	}
+
+	m := &Manager{}
+	global := cfg
+	global.Channels = nil
+	if err := m.validate(&global); err != nil {
+		report(SeverityError, "validate", cfgFile, "", "%v", err)
+	}
+	cfg.GlobalAliases = global.GlobalAliases
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Channels)) {
+		ch, file := cfg.Channels[name], chFiles[name]
+		if err := m.ValidateChannel(&cfg, ch); err != nil {
+			report(SeverityError, "validate", file, name, "%v", err)
+			continue
+		}
+
+		shadowed, duplicates := lintMwords(ch)
+		for _, msg := range duplicates {
+			report(SeverityWarning, "mword_duplicate", file, name, "%s", msg)
+		}
+		for _, msg := range shadowed {
+			report(SeverityWarning, "mword_shadowed", file, name, "%s", msg)
+		}
+		for _, msg := range lintAliases(ch, cfg.GlobalAliases) {
+			report(SeverityWarning, "alias_duplicate", file, name, "%s", msg)
+		}
+		for _, msg := range lintAliasCycles(ch, cfg.GlobalAliases) {
+			report(SeverityError, "alias_cycle", file, name, "%s", msg)
+		}
+		for _, msg := range lintTimers(ch, cfg.GlobalAliases) {
+			report(SeverityError, "timer_command", file, name, "%s", msg)
+		}
+	}
+	return issues
+}
+
+func lintRead(path string, kind migrations.Kind, v any) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	data, _, err := migrations.Migrate(raw, kind)
+	if err != nil {
+		return err
+	}
+
+	// регулярные выражения мвордов и исключений компилируются при разборе
+	return json.Unmarshal(data, v)
+}
+
+type lintMword struct {
+	name string // откуда правило: мворд или группа
+	mw   Mword
+	opts *MwordOptions
+}
+
+// lintMwords ищет мворды, срабатывание которых перекрывается более общим правилом:
+// сообщение, подходящее под перекрытое правило, всегда подходит и под перекрывающее.
+func lintMwords(ch *Channel) (shadowed, duplicates []string) {
+	var rules []lintMword
+	for _, mw := range ch.Mword {
+		rules = append(rules, lintMword{name: "mword", mw: mw, opts: mw.Options})
+	}
+	for _, group := range slices.Sorted(maps.Keys(ch.MwordGroup)) {
+		mwg := ch.MwordGroup[group]
+		if !mwg.Enabled {
+			continue
+		}
+
+		for _, mw := range mwg.Words {
+			opts := mwg.Options
+			if mw.Options != nil {
+				opts = mw.Options
+			}
+			rules = append(rules, lintMword{name: "mword group " + group, mw: mw, opts: opts})
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, a := range rules {
+		for j, b := range rules {
+			if i == j || a.mw.Word == "" || b.mw.Word == "" {
+				continue
+			}
+
+			if a.mw.Word == b.mw.Word {
+				if i < j {
+					duplicates = append(duplicates, fmt.Sprintf("%q is defined twice (%s, %s)", a.mw.Word, a.name, b.name))
+				}
+				continue
+			}
+
+			msg := fmt.Sprintf("%q (%s) is shadowed by %q (%s)", b.mw.Word, b.name, a.mw.Word, a.name)
+			if !seen[msg] && lintCovers(a, b) {
+				seen[msg] = true
+				shadowed = append(shadowed, msg)
+			}
+		}
+	}
+	return shadowed, duplicates
+}
+
+// lintCovers сообщает, срабатывает ли правило a на всех сообщениях, на которых срабатывает b.
+func lintCovers(a, b lintMword) bool {
+	// правило с ограничениями срабатывает не всегда
+	if o := a.opts; o != nil {
+		for _, opt := range []*bool{o.IsFirst, o.NoSub, o.NoVip, o.OneWord, o.CaseSensitive} {
+			if opt != nil && *opt {
+				return false
+			}
+		}
+	}
+
+	if aMode := lintMode(a.opts); aMode != AlwaysMode && aMode != lintMode(b.opts) {
+		return false
+	}
+
+	aWord, bWord := strings.ToLower(a.mw.Word), strings.ToLower(b.mw.Word)
+	if (a.opts != nil && a.opts.Contains != nil && *a.opts.Contains) || strings.Contains(aWord, " ") {
+		return strings.Contains(bWord, aWord)
+	}
+
+	// b срабатывает на подстроку, а a - только на отдельное слово
+	if b.opts != nil && b.opts.Contains != nil && *b.opts.Contains {
+		return false
+	}
+	return slices.Contains(strings.Fields(bWord), aWord)
+}
+
+// lintMode возвращает режим работы мворда; по умолчанию мворды работают только на стриме.
+func lintMode(o *MwordOptions) int {
+	if o == nil || o.Mode == nil || *o.Mode == 0 {
+		return OnlineMode
+	}
+	return *o.Mode
+}
+
+// lintAliases ищет алиасы, определённые в нескольких местах.
+func lintAliases(ch *Channel, globalAliases map[string]string) []string {
+	defined := make(map[string][]string)
+	for alias, original := range ch.Aliases {
+		if global, ok := globalAliases[alias]; ok && global != original {
+			defined[alias] = append(defined[alias], "global aliases")
+		}
+		defined[alias] = append(defined[alias], "aliases")
+	}
+	for _, group := range slices.Sorted(maps.Keys(ch.AliasGroups)) {
+		for alias := range ch.AliasGroups[group].Aliases {
+			defined[alias] = append(defined[alias], "alias group "+group)
+		}
+	}
+
+	var out []string
+	for _, alias := range slices.Sorted(maps.Keys(defined)) {
+		if places := defined[alias]; len(places) > 1 {
+			out = append(out, fmt.Sprintf("%q is defined in %s", alias, strings.Join(places, ", ")))
+		}
+	}
+	return out
+}
+
+// lintAliasCycles ищет алиасы, которые через цепочку замен указывают сами на себя.
+func lintAliasCycles(ch *Channel, globalAliases map[string]string) []string {
+	aliases := lintAllAliases(ch, globalAliases)
+
+	seen := make(map[string]bool)
+	var out []string
+	for _, start := range slices.Sorted(maps.Keys(aliases)) {
+		path := []string{start}
+		for cur := start; ; {
+			next, ok := lintResolveAlias(aliases, aliases[cur])
+			if !ok {
+				break
+			}
+
+			if i := slices.Index(path, next); i != -1 {
+				cycle := path[i:]
+				key := slices.Min(cycle)
+				if !seen[key] {
+					seen[key] = true
+					out = append(out, "alias cycle: "+strings.Join(append(slices.Clone(cycle), next), " -> "))
+				}
+				break
+			}
+
+			path = append(path, next)
+			cur = next
+		}
+	}
+	return out
+}
+
+// lintTimers ищет таймеры без текста и таймеры, текст которых ссылается на несуществующую команду.
+func lintTimers(ch *Channel, globalAliases map[string]string) []string {
+	aliases := lintAllAliases(ch, globalAliases)
+
+	var out []string
+	for _, name := range slices.Sorted(maps.Keys(ch.Commands)) {
+		cmd := ch.Commands[name]
+		if cmd == nil || cmd.Timer == nil {
+			continue
+		}
+
+		text := strings.TrimSpace(cmd.Text)
+		if text == "" {
+			out = append(out, fmt.Sprintf("timer %s has no command text", name))
+			continue
+		}
+
+		target := strings.ToLower(strings.Fields(text)[0])
+		if !strings.HasPrefix(target, "!") || target == name {
+			continue
+		}
+
+		if resolved, ok := lintResolveAlias(aliases, target); ok {
+			target = strings.ToLower(strings.Fields(aliases[resolved])[0])
+		}
+
+		if _, ok := ch.Commands[target]; !ok && !strings.HasPrefix(target, "!am") {
+			out = append(out, fmt.Sprintf("timer %s references missing command %s", name, target))
+		}
+	}
+	return out
+}
+
+// lintAllAliases собирает алиасы в том же порядке приоритета, что и шаблон алиасов.
+func lintAllAliases(ch *Channel, globalAliases map[string]string) map[string]string {
+	aliases := maps.Clone(globalAliases)
+	if aliases == nil {
+		aliases = make(map[string]string)
+	}
+	maps.Copy(aliases, ch.Aliases)
+
+	for _, alg := range ch.AliasGroups {
+		if !alg.Enabled {
+			continue
+		}
+
+		for alias := range alg.Aliases {
+			aliases[alias] = alg.Original
+		}
+	}
+
+	// пустые значения шаблон алиасов игнорирует
+	maps.DeleteFunc(aliases, func(_, original string) bool {
+		return strings.TrimSpace(original) == ""
+	})
+	return aliases
+}
+
+// lintResolveAlias возвращает алиас, которым начинается текст (самый длинный из подходящих).
+func lintResolveAlias(aliases map[string]string, text string) (string, bool) {
+	words := strings.Fields(strings.ToLower(text))
+
+	var best string
+	for alias := range aliases {
+		aw := strings.Fields(strings.ToLower(alias))
+		if len(aw) == 0 || len(aw) > len(words) || !slices.Equal(words[:len(aw)], aw) {
+			continue
+		}
+
+		if len(alias) > len(best) || (len(alias) == len(best) && cmp.Less(alias, best)) {
+			best = alias
+		}
+	}
+	return best, best != ""
+}
@@ -0,0 +1,137 @@
+package config_test
+
+import (
+	"encoding/json/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+	"twitchspam/internal/app/infrastructure/config"
+)
+
+// skipWithoutTimers пропускает тест, если тулчейн не умеет сериализовать таймеры (format:nano).
+func skipWithoutTimers(t *testing.T) {
+	t.Helper()
+
+	if _, err := json.Marshal(config.Timers{}); err != nil {
+		t.Skipf("timers are not supported by this toolchain: %v", err)
+	}
+}
+
+func TestLint(t *testing.T) {
+	ptr := func(v bool) *bool { return &v }
+	punishments := []config.Punishment{{Action: "delete"}}
+	timer := func(text string) *config.Commands {
+		return &config.Commands{Text: text, Timer: &config.Timers{Enabled: true, Interval: time.Minute, Count: 1}}
+	}
+
+	tests := []struct {
+		name   string
+		update func(ch *config.Channel)
+		check  string
+		want   string
+		timers bool
+	}{
+		{
+			name: "mword shadowed",
+			update: func(ch *config.Channel) {
+				ch.Mword = []config.Mword{{Word: "казино", Punishments: punishments}, {Word: "лучшее казино", Punishments: punishments}}
+			},
+			check: "mword_shadowed",
+			want:  `"лучшее казино" (mword) is shadowed by "казино" (mword)`,
+		},
+		{
+			name: "mword shadowed by contains",
+			update: func(ch *config.Channel) {
+				ch.Mword = []config.Mword{{Word: "bot", Options: &config.MwordOptions{Contains: ptr(true)}, Punishments: punishments}, {Word: "robots", Punishments: punishments}}
+			},
+			check: "mword_shadowed",
+			want:  `"robots" (mword) is shadowed by "bot" (mword)`,
+		},
+		{
+			name: "mword duplicate",
+			update: func(ch *config.Channel) {
+				ch.Mword = []config.Mword{{Word: "казино", Punishments: punishments}}
+				ch.MwordGroup = map[string]*config.MwordGroup{"ads": {Enabled: true, Punishments: punishments, Words: []config.Mword{{Word: "казино"}}}}
+			},
+			check: "mword_duplicate",
+			want:  `"казино" is defined twice (mword, mword group ads)`,
+		},
+		{
+			name: "alias duplicate",
+			update: func(ch *config.Channel) {
+				ch.Aliases = map[string]string{"!tg": "!telegram"}
+				ch.AliasGroups = map[string]*config.AliasGroups{"links": {Enabled: true, Original: "!links", Aliases: map[string]struct{}{"!tg": {}}}}
+			},
+			check: "alias_duplicate",
+			want:  `"!tg" is defined in aliases, alias group links`,
+		},
+		{
+			name: "alias cycle",
+			update: func(ch *config.Channel) {
+				ch.Aliases = map[string]string{"!a": "!b", "!b": "!c", "!c": "!a"}
+			},
+			check: "alias_cycle",
+			want:  "alias cycle: !a -> !b -> !c -> !a",
+		},
+		{
+			name: "timer without text",
+			update: func(ch *config.Channel) {
+				ch.Commands = map[string]*config.Commands{"!empty": timer(" ")}
+			},
+			check:  "timer_command",
+			want:   "timer !empty has no command text",
+			timers: true,
+		},
+		{
+			name: "timer missing command",
+			update: func(ch *config.Channel) {
+				ch.Commands = map[string]*config.Commands{"!promo": timer("!tg")}
+				ch.Aliases = map[string]string{"!tg": "!telegram"}
+			},
+			check:  "timer_command",
+			want:   "timer !promo references missing command !telegram",
+			timers: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.timers {
+				skipWithoutTimers(t)
+			}
+
+			m := newTestManager(t)
+			require.Empty(t, config.Lint("configs"))
+
+			require.NoError(t, m.Update(func(cfg *config.Config) {
+				tt.update(cfg.Channels["test"])
+			}))
+
+			issues := config.Lint("configs")
+			require.Len(t, issues, 1, "%v", issues)
+			assert.Equal(t, tt.check, issues[0].Check)
+			assert.Equal(t, "test", issues[0].Channel)
+			assert.Equal(t, tt.want, issues[0].Message)
+		})
+	}
+}
+
+func TestLint_ValidTimers(t *testing.T) {
+	skipWithoutTimers(t)
+
+	m := newTestManager(t)
+
+	// таймер на существующую команду, через алиас и на команду бота - без замечаний
+	require.NoError(t, m.Update(func(cfg *config.Config) {
+		ch := cfg.Channels["test"]
+		ch.Aliases = map[string]string{"!tg": "!telegram"}
+		ch.Commands = map[string]*config.Commands{
+			"!telegram": {Text: "t.me/channel"},
+			"!promo":    {Text: "!tg", Timer: &config.Timers{Enabled: true, Interval: time.Minute, Count: 1}},
+			"!status":   {Text: "!am status", Timer: &config.Timers{Enabled: true, Interval: time.Minute, Count: 1}},
+		}
+	}))
+
+	assert.Empty(t, config.Lint("configs"))
+}
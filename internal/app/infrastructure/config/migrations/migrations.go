@@ -0,0 +1,130 @@
+package migrations
+
+import (
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Current - актуальная версия схемы config.json и файлов каналов.
+const Current = 3
+
+const versionKey = "schema_version"
+
+// ErrNewerVersion - файл создан более новой версией бота, запуск с ним невозможен.
+var ErrNewerVersion = errors.New("config schema version is newer than supported")
+
+type Kind int
+
+const (
+	Config Kind = iota
+	Channel
+)
+
+// Migration переводит документ с версии Version-1 на Version. Для файлов, которые
+// миграция не затрагивает, соответствующая функция может быть nil.
+type Migration struct {
+	Version     int
+	Description string
+	Config      func(doc map[string]any) error
+	Channel     func(doc map[string]any) error
+}
+
+// list - миграции в порядке применения; версии должны идти подряд, начиная с 1.
+var list = []Migration{
+	{
+		Version:     1,
+		Description: "заполнение отсутствующих словарей",
+		Config:      migrateConfigV1,
+		Channel:     migrateChannelV1,
+	},
+	{
+		Version:     2,
+		Description: "профили и журнал чата",
+		Channel:     migrateChannelV2,
+	},
+	{
+		Version:     3,
+		Description: "хайлайты и период топа чаттеров",
+		Channel:     migrateChannelV3,
+	},
+}
+
+// Migrate обновляет документ до актуальной версии. Возвращает исходную версию документа;
+// если она совпадает с Current, данные возвращаются без изменений.
+func Migrate(data []byte, kind Kind) ([]byte, int, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("parse json: %w", err)
+	}
+
+	from, err := version(doc)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if from > Current {
+		return nil, from, fmt.Errorf("%w: %d > %d", ErrNewerVersion, from, Current)
+	}
+
+	if from == Current {
+		return data, from, nil
+	}
+
+	for _, m := range list {
+		if m.Version <= from {
+			continue
+		}
+
+		fn := m.Config
+		if kind == Channel {
+			fn = m.Channel
+		}
+
+		if fn != nil {
+			if err := fn(doc); err != nil {
+				return nil, from, fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
+			}
+		}
+		doc[versionKey] = m.Version
+	}
+
+	out, err := json.Marshal(doc, json.Deterministic(true), jsontext.WithIndent("  "))
+	if err != nil {
+		return nil, from, fmt.Errorf("marshal json: %w", err)
+	}
+	return out, from, nil
+}
+
+func version(doc map[string]any) (int, error) {
+	raw, ok := doc[versionKey]
+	if !ok || raw == nil {
+		return 0, nil
+	}
+
+	v, ok := raw.(float64)
+	if !ok {
+		return 0, fmt.Errorf("%s must be a number", versionKey)
+	}
+
+	if v < 0 || v != math.Trunc(v) {
+		return 0, fmt.Errorf("%s must be a non-negative integer", versionKey)
+	}
+	return int(v), nil
+}
+
+// ensureMap создаёт пустой объект по ключу, если его нет или он равен null.
+func ensureMap(doc map[string]any, key string) (map[string]any, error) {
+	switch v := doc[key].(type) {
+	case nil:
+		m := make(map[string]any)
+		doc[key] = m
+		return m, nil
+	case map[string]any:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%s must be an object", key)
+	}
+}
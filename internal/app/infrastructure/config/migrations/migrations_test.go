@@ -0,0 +1,118 @@
+package migrations_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"twitchspam/internal/app/infrastructure/config/migrations"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", name, err)
+	}
+	return data
+}
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		kind     migrations.Kind
+		input    string
+		from     int
+		expected string
+	}{
+		{
+			name:     "config v0",
+			kind:     migrations.Config,
+			input:    "config_v0.json",
+			from:     0,
+			expected: "config_v3.json",
+		},
+		{
+			name:     "config v1",
+			kind:     migrations.Config,
+			input:    "config_v1.json",
+			from:     1,
+			expected: "config_v3.json",
+		},
+		{
+			name:     "config v2",
+			kind:     migrations.Config,
+			input:    "config_v2.json",
+			from:     2,
+			expected: "config_v3.json",
+		},
+		{
+			name:     "channel v0",
+			kind:     migrations.Channel,
+			input:    "channel_v0.json",
+			from:     0,
+			expected: "channel_v3.json",
+		},
+		{
+			name:     "channel v1",
+			kind:     migrations.Channel,
+			input:    "channel_v1.json",
+			from:     1,
+			expected: "channel_v3.json",
+		},
+		{
+			name:     "channel v2",
+			kind:     migrations.Channel,
+			input:    "channel_v2.json",
+			from:     2,
+			expected: "channel_v3.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, from, err := migrations.Migrate(readFixture(t, tt.input), tt.kind)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.from, from)
+			assert.JSONEq(t, string(readFixture(t, tt.expected)), string(out))
+		})
+	}
+}
+
+func TestMigrateV3_KeepsExplicitSettings(t *testing.T) {
+	t.Parallel()
+
+	out, _, err := migrations.Migrate([]byte(`{"schema_version": 2, "highlights": {"enabled": false}, "stats": {"top_period_days": 0}}`), migrations.Channel)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"schema_version": 3, "highlights": {"enabled": false}, "stats": {"top_period_days": 0}}`, string(out))
+}
+
+func TestMigrate_CurrentVersionUnchanged(t *testing.T) {
+	t.Parallel()
+
+	data := readFixture(t, "channel_v3.json")
+	out, from, err := migrations.Migrate(data, migrations.Channel)
+	assert.NoError(t, err)
+	assert.Equal(t, migrations.Current, from)
+	assert.Equal(t, data, out)
+}
+
+func TestMigrate_NewerVersion(t *testing.T) {
+	t.Parallel()
+
+	_, from, err := migrations.Migrate(readFixture(t, "channel_newer.json"), migrations.Channel)
+	assert.ErrorIs(t, err, migrations.ErrNewerVersion)
+	assert.Equal(t, 99, from)
+}
+
+func TestMigrate_InvalidDocument(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := migrations.Migrate(readFixture(t, "channel_invalid.json"), migrations.Channel)
+	assert.Error(t, err)
+}
@@ -0,0 +1,38 @@
+package migrations
+
+// Файлы до введения schema_version могли не содержать словарей, добавленных позже
+// (группы мвордов и алиасов, роли, трасты); раньше они заполнялись при валидации.
+
+func migrateConfigV1(doc map[string]any) error {
+	for _, key := range []string{"user_tokens", "global_roles", "global_aliases"} {
+		if _, err := ensureMap(doc, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateChannelV1(doc map[string]any) error {
+	for _, key := range []string{"mword_group", "markers", "commands", "aliases", "aliases_group", "roles", "trusts"} {
+		if _, err := ensureMap(doc, key); err != nil {
+			return err
+		}
+	}
+
+	spam, err := ensureMap(doc, "spam")
+	if err != nil {
+		return err
+	}
+
+	if _, err := ensureMap(spam, "exceptions"); err != nil {
+		return err
+	}
+
+	emotes, err := ensureMap(spam, "settings_emotes")
+	if err != nil {
+		return err
+	}
+
+	_, err = ensureMap(emotes, "exceptions")
+	return err
+}
@@ -0,0 +1,43 @@
+package migrations
+
+// Профили, автопереключение профилей и журнал чата появились после v1; их значения
+// по умолчанию раньше проставлялись при валидации.
+
+const defaultRetentionDays = 7
+
+func migrateChannelV2(doc map[string]any) error {
+	if _, err := ensureMap(doc, "profiles"); err != nil {
+		return err
+	}
+
+	auto, err := ensureMap(doc, "profile_auto")
+	if err != nil {
+		return err
+	}
+	if _, err := ensureMap(auto, "categories"); err != nil {
+		return err
+	}
+
+	groups, err := ensureMap(doc, "aliases_group")
+	if err != nil {
+		return err
+	}
+	for name := range groups {
+		group, err := ensureMap(groups, name)
+		if err != nil {
+			return err
+		}
+		if _, err := ensureMap(group, "aliases"); err != nil {
+			return err
+		}
+	}
+
+	chatLog, err := ensureMap(doc, "chat_log")
+	if err != nil {
+		return err
+	}
+	if days, _ := chatLog["retention_days"].(float64); days == 0 {
+		chatLog["retention_days"] = defaultRetentionDays
+	}
+	return nil
+}
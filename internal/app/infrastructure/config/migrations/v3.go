@@ -0,0 +1,26 @@
+package migrations
+
+// Хайлайты и период для !stats top all появились после v2: для существующих каналов
+// включаются те же значения по умолчанию, что и для новых. Нулевые значения на диск не пишутся,
+// поэтому отсутствие ключа в файлах до v3 означает, что настройка не задавалась.
+
+const defaultTopPeriodDays = 30
+
+func migrateChannelV3(doc map[string]any) error {
+	highlights, err := ensureMap(doc, "highlights")
+	if err != nil {
+		return err
+	}
+	if _, ok := highlights["enabled"]; !ok {
+		highlights["enabled"] = true
+	}
+
+	stats, err := ensureMap(doc, "stats")
+	if err != nil {
+		return err
+	}
+	if _, ok := stats["top_period_days"]; !ok {
+		stats["top_period_days"] = defaultTopPeriodDays
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 )
 
 type Config struct {
+	SchemaVersion int                    `json:"schema_version"`
 	App           App                    `json:"app"`
 	Proxy         *Proxy                 `json:"proxy"`
 	UserAccess    UserAccess             `json:"user_access"`
@@ -17,16 +18,24 @@ type Config struct {
 	GlobalRoles   map[string][]string    `json:"global_roles"` // ключ - название роли, значение - скоупы
 	GlobalAliases map[string]string      `json:"global_aliases"`
 	Banwords      Banwords               `json:"banwords"`
+	Storage       Storage                `json:"storage"`
+}
+
+// Storage - где хранятся каналы и кэши: json (файлы, по умолчанию) или bolt (встроенная база).
+type Storage struct {
+	Backend string `json:"backend"`
+	Path    string `json:"path"` // путь к файлу базы для bolt
 }
 
 type App struct {
-	LogLevel  string `json:"log_level"`
-	GinMode   string `json:"gin_mode"`
-	OAuth     string `json:"oauth"`
-	ClientID  string `json:"client_id"`
-	Username  string `json:"username"`
-	UserID    string `json:"user_id"`
-	AuthToken string `json:"auth_token"`
+	LogLevel  string   `json:"log_level"`
+	GinMode   string   `json:"gin_mode"`
+	OAuth     string   `json:"oauth"`
+	ClientID  string   `json:"client_id"`
+	Username  string   `json:"username"`
+	UserID    string   `json:"user_id"`
+	AuthToken string   `json:"auth_token"`
+	Admins    []string `json:"admins"` // логины глобальных администраторов бота
 }
 
 type Proxy struct {
@@ -48,20 +57,63 @@ type UserTokens struct {
 }
 
 type Channel struct {
-	ID          string                           `json:"id"`
-	Name        string                           `json:"name"`
-	Enabled     bool                             `json:"enabled"`
-	WindowSecs  int                              `json:"-"`
-	Spam        Spam                             `json:"spam"`
-	Automod     Automod                          `json:"automod"`
-	Mword       []Mword                          `json:"mword"`
-	MwordGroup  map[string]*MwordGroup           `json:"mword_group"`
-	Markers     map[string]map[string][]*Markers `json:"markers"` // первый ключ - юзернейм, второй ключ - название маркера
-	Commands    map[string]*Commands             `json:"commands"`
-	Aliases     map[string]string                `json:"aliases"` // ключ - алиас, значение - оригинальная команда
-	AliasGroups map[string]*AliasGroups          `json:"aliases_group"`
-	Roles       map[string][]string              `json:"roles"` // ключ - название роли, значение - скоупы
-	Trusts      map[string]*Trust                `json:"trusts"`
+	SchemaVersion int                              `json:"schema_version"`
+	ID            string                           `json:"id"`
+	Name          string                           `json:"name"`
+	Enabled       bool                             `json:"enabled"`
+	WindowSecs    int                              `json:"-"`
+	Spam          Spam                             `json:"spam"`
+	Automod       Automod                          `json:"automod"`
+	Mword         []Mword                          `json:"mword"`
+	MwordGroup    map[string]*MwordGroup           `json:"mword_group"`
+	Markers       map[string]map[string][]*Markers `json:"markers"` // первый ключ - юзернейм, второй ключ - название маркера
+	Commands      map[string]*Commands             `json:"commands"`
+	Aliases       map[string]string                `json:"aliases"` // ключ - алиас, значение - оригинальная команда
+	AliasGroups   map[string]*AliasGroups          `json:"aliases_group"`
+	Roles         map[string][]string              `json:"roles"` // ключ - название роли, значение - скоупы
+	Trusts        map[string]*Trust                `json:"trusts"`
+	Profiles      map[string]*Profile              `json:"profiles"`
+	ActiveProfile string                           `json:"active_profile"`
+	ProfileAuto   ProfileAuto                      `json:"profile_auto"`
+	ChatLog       ChatLog                          `json:"chat_log"`
+	Stats         StatsSettings                    `json:"stats"`
+	Highlights    Highlights                       `json:"highlights"`
+}
+
+// Highlights - автоматические маркеры на всплесках активности чата и топ моментов после стрима.
+type Highlights struct {
+	Enabled bool `json:"enabled"`
+}
+
+type StatsSettings struct {
+	TopPeriodDays int  `json:"top_period_days"` // период для !stats top all; 0 - за всё время
+	ModReport     bool `json:"mod_report"`      // добавлять отчёт по модераторам в итоги стрима
+}
+
+// ChatLog - журнал сообщений чата на диске для !am logs и !am grep.
+type ChatLog struct {
+	RetentionDays int `json:"retention_days"` // сколько суток хранить журнал
+}
+
+// Profile - именованный набор настроек канала, переключаемый через !am profile use.
+type Profile struct {
+	Spam        ProfileSpam `json:"spam"`
+	Automod     Automod     `json:"automod"`
+	MwordGroups []string    `json:"mword_groups"` // включённые группы мвордов, остальные выключаются
+}
+
+type ProfileSpam struct {
+	Mode            int               `json:"mode"`
+	SettingsDefault SpamSettings      `json:"settings_default"`
+	SettingsVIP     SpamSettings      `json:"settings_vip"`
+	SettingsEmotes  SpamSettingsEmote `json:"settings_emotes"`
+}
+
+// ProfileAuto - автоматическое переключение профилей; пустое значение - не переключать.
+type ProfileAuto struct {
+	Online     string            `json:"online"`
+	Offline    string            `json:"offline"`
+	Categories map[string]string `json:"categories"` // ключ - категория в нижнем регистре, значение - профиль
 }
 
 type Trust struct {
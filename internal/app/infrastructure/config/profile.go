@@ -0,0 +1,79 @@
+package config
+
+import (
+	"encoding/json/v2"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SaveProfile сохраняет текущие настройки спама, автомода и включённые группы мвордов канала в профиль.
+func SaveProfile(ch *Channel, name string) error {
+	p := &Profile{
+		Spam: ProfileSpam{
+			Mode:            ch.Spam.Mode,
+			SettingsDefault: ch.Spam.SettingsDefault,
+			SettingsVIP:     ch.Spam.SettingsVIP,
+			SettingsEmotes:  ch.Spam.SettingsEmotes,
+		},
+		Automod: ch.Automod,
+	}
+
+	for group, mwg := range ch.MwordGroup {
+		if mwg.Enabled {
+			p.MwordGroups = append(p.MwordGroups, group)
+		}
+	}
+	slices.Sort(p.MwordGroups)
+
+	// профиль не должен делить наказания и исключения с текущими настройками канала
+	p, err := clone(p)
+	if err != nil {
+		return fmt.Errorf("clone profile: %w", err)
+	}
+
+	ch.Profiles[strings.ToLower(name)] = p
+	ch.ActiveProfile = strings.ToLower(name)
+	return nil
+}
+
+// ApplyProfile переносит профиль в настройки канала.
+func ApplyProfile(ch *Channel, name string) error {
+	name = strings.ToLower(name)
+	p, ok := ch.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+
+	p, err := clone(p)
+	if err != nil {
+		return fmt.Errorf("clone profile: %w", err)
+	}
+
+	ch.Spam.Mode = p.Spam.Mode
+	ch.Spam.SettingsDefault = p.Spam.SettingsDefault
+	ch.Spam.SettingsVIP = p.Spam.SettingsVIP
+	ch.Spam.SettingsEmotes = p.Spam.SettingsEmotes
+	ch.Automod = p.Automod
+
+	for group, mwg := range ch.MwordGroup {
+		mwg.Enabled = slices.Contains(p.MwordGroups, group)
+	}
+
+	ch.ActiveProfile = name
+	return nil
+}
+
+func clone[T any](v T) (T, error) {
+	var out T
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return out, err
+	}
+
+	if err := json.Unmarshal(data, &out); err != nil {
+		return out, err
+	}
+	return out, nil
+}
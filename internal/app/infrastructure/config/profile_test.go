@@ -0,0 +1,78 @@
+package config_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"twitchspam/internal/app/infrastructure/config"
+)
+
+func newProfileChannel() *config.Channel {
+	ch := (&config.Manager{}).GetChannel()
+	ch.Profiles = make(map[string]*config.Profile)
+	ch.MwordGroup = map[string]*config.MwordGroup{
+		"ads":  {Enabled: true},
+		"spam": {Enabled: false},
+		"bots": {Enabled: true},
+	}
+	return ch
+}
+
+func TestSaveProfile(t *testing.T) {
+	t.Parallel()
+
+	ch := newProfileChannel()
+	ch.Spam.SettingsDefault.MessageLimit = 5
+	ch.Automod = config.Automod{Enabled: true, Delay: 3}
+
+	require.NoError(t, config.SaveProfile(ch, "Night"))
+
+	p, ok := ch.Profiles["night"]
+	require.True(t, ok)
+	assert.Equal(t, "night", ch.ActiveProfile)
+	assert.Equal(t, 5, p.Spam.SettingsDefault.MessageLimit)
+	assert.Equal(t, config.Automod{Enabled: true, Delay: 3}, p.Automod)
+	assert.Equal(t, []string{"ads", "bots"}, p.MwordGroups)
+
+	// профиль - копия, а не ссылка на настройки канала
+	ch.Spam.SettingsDefault.MessageLimit = 7
+	ch.Spam.SettingsDefault.Punishments[0].Duration = 1
+	assert.Equal(t, 5, p.Spam.SettingsDefault.MessageLimit)
+	assert.Equal(t, 600, p.Spam.SettingsDefault.Punishments[0].Duration)
+}
+
+func TestApplyProfile(t *testing.T) {
+	t.Parallel()
+
+	ch := newProfileChannel()
+	ch.Spam.SettingsDefault.MessageLimit = 5
+	ch.Automod.Enabled = false
+	require.NoError(t, config.SaveProfile(ch, "day"))
+
+	ch.Spam.SettingsDefault.MessageLimit = 9
+	ch.Automod.Enabled = true
+	ch.MwordGroup["ads"].Enabled = false
+	ch.MwordGroup["spam"].Enabled = true
+	require.NoError(t, config.SaveProfile(ch, "night"))
+
+	require.NoError(t, config.ApplyProfile(ch, "DAY"))
+	assert.Equal(t, "day", ch.ActiveProfile)
+	assert.Equal(t, 5, ch.Spam.SettingsDefault.MessageLimit)
+	assert.False(t, ch.Automod.Enabled)
+	assert.True(t, ch.MwordGroup["ads"].Enabled)
+	assert.False(t, ch.MwordGroup["spam"].Enabled)
+	assert.True(t, ch.MwordGroup["bots"].Enabled)
+
+	// изменения канала после применения не попадают в профиль
+	ch.Spam.SettingsDefault.Punishments[0].Duration = 1
+	assert.Equal(t, 600, ch.Profiles["day"].Spam.SettingsDefault.Punishments[0].Duration)
+
+	require.NoError(t, config.ApplyProfile(ch, "night"))
+	assert.Equal(t, 9, ch.Spam.SettingsDefault.MessageLimit)
+	assert.True(t, ch.Automod.Enabled)
+	assert.False(t, ch.MwordGroup["ads"].Enabled)
+	assert.True(t, ch.MwordGroup["spam"].Enabled)
+
+	assert.Error(t, config.ApplyProfile(ch, "unknown"))
+	assert.Equal(t, "night", ch.ActiveProfile)
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"context"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ErrReloadRejected - изменения на диске не прошли проверку и не были применены.
+var ErrReloadRejected = errors.New("config reload rejected")
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// OnReload регистрирует обработчик, вызываемый после успешной перезагрузки конфига с диска.
+func (m *Manager) OnReload(fn func(cfg *Config)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.onReload = append(m.onReload, fn)
+}
+
+// Watch раз в interval проверяет файлы конфига и перезагружает их при изменении.
+// Результат каждой попытки передаётся в report: список изменений и ошибка, если правки отклонены.
+func (m *Manager) Watch(ctx context.Context, interval time.Duration, report func(diff []string, err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.filesMu.Lock()
+			changed := !maps.Equal(m.files, m.snapshotFiles())
+			m.filesMu.Unlock()
+
+			if !changed {
+				continue
+			}
+
+			diff, err := m.Reload()
+			report(diff, err)
+		}
+	}
+}
+
+// Reload перечитывает конфиг с диска, проверяет его и атомарно подменяет текущий.
+func (m *Manager) Reload() ([]string, error) {
+	m.mu.Lock()
+
+	cfg, err := m.readParse()
+
+	// запоминаем состояние файлов (в том числе после миграции), чтобы не перечитывать одни и те же невалидные правки
+	m.rememberFiles()
+	if err != nil {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("%w: %w", ErrReloadRejected, err)
+	}
+
+	if err := m.validate(cfg); err != nil {
+		m.mu.Unlock()
+		return diffConfigs(m.cfg.Load(), cfg), fmt.Errorf("%w: validate: %w", ErrReloadRejected, err)
+	}
+
+	old := m.cfg.Load()
+	diff := diffConfigs(old, cfg)
+
+	oldChannels, newChannels := slices.Sorted(maps.Keys(old.Channels)), slices.Sorted(maps.Keys(cfg.Channels))
+	if !slices.Equal(oldChannels, newChannels) {
+		m.mu.Unlock()
+		return diff, fmt.Errorf("%w: adding or removing channels requires a restart", ErrReloadRejected)
+	}
+
+	if len(diff) == 0 {
+		m.mu.Unlock()
+		return nil, nil
+	}
+
+	// компоненты не хранят *Config, а берут его через Get, поэтому достаточно подменить указатель
+	m.storeLocked(old, cfg)
+	subscribers := slices.Clone(m.onReload)
+	m.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(cfg)
+	}
+	return diff, nil
+}
+
+// rememberFiles запоминает текущее состояние файлов конфига.
+func (m *Manager) rememberFiles() {
+	m.filesMu.Lock()
+	defer m.filesMu.Unlock()
+
+	m.files = m.snapshotFiles()
+}
+
+func (m *Manager) snapshotFiles() map[string]fileState {
+	files := make(map[string]fileState)
+
+	paths := []string{configPath, secretsPath}
+	if entries, err := os.ReadDir(channelsDir); err == nil {
+		for _, e := range entries {
+			if !e.IsDir() && filepath.Ext(e.Name()) == ".json" {
+				paths = append(paths, filepath.Join(channelsDir, e.Name()))
+			}
+		}
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+	return files
+}
+
+// diffConfigs возвращает построчный список отличий двух конфигов в формате "путь: старое -> новое".
+func diffConfigs(oldCfg, newCfg *Config) []string {
+	oldData, _ := json.Marshal(oldCfg, json.OmitZeroStructFields(true))
+	newData, _ := json.Marshal(newCfg, json.OmitZeroStructFields(true))
+	return diffJSON(oldData, newData)
+}
+
+func diffJSON(oldData, newData []byte) []string {
+	var oldVal, newVal any
+	_ = json.Unmarshal(oldData, &oldVal)
+	_ = json.Unmarshal(newData, &newVal)
+
+	var diff []string
+	diffValues("", oldVal, newVal, &diff)
+	return diff
+}
+
+func diffValues(path string, oldVal, newVal any, diff *[]string) {
+	oldMap, okOld := oldVal.(map[string]any)
+	newMap, okNew := newVal.(map[string]any)
+	if okOld && okNew {
+		keys := maps.Clone(oldMap)
+		maps.Copy(keys, newMap)
+
+		for _, k := range slices.Sorted(maps.Keys(keys)) {
+			key := k
+			if path != "" {
+				key = path + "." + k
+			}
+			diffValues(key, oldMap[k], newMap[k], diff)
+		}
+		return
+	}
+
+	// отсутствующее значение и пустые map/слайс не считаются изменением
+	if isEmptyValue(oldVal) && isEmptyValue(newVal) {
+		return
+	}
+
+	oldData, _ := json.Marshal(oldVal, json.Deterministic(true))
+	newData, _ := json.Marshal(newVal, json.Deterministic(true))
+	if string(oldData) == string(newData) {
+		return
+	}
+
+	*diff = append(*diff, fmt.Sprintf("%s: %s -> %s", path, truncate(string(oldData)), truncate(string(newData))))
+}
+
+func isEmptyValue(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case map[string]any:
+		return len(v) == 0
+	case []any:
+		return len(v) == 0
+	}
+	return false
+}
+
+func truncate(s string) string {
+	const maxLen = 200
+	if r := []rune(s); len(r) > maxLen {
+		return string(r[:maxLen]) + "..."
+	}
+	return s
+}
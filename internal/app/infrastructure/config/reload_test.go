@@ -0,0 +1,93 @@
+package config_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"twitchspam/internal/app/infrastructure/config"
+)
+
+func writeConfig(t *testing.T, replace ...string) {
+	t.Helper()
+
+	data := strings.NewReplacer(replace...).Replace(testConfig)
+	require.NoError(t, os.WriteFile(filepath.Join("configs", "config.json"), []byte(data), 0644))
+}
+
+func TestReload_NoChanges(t *testing.T) {
+	m := newTestManager(t)
+	old := m.Get()
+
+	diff, err := m.Reload()
+	assert.NoError(t, err)
+	assert.Empty(t, diff)
+	assert.Same(t, old, m.Get())
+}
+
+func TestReload_SwapsConfigAndReportsDiff(t *testing.T) {
+	m := newTestManager(t)
+	old := m.Get()
+
+	var reloaded *config.Config
+	m.OnReload(func(cfg *config.Config) { reloaded = cfg })
+
+	writeConfig(t, `"log_level": "info"`, `"log_level": "debug"`, `"app": {`, `"global_aliases": {"!hi": "!hello"}, "app": {`)
+
+	diff, err := m.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`app.log_level: "info" -> "debug"`,
+		`global_aliases.!hi: null -> "!hello"`,
+	}, diff)
+
+	cfg := m.Get()
+	assert.NotSame(t, old, cfg)
+	assert.Same(t, cfg, reloaded)
+	assert.Equal(t, "debug", cfg.App.LogLevel)
+	assert.Equal(t, "info", old.App.LogLevel, "previous config must stay untouched")
+	assert.Contains(t, cfg.Channels, "test")
+}
+
+func TestReload_RejectsInvalidConfig(t *testing.T) {
+	m := newTestManager(t)
+	old := m.Get()
+
+	writeConfig(t, `"log_level": "info"`, `"log_level": "verbose"`)
+
+	diff, err := m.Reload()
+	assert.ErrorIs(t, err, config.ErrReloadRejected)
+	assert.Equal(t, []string{`app.log_level: "info" -> "verbose"`}, diff)
+	assert.Same(t, old, m.Get())
+}
+
+func TestReload_RejectsAddedChannel(t *testing.T) {
+	m := newTestManager(t)
+	old := m.Get()
+
+	data, err := os.ReadFile(filepath.Join("configs", "channels", "test.json"))
+	require.NoError(t, err)
+	data = []byte(strings.Replace(string(data), `"name": "test"`, `"name": "other"`, 1))
+	require.NoError(t, os.WriteFile(filepath.Join("configs", "channels", "other.json"), data, 0644))
+
+	diff, err := m.Reload()
+	assert.ErrorIs(t, err, config.ErrReloadRejected)
+	assert.NotEmpty(t, diff)
+	assert.Same(t, old, m.Get())
+	assert.NotContains(t, m.Get().Channels, "other")
+}
+
+func TestReload_RejectsRemovedChannel(t *testing.T) {
+	m := newTestManager(t)
+	old := m.Get()
+
+	require.NoError(t, os.Remove(filepath.Join("configs", "channels", "test.json")))
+
+	diff, err := m.Reload()
+	assert.ErrorIs(t, err, config.ErrReloadRejected)
+	assert.NotEmpty(t, diff)
+	assert.Same(t, old, m.Get())
+	assert.Contains(t, m.Get().Channels, "test")
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"encoding/json/jsontext"
+	"encoding/json/v2"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+)
+
+const secretsPath = "configs/secrets.json"
+
+const redacted = "[redacted]"
+
+// Secrets - отдельное хранилище секретов (0600), которое сохранения конфига не затрагивают.
+type Secrets struct {
+	OAuth        string                 `json:"oauth,omitempty"`
+	AuthToken    string                 `json:"auth_token,omitempty"`
+	ClientSecret string                 `json:"client_secret,omitempty"`
+	UsersTokens  map[string]*UserTokens `json:"user_tokens,omitempty"`
+}
+
+// secretFields - строковые секреты конфига и переменные окружения, которые их переопределяют.
+var secretFields = []struct {
+	env    string
+	field  func(cfg *Config) *string
+	secret func(s *Secrets) *string
+}{
+	{"TWITCHSPAM_OAUTH", func(cfg *Config) *string { return &cfg.App.OAuth }, func(s *Secrets) *string { return &s.OAuth }},
+	{"TWITCHSPAM_AUTH_TOKEN", func(cfg *Config) *string { return &cfg.App.AuthToken }, func(s *Secrets) *string { return &s.AuthToken }},
+	{"TWITCHSPAM_CLIENT_SECRET", func(cfg *Config) *string { return &cfg.UserAccess.ClientSecret }, func(s *Secrets) *string { return &s.ClientSecret }},
+}
+
+// applySecrets накладывает на конфиг значения из файла секретов и переменных окружения.
+func (m *Manager) applySecrets(cfg *Config) error {
+	data, err := os.ReadFile(secretsPath)
+	switch {
+	case err == nil:
+		var s Secrets
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("parse secrets: %w", err)
+		}
+		m.secretsFile.Store(true)
+
+		for _, f := range secretFields {
+			if val := *f.secret(&s); val != "" {
+				*f.field(cfg) = val
+			}
+		}
+
+		if s.UsersTokens != nil {
+			cfg.UsersTokens = s.UsersTokens
+		}
+		// при файле секретов токены в config.json не сохраняются, а пустой словарь в файле опускается
+		if cfg.UsersTokens == nil {
+			cfg.UsersTokens = make(map[string]*UserTokens)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("read secrets: %w", err)
+	}
+
+	for _, f := range secretFields {
+		if val, ok := os.LookupEnv(f.env); ok && val != "" {
+			*f.field(cfg) = val
+		}
+	}
+	return nil
+}
+
+// stripSecrets убирает из сохраняемой копии конфига секреты, которые хранятся вне config.json.
+func (m *Manager) stripSecrets(cfg *Config) {
+	useFile := m.secretsFile.Load()
+	for _, f := range secretFields {
+		if _, ok := os.LookupEnv(f.env); ok || useFile {
+			*f.field(cfg) = ""
+		}
+	}
+
+	if useFile {
+		cfg.UsersTokens = nil
+	}
+}
+
+// SetUserTokens публикует новую версию конфига с токенами пользователя и сохраняет их в файл секретов.
+// Значение nil удаляет токены. Общую блокировку конфига не берёт, поэтому безопасен и внутри Update.
+func (m *Manager) SetUserTokens(userID string, token *UserTokens) error {
+	m.secretsMu.Lock()
+	defer m.secretsMu.Unlock()
+
+	var cfg *Config
+	for {
+		cur := m.cfg.Load()
+		next := *cur
+		next.UsersTokens = make(map[string]*UserTokens, len(cur.UsersTokens)+1)
+		maps.Copy(next.UsersTokens, cur.UsersTokens)
+		if token == nil {
+			delete(next.UsersTokens, userID)
+		} else {
+			next.UsersTokens[userID] = token
+		}
+
+		if m.cfg.CompareAndSwap(cur, &next) {
+			cfg = &next
+			break
+		}
+	}
+
+	if err := m.saveSecretsLocked(cfg); err != nil {
+		return err
+	}
+
+	// собственная запись не должна восприниматься как ручная правка
+	m.rememberFiles()
+	return nil
+}
+
+// secretsChanged сообщает, изменились ли строковые секреты; токены пользователей меняет только SetUserTokens.
+func secretsChanged(old, cfg *Config) bool {
+	for _, f := range secretFields {
+		if *f.field(old) != *f.field(cfg) {
+			return true
+		}
+	}
+	return false
+}
+
+// saveSecretsLocked записывает секреты в файл секретов (0600); после этого они убираются из config.json.
+// Вызывается под secretsMu.
+func (m *Manager) saveSecretsLocked(cfg *Config) error {
+	m.updateRedactor(cfg)
+
+	s := Secrets{UsersTokens: cfg.UsersTokens}
+	for _, f := range secretFields {
+		// значения из окружения на диск не попадают
+		if _, ok := os.LookupEnv(f.env); !ok {
+			*f.secret(&s) = *f.field(cfg)
+		}
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("marshal secrets: %w", err)
+	}
+
+	if err := (*jsontext.Value)(&data).Indent(); err != nil {
+		return fmt.Errorf("indent: %w", err)
+	}
+
+	if err := m.writeAtomic(secretsPath, data, 0600); err != nil {
+		return fmt.Errorf("write secrets: %w", err)
+	}
+	m.secretsFile.Store(true)
+	return nil
+}
+
+// Redact заменяет в тексте все известные секреты.
+func (m *Manager) Redact(text string) string {
+	r := m.redactor.Load()
+	if r == nil {
+		return text
+	}
+	return r.Replace(text)
+}
+
+func (m *Manager) updateRedactor(cfg *Config) {
+	values := []string{cfg.App.OAuth, cfg.App.AuthToken, cfg.UserAccess.ClientSecret}
+	for _, t := range cfg.UsersTokens {
+		if t != nil {
+			values = append(values, t.AccessToken, t.RefreshToken)
+		}
+	}
+
+	var pairs []string
+	for _, v := range values {
+		// короткие значения не заменяем, чтобы не портить обычный текст
+		if len(v) < 8 {
+			continue
+		}
+		pairs = append(pairs, v, redacted)
+
+		if oauth, ok := strings.CutPrefix(v, "oauth:"); ok && len(oauth) >= 8 {
+			pairs = append(pairs, oauth, redacted)
+		}
+	}
+	m.redactor.Store(strings.NewReplacer(pairs...))
+}
@@ -0,0 +1,128 @@
+package config_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"twitchspam/internal/app/infrastructure/config"
+)
+
+var secretsPath = filepath.Join("configs", "secrets.json")
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestSecrets_EnvOverride(t *testing.T) {
+	t.Setenv("TWITCHSPAM_OAUTH", "env-oauth-token")
+	m := newTestManager(t)
+
+	assert.Equal(t, "env-oauth-token", m.Get().App.OAuth)
+	assert.Equal(t, "auth-token", m.Get().App.AuthToken)
+
+	require.NoError(t, m.SetUserTokens("42", &config.UserTokens{AccessToken: "user-access-token", RefreshToken: "user-refresh-token"}))
+
+	// значение из окружения не сохраняется ни в конфиг, ни в файл секретов
+	assert.NotContains(t, readFile(t, filepath.Join("configs", "config.json")), "oauth-token")
+	assert.NotContains(t, readFile(t, secretsPath), "oauth-token")
+	assert.Contains(t, readFile(t, secretsPath), "auth-token")
+	assert.Equal(t, "[redacted]", m.Redact("env-oauth-token"))
+}
+
+func TestSecrets_FileMode(t *testing.T) {
+	m := newTestManager(t)
+
+	require.NoError(t, m.SetUserTokens("42", &config.UserTokens{AccessToken: "user-access-token", RefreshToken: "user-refresh-token"}))
+
+	info, err := os.Stat(secretsPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	secrets := readFile(t, secretsPath)
+	assert.Contains(t, secrets, "user-access-token")
+	assert.Contains(t, secrets, "oauth-token")
+	assert.Equal(t, "token [redacted]", m.Redact("token user-access-token"))
+
+	require.NoError(t, m.SetUserTokens("42", nil))
+	assert.NotContains(t, readFile(t, secretsPath), "user-access-token")
+	assert.NotContains(t, m.Get().UsersTokens, "42")
+}
+
+func TestSecrets_StrippedOnSave(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	// токены из config.json старых версий переносятся в файл секретов при первом сохранении
+	require.NoError(t, os.MkdirAll("configs", 0750))
+	require.NoError(t, os.WriteFile(filepath.Join("configs", "config.json"), []byte(`{
+		"app": {"oauth": "oauth-token", "client_id": "client-id", "username": "bot", "user_id": "1", "auth_token": "auth-token"},
+		"user_tokens": {"42": {"access_token": "user-access-token", "refresh_token": "user-refresh-token"}}
+	}`), 0644))
+
+	m, err := config.New()
+	require.NoError(t, err)
+
+	require.NoError(t, m.Update(func(cfg *config.Config) {
+		cfg.App.LogLevel = "debug"
+	}))
+
+	cfgData := readFile(t, filepath.Join("configs", "config.json"))
+	for _, secret := range []string{"oauth-token", "auth-token", "user-access-token", "user-refresh-token"} {
+		assert.NotContains(t, cfgData, secret)
+		assert.Contains(t, readFile(t, secretsPath), secret)
+	}
+
+	// следующее сохранение не возвращает секреты в config.json
+	require.NoError(t, m.Update(func(cfg *config.Config) {
+		cfg.App.LogLevel = "info"
+	}))
+	assert.NotContains(t, readFile(t, filepath.Join("configs", "config.json")), "oauth-token")
+
+	// после перезапуска секреты читаются из файла секретов
+	restarted, err := config.New()
+	require.NoError(t, err)
+	assert.Equal(t, "oauth-token", restarted.Get().App.OAuth)
+	assert.Equal(t, "user-access-token", restarted.Get().UsersTokens["42"].AccessToken)
+}
+
+func TestSecrets_SavedOnlyOnChange(t *testing.T) {
+	m := newTestManager(t)
+
+	require.NoError(t, m.SetUserTokens("42", &config.UserTokens{AccessToken: "user-access-token", RefreshToken: "user-refresh-token"}))
+	require.NoError(t, os.Remove(secretsPath))
+
+	// правка без изменения секретов не переписывает файл секретов
+	require.NoError(t, m.Update(func(cfg *config.Config) {
+		cfg.App.LogLevel = "debug"
+	}))
+	assert.NoFileExists(t, secretsPath)
+
+	require.NoError(t, m.Update(func(cfg *config.Config) {
+		cfg.App.AuthToken = "new-auth-token"
+	}))
+	secrets := readFile(t, secretsPath)
+	assert.Contains(t, secrets, "new-auth-token")
+	assert.Contains(t, secrets, "user-access-token")
+	assert.Equal(t, "[redacted]", m.Redact("new-auth-token"))
+}
+
+func TestSetUserTokens_InsideUpdate(t *testing.T) {
+	m := newTestManager(t)
+	old := m.Get()
+
+	// токены обновляются из команды, которая выполняется внутри Update, и не теряются при его сохранении
+	require.NoError(t, m.Update(func(cfg *config.Config) {
+		require.NoError(t, m.SetUserTokens("42", &config.UserTokens{AccessToken: "user-access-token", RefreshToken: "user-refresh-token"}))
+		cfg.App.LogLevel = "debug"
+	}))
+
+	assert.NotContains(t, old.UsersTokens, "42")
+	assert.Equal(t, "user-access-token", m.Get().UsersTokens["42"].AccessToken)
+	assert.Equal(t, "debug", m.Get().App.LogLevel)
+	assert.Contains(t, readFile(t, secretsPath), "user-refresh-token")
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 )
 
 func (m *Manager) validate(cfg *Config) error {
@@ -33,8 +35,9 @@ func (m *Manager) validate(cfg *Config) error {
 		return errors.New("app.auth_token is required")
 	}
 
-	if cfg.UsersTokens == nil {
-		cfg.UsersTokens = make(map[string]*UserTokens)
+	// storage
+	if cfg.Storage.Backend != "" && cfg.Storage.Backend != StorageJSON && cfg.Storage.Backend != StorageBolt {
+		return fmt.Errorf("storage.backend must be one of json, bolt; got %s", cfg.Storage.Backend)
 	}
 
 	// limiter
@@ -44,15 +47,10 @@ func (m *Manager) validate(cfg *Config) error {
 
 	validPunishments := map[string]bool{"none": true, "delete": true, "timeout": true, "warn": true, "ban": true}
 	for _, channel := range cfg.Channels {
-		channel.WindowSecs = 180
-
 		// spam
 		if channel.Spam.Mode < 0 || channel.Spam.Mode > 3 {
 			return fmt.Errorf("spam.mode must be one of always (0), online (1), offline (2); got %d", channel.Spam.Mode)
 		}
-		if channel.Spam.Exceptions == nil {
-			channel.Spam.Exceptions = make(map[string]*ExceptionsSettings)
-		}
 		for _, except := range channel.Spam.Exceptions {
 			if except == nil {
 				return errors.New("spam.exceptions.value is required")
@@ -168,9 +166,6 @@ func (m *Manager) validate(cfg *Config) error {
 		if channel.Spam.SettingsEmotes.MaxEmotesLength != 0 && !validPunishments[channel.Spam.SettingsEmotes.MaxEmotesPunishment.Action] {
 			return fmt.Errorf("spam.settings_emote.max_emotes_punishments must be on of delete, warn, timeout, ban; got %s", channel.Spam.SettingsEmotes.MaxEmotesPunishment.Action)
 		}
-		if channel.Spam.SettingsEmotes.Exceptions == nil {
-			channel.Spam.SettingsEmotes.Exceptions = make(map[string]*ExceptionsSettings)
-		}
 		for _, except := range channel.Spam.SettingsEmotes.Exceptions {
 			if except == nil {
 				return errors.New("spam.settings_emote.exceptions.value is required")
@@ -219,9 +214,6 @@ func (m *Manager) validate(cfg *Config) error {
 			}
 		}
 
-		if channel.MwordGroup == nil {
-			channel.MwordGroup = make(map[string]*MwordGroup)
-		}
 		for _, mwg := range channel.MwordGroup {
 			if mwg == nil {
 				return errors.New("mword_group.value is required")
@@ -241,49 +233,41 @@ func (m *Manager) validate(cfg *Config) error {
 			}
 		}
 
-		if channel.Markers == nil {
-			channel.Markers = make(map[string]map[string][]*Markers)
-		}
-
-		if channel.Commands == nil {
-			channel.Commands = make(map[string]*Commands)
-		}
-
-		if channel.Aliases == nil {
-			channel.Aliases = make(map[string]string)
-		}
-
-		if channel.AliasGroups == nil {
-			channel.AliasGroups = make(map[string]*AliasGroups)
-		}
 		for _, alg := range channel.AliasGroups {
 			if alg == nil {
 				return errors.New("mword_group.value is required")
 			}
 
-			if alg.Aliases == nil {
-				alg.Aliases = make(map[string]struct{})
-			}
-
 			if alg.Original == "" {
 				return errors.New("mword_group.original must not be empty")
 			}
 		}
 
-		if channel.Roles == nil {
-			channel.Roles = make(map[string][]string)
+		// profiles
+		for name, p := range channel.Profiles {
+			if p == nil {
+				return errors.New("profiles.value is required")
+			}
+			if p.Spam.Mode < 0 || p.Spam.Mode > 3 {
+				return fmt.Errorf("profiles.%s.spam.mode must be one of always (0), online (1), offline (2); got %d", name, p.Spam.Mode)
+			}
 		}
-		if channel.Trusts == nil {
-			channel.Trusts = make(map[string]*Trust)
+
+		for _, name := range append([]string{channel.ProfileAuto.Online, channel.ProfileAuto.Offline}, slices.Collect(maps.Values(channel.ProfileAuto.Categories))...) {
+			if _, ok := channel.Profiles[name]; name != "" && !ok {
+				return fmt.Errorf("profile_auto references unknown profile %q", name)
+			}
 		}
-	}
 
-	if cfg.GlobalRoles == nil {
-		cfg.GlobalRoles = make(map[string][]string)
-	}
+		// chat log
+		if channel.ChatLog.RetentionDays < 1 || channel.ChatLog.RetentionDays > 365 {
+			return fmt.Errorf("chat_log.retention_days must be between 1 and 365; got %d", channel.ChatLog.RetentionDays)
+		}
 
-	if cfg.GlobalAliases == nil {
-		cfg.GlobalAliases = make(map[string]string)
+		// stats
+		if channel.Stats.TopPeriodDays < 0 || channel.Stats.TopPeriodDays > 366 {
+			return fmt.Errorf("stats.top_period_days must be between 0 and 366; got %d", channel.Stats.TopPeriodDays)
+		}
 	}
 
 	return nil
@@ -2,12 +2,20 @@ package storage
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/maypok86/otter/v2"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// flushDelay - сколько ждать после изменения перед записью, чтобы склеить серию изменений в одну запись.
+const flushDelay = 500 * time.Millisecond
+
 type Cache[T any] struct {
 	outer *otter.Cache[string, T]
 
@@ -17,99 +25,210 @@ type Cache[T any] struct {
 	persist       bool
 	flushOnChange bool
 	filePath      string
-	stopFlush     chan struct{}
+
+	onFlush FlushHook
+	onError ErrorHook
+
+	flushMu   sync.Mutex    // файл пишется только одной записью за раз
+	changed   atomic.Bool   // есть изменения, не записанные на диск
+	dirty     chan struct{} // сигнал фоновой записи; буфер 1 склеивает повторные сигналы
+	stopFlush chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-func NewCache[T any](capacity int32, ttl time.Duration, persist bool, flushOnChange bool, filePath string, flushInterval time.Duration) *Cache[T] {
+// FlushHook получает результат записи кэша на диск: длительность и размер записанных данных.
+type FlushHook func(cache string, d time.Duration, size int)
+
+type CacheOption func(*cacheOptions)
+
+type cacheOptions struct {
+	onFlush FlushHook
+	onError ErrorHook
+}
+
+// WithFlushHook задаёт обработчик успешных записей кэша на диск (например, для метрик).
+func WithFlushHook(hook FlushHook) CacheOption {
+	return func(o *cacheOptions) {
+		o.onFlush = hook
+	}
+}
+
+// WithErrorHook задаёт обработчик ошибок записи кэша на диск.
+func WithErrorHook(hook ErrorHook) CacheOption {
+	return func(o *cacheOptions) {
+		o.onError = hook
+	}
+}
+
+func NewCache[T any](capacity int32, ttl time.Duration, persist bool, flushOnChange bool, filePath string, flushInterval time.Duration, opts ...CacheOption) *Cache[T] {
+	var o cacheOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	c := &Cache[T]{
 		ttl:           atomic.Int64{},
 		cap:           atomic.Int32{},
 		persist:       persist,
 		flushOnChange: flushOnChange,
 		filePath:      filePath,
+		onFlush:       o.onFlush,
+		onError:       o.onError,
+		dirty:         make(chan struct{}, 1),
 		stopFlush:     make(chan struct{}),
+		done:          make(chan struct{}),
 	}
 	c.outer = otter.Must(&otter.Options[string, T]{
 		InitialCapacity:  int(capacity),
 		ExpiryCalculator: otter.ExpiryAccessing[string, T](ttl),
 		OnDeletion: func(e otter.DeletionEvent[string, T]) {
-			if c.persist && c.flushOnChange {
-				c.FlushToDisk()
-			}
+			c.markDirty()
 		},
 	})
 
 	c.ttl.Store(ttl.Nanoseconds())
 	c.cap.Store(capacity)
 
-	if c.persist && c.filePath != "" {
-		_ = c.loadFromDisk()
+	if !c.persist || c.filePath == "" {
+		close(c.done)
+		return c
 	}
 
-	if c.persist && !c.flushOnChange && flushInterval > 0 {
-		go c.periodicFlush(flushInterval)
-	}
+	_ = c.loadFromDisk()
+	go c.flusher(flushInterval)
 
 	return c
 }
 
 func (c *Cache[T]) Set(key string, val T) {
 	c.outer.Set(key, val)
-	if c.persist && c.flushOnChange {
-		go c.FlushToDisk()
-	}
+	c.markDirty()
 }
 
 func (c *Cache[T]) Get(key string) (T, bool) {
 	return c.outer.GetIfPresent(key)
 }
 
+// Keys возвращает отсортированные ключи, начинающиеся с prefix.
+func (c *Cache[T]) Keys(prefix string) []string {
+	var keys []string
+	for k := range c.outer.All() {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
 func (c *Cache[T]) ClearKey(key string) {
 	c.outer.Invalidate(key)
-	if c.persist && c.flushOnChange {
-		c.FlushToDisk()
-	}
+	c.markDirty()
 }
 
 func (c *Cache[T]) ClearAll() {
 	c.outer.InvalidateAll()
-	if c.persist && c.flushOnChange {
-		c.FlushToDisk()
-	}
+	c.markDirty()
 }
 
+// FlushToDisk сразу записывает кэш на диск через временный файл и переименование.
 func (c *Cache[T]) FlushToDisk() {
 	if !c.persist || c.filePath == "" {
 		return
 	}
 
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	// изменения, сделанные после этого места, попадут в следующую запись
+	c.changed.Store(false)
+
+	name := strings.TrimSuffix(filepath.Base(c.filePath), filepath.Ext(c.filePath))
+	start := time.Now()
+
 	cacheData := make(map[string]T)
 	for k, v := range c.outer.All() {
 		cacheData[k] = v
 	}
 
 	data, err := json.MarshalIndent(cacheData, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(c.filePath, data, 0600)
+	}
+
 	if err != nil {
+		c.changed.Store(true)
+		if c.onError != nil {
+			c.onError(name, "flush", err)
+		}
 		return
 	}
 
-	_ = os.WriteFile(c.filePath, data, 0600)
+	if c.onFlush != nil {
+		c.onFlush(name, time.Since(start), len(data))
+	}
 }
 
-func (c *Cache[T]) periodicFlush(interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+// Close останавливает фоновую запись и записывает несохранённые изменения.
+func (c *Cache[T]) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stopFlush)
+	})
+	<-c.done
+}
+
+func (c *Cache[T]) markDirty() {
+	if !c.persist {
+		return
+	}
+
+	c.changed.Store(true)
+	if c.flushOnChange {
+		select {
+		case c.dirty <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flusher - единственный писатель файла кэша: при flushOnChange записывает изменения
+// с задержкой flushDelay, иначе раз в interval, если что-то изменилось.
+func (c *Cache[T]) flusher(interval time.Duration) {
+	defer close(c.done)
+
+	var tick <-chan time.Time
+	if !c.flushOnChange && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
 
 	for {
 		select {
-		case <-ticker.C:
-			c.FlushToDisk()
+		case <-c.dirty:
+			timer := time.NewTimer(flushDelay)
+			select {
+			case <-timer.C:
+			case <-c.stopFlush:
+				timer.Stop()
+			}
+			c.flushIfChanged()
+		case <-tick:
+			c.flushIfChanged()
 		case <-c.stopFlush:
+			c.flushIfChanged()
 			return
 		}
 	}
 }
 
+func (c *Cache[T]) flushIfChanged() {
+	if c.changed.Load() {
+		c.FlushToDisk()
+	}
+}
+
 func (c *Cache[T]) loadFromDisk() error {
 	data, err := os.ReadFile(c.filePath)
 	if err != nil {
@@ -124,10 +243,36 @@ func (c *Cache[T]) loadFromDisk() error {
 	for k, v := range items {
 		c.outer.Set(k, v)
 	}
+	c.changed.Store(false)
 
 	return nil
 }
 
-func (c *Cache[T]) Close() {
-	close(c.stopFlush)
+// writeFileAtomic пишет данные во временный файл рядом с path и переименовывает его,
+// чтобы при падении на диске оставалась либо старая, либо новая версия целиком.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("sync: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
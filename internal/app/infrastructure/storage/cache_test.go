@@ -0,0 +1,124 @@
+package storage_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+	"twitchspam/internal/app/infrastructure/storage"
+)
+
+func TestCache_CoalescesWrites(t *testing.T) {
+	t.Parallel()
+
+	var flushes atomic.Int32
+	path := filepath.Join(t.TempDir(), "stats.json")
+	c := storage.NewCache[testItem](0, 0, true, true, path, 0,
+		storage.WithFlushHook(func(cache string, _ time.Duration, size int) {
+			assert.Equal(t, "stats", cache)
+			assert.Positive(t, size)
+			flushes.Add(1)
+		}),
+	)
+	t.Cleanup(c.Close)
+
+	// серия изменений записывается на диск одной записью
+	for i := range 20 {
+		c.Set(strconv.Itoa(i), testItem{Count: i})
+	}
+
+	require.Eventually(t, func() bool { return flushes.Load() == 1 }, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(time.Second)
+	assert.Equal(t, int32(1), flushes.Load())
+
+	loaded := storage.NewCache[testItem](0, 0, true, false, path, 0)
+	t.Cleanup(loaded.Close)
+	v, ok := loaded.Get("19")
+	require.True(t, ok)
+	assert.Equal(t, 19, v.Count)
+}
+
+func TestCache_AtomicRename(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "stats.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"old": {"name": "old"}}`), 0644))
+	before, err := os.Stat(path)
+	require.NoError(t, err)
+
+	c := storage.NewCache[testItem](0, 0, true, false, path, 0)
+	t.Cleanup(c.Close)
+
+	c.Set("new", testItem{Name: "new"})
+	c.FlushToDisk()
+
+	// файл заменён переименованием нового файла, временных файлов не осталось
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.False(t, os.SameFile(before, after))
+	assert.Equal(t, os.FileMode(0600), after.Mode().Perm())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "stats.json", entries[0].Name())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"old"`)
+	assert.Contains(t, string(data), `"new"`)
+}
+
+func TestCache_FlushError(t *testing.T) {
+	t.Parallel()
+
+	var failed atomic.Int32
+	path := filepath.Join(t.TempDir(), "missing", "stats.json")
+	c := storage.NewCache[testItem](0, 0, true, false, path, 0,
+		storage.WithErrorHook(func(cache, op string, err error) {
+			assert.Equal(t, "stats", cache)
+			assert.Equal(t, "flush", op)
+			assert.Error(t, err)
+			failed.Add(1)
+		}),
+	)
+
+	c.Set("a", testItem{Name: "alice"})
+	c.FlushToDisk()
+	assert.Equal(t, int32(1), failed.Load())
+
+	// неудачная запись повторяется при закрытии
+	c.Close()
+	assert.Equal(t, int32(2), failed.Load())
+}
+
+func TestCache_FlushOnClose(t *testing.T) {
+	t.Parallel()
+
+	var flushes atomic.Int32
+	path := filepath.Join(t.TempDir(), "stats.json")
+	c := storage.NewCache[testItem](0, 0, true, false, path, time.Hour,
+		storage.WithFlushHook(func(string, time.Duration, int) {
+			flushes.Add(1)
+		}),
+	)
+
+	c.Set("a", testItem{Name: "alice"})
+	assert.NoFileExists(t, path)
+
+	c.Close()
+	c.Close()
+	assert.Equal(t, int32(1), flushes.Load())
+
+	reopened := storage.NewCache[testItem](0, 0, true, false, path, 0)
+	t.Cleanup(reopened.Close)
+
+	v, ok := reopened.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, "alice", v.Name)
+}
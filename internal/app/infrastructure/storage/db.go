@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DB - встроенное key-value хранилище (bbolt). Данные разложены по бакетам,
+// каждая запись пишется отдельно, без перезаписи всего файла.
+type DB struct {
+	bolt *bbolt.DB
+}
+
+// Tx - транзакция хранилища. Бакеты создаются при первой записи.
+type Tx struct {
+	tx *bbolt.Tx
+}
+
+func OpenDB(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open db: %w", err)
+	}
+	return &DB{bolt: db}, nil
+}
+
+func (d *DB) Close() error {
+	return d.bolt.Close()
+}
+
+// Update выполняет fn в транзакции на запись; при ошибке все изменения откатываются.
+func (d *DB) Update(fn func(tx *Tx) error) error {
+	return d.bolt.Update(func(tx *bbolt.Tx) error {
+		return fn(&Tx{tx: tx})
+	})
+}
+
+// View выполняет fn в транзакции только на чтение.
+func (d *DB) View(fn func(tx *Tx) error) error {
+	return d.bolt.View(func(tx *bbolt.Tx) error {
+		return fn(&Tx{tx: tx})
+	})
+}
+
+func (t *Tx) Put(bucket, key string, val []byte) error {
+	b, err := t.tx.CreateBucketIfNotExists([]byte(bucket))
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), val)
+}
+
+// Get возвращает копию значения: данные bbolt действительны только внутри транзакции.
+func (t *Tx) Get(bucket, key string) ([]byte, bool) {
+	b := t.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil, false
+	}
+
+	val := b.Get([]byte(key))
+	if val == nil {
+		return nil, false
+	}
+	return append([]byte(nil), val...), true
+}
+
+func (t *Tx) Delete(bucket, key string) error {
+	b := t.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+	return b.Delete([]byte(key))
+}
+
+func (t *Tx) DeleteBucket(bucket string) error {
+	err := t.tx.DeleteBucket([]byte(bucket))
+	if errors.Is(err, bbolt.ErrBucketNotFound) {
+		return nil
+	}
+	return err
+}
+
+// ForEach обходит записи бакета; val действителен только внутри fn.
+func (t *Tx) ForEach(bucket string, fn func(key string, val []byte) error) error {
+	b := t.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+
+	return b.ForEach(func(k, v []byte) error {
+		return fn(string(k), v)
+	})
+}
+
+// Empty сообщает, что в бакете нет записей.
+func (t *Tx) Empty(bucket string) bool {
+	b := t.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return true
+	}
+
+	k, _ := b.Cursor().First()
+	return k == nil
+}
+
+// MigrateJSON однократно переносит JSON-файл кэша (объект ключ-значение) в пустой бакет
+// одной транзакцией, после чего переименовывает файл в <path>.migrated. Возвращает число записей.
+func (d *DB) MigrateJSON(bucket, path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var items map[string]json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return 0, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+
+	var migrated bool
+	if err := d.Update(func(tx *Tx) error {
+		// данные уже в базе - файл остался от прошлой версии
+		if !tx.Empty(bucket) {
+			return nil
+		}
+
+		for k, v := range items {
+			if err := tx.Put(bucket, k, v); err != nil {
+				return err
+			}
+		}
+		migrated = true
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	if !migrated {
+		return 0, nil
+	}
+
+	if err := os.Rename(path, path+".migrated"); err != nil {
+		return len(items), err
+	}
+	return len(items), nil
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrorHook получает ошибки записи кэша, которые некому вернуть: op - операция (set, delete, clear, flush).
+type ErrorHook func(cache, op string, err error)
+
+// DBCache - персистентный кэш поверх DB: значения хранятся в памяти,
+// а каждое изменение сразу записывается в свой бакет только для изменённого ключа.
+type DBCache[T any] struct {
+	wmu     sync.Mutex // держится на время изменения памяти и записи в базу, чтобы порядок записей совпадал
+	mu      sync.RWMutex
+	items   map[string]T
+	db      *DB
+	bucket  string
+	onError ErrorHook
+}
+
+// NewDBCache загружает бакет в память. Если бакет пуст, в него переносится JSON-файл
+// прежнего файлового кэша legacyPath. Ошибки записи передаются в onError (может быть nil).
+func NewDBCache[T any](db *DB, bucket, legacyPath string, onError ErrorHook) (*DBCache[T], error) {
+	c := &DBCache[T]{
+		items:   make(map[string]T),
+		db:      db,
+		bucket:  bucket,
+		onError: onError,
+	}
+
+	if legacyPath != "" {
+		if _, err := db.MigrateJSON(bucket, legacyPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := db.View(func(tx *Tx) error {
+		return tx.ForEach(bucket, func(key string, val []byte) error {
+			var v T
+			if err := json.Unmarshal(val, &v); err != nil {
+				return err
+			}
+			c.items[key] = v
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *DBCache[T]) Set(key string, val T) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.mu.Lock()
+	c.items[key] = val
+	c.mu.Unlock()
+
+	data, err := json.Marshal(val)
+	if err == nil {
+		err = c.db.Update(func(tx *Tx) error {
+			return tx.Put(c.bucket, key, data)
+		})
+	}
+	c.report("set", err)
+}
+
+func (c *DBCache[T]) Get(key string) (T, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v, ok := c.items[key]
+	return v, ok
+}
+
+// Keys возвращает отсортированные ключи, начинающиеся с prefix.
+func (c *DBCache[T]) Keys(prefix string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var keys []string
+	for k := range c.items {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func (c *DBCache[T]) ClearKey(key string) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.mu.Lock()
+	delete(c.items, key)
+	c.mu.Unlock()
+
+	c.report("delete", c.db.Update(func(tx *Tx) error {
+		return tx.Delete(c.bucket, key)
+	}))
+}
+
+func (c *DBCache[T]) ClearAll() {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.mu.Lock()
+	clear(c.items)
+	c.mu.Unlock()
+
+	c.report("clear", c.db.Update(func(tx *Tx) error {
+		return tx.DeleteBucket(c.bucket)
+	}))
+}
+
+// FlushToDisk переписывает бакет целиком одной транзакцией; обычные изменения уже записаны.
+func (c *DBCache[T]) FlushToDisk() {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.mu.RLock()
+	items := maps.Clone(c.items)
+	c.mu.RUnlock()
+
+	c.report("flush", c.db.Update(func(tx *Tx) error {
+		if err := tx.DeleteBucket(c.bucket); err != nil {
+			return err
+		}
+
+		for k, v := range items {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Put(c.bucket, k, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// Close ничего не делает: изменения записываются в базу сразу.
+func (c *DBCache[T]) Close() {}
+
+func (c *DBCache[T]) report(op string, err error) {
+	if err != nil && c.onError != nil {
+		c.onError(c.bucket, op, err)
+	}
+}
@@ -0,0 +1,158 @@
+package storage_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"twitchspam/internal/app/infrastructure/storage"
+)
+
+type testItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func openTestDB(t *testing.T) (*storage.DB, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	db, err := storage.OpenDB(filepath.Join(dir, "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, dir
+}
+
+func TestMigrateJSON_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	db, dir := openTestDB(t)
+	path := filepath.Join(dir, "stats.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"a": {"name": "alice", "count": 1}, "b": {"name": "bob", "count": 2}}`), 0600))
+
+	n, err := db.MigrateJSON("stats", path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// файл переименован, повторный запуск ничего не переносит
+	assert.NoFileExists(t, path)
+	assert.FileExists(t, path+".migrated")
+
+	n, err = db.MigrateJSON("stats", path)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	c, err := storage.NewDBCache[testItem](db, "stats", path, nil)
+	require.NoError(t, err)
+
+	v, ok := c.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, testItem{Name: "alice", Count: 1}, v)
+	v, ok = c.Get("b")
+	require.True(t, ok)
+	assert.Equal(t, testItem{Name: "bob", Count: 2}, v)
+}
+
+func TestMigrateJSON_BucketNotEmpty(t *testing.T) {
+	t.Parallel()
+
+	db, dir := openTestDB(t)
+	require.NoError(t, db.Update(func(tx *storage.Tx) error {
+		return tx.Put("stats", "a", []byte(`{"name": "db", "count": 5}`))
+	}))
+
+	// данные в базе важнее оставшегося от прошлой версии файла
+	path := filepath.Join(dir, "stats.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"a": {"name": "file", "count": 1}}`), 0600))
+
+	n, err := db.MigrateJSON("stats", path)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.FileExists(t, path)
+
+	c, err := storage.NewDBCache[testItem](db, "stats", path, nil)
+	require.NoError(t, err)
+
+	v, ok := c.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, "db", v.Name)
+}
+
+func TestDBCache_Persists(t *testing.T) {
+	t.Parallel()
+
+	db, _ := openTestDB(t)
+	c, err := storage.NewDBCache[testItem](db, "stats", "", nil)
+	require.NoError(t, err)
+
+	c.Set("a", testItem{Name: "alice", Count: 1})
+	c.Set("b", testItem{Name: "bob", Count: 2})
+	c.ClearKey("b")
+
+	reopened, err := storage.NewDBCache[testItem](db, "stats", "", nil)
+	require.NoError(t, err)
+
+	_, ok := reopened.Get("a")
+	assert.True(t, ok)
+	_, ok = reopened.Get("b")
+	assert.False(t, ok)
+}
+
+func TestDBCache_ConcurrentWritesMatchMemory(t *testing.T) {
+	t.Parallel()
+
+	db, _ := openTestDB(t)
+	c, err := storage.NewDBCache[testItem](db, "stats", "", nil)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%10 == 9 {
+				c.ClearKey("a")
+				return
+			}
+			c.Set("a", testItem{Name: "alice", Count: i})
+		}()
+	}
+	wg.Wait()
+
+	// последнее значение в памяти совпадает с последним записанным в базу
+	reopened, err := storage.NewDBCache[testItem](db, "stats", "", nil)
+	require.NoError(t, err)
+
+	want, wantOK := c.Get("a")
+	got, ok := reopened.Get("a")
+	assert.Equal(t, wantOK, ok)
+	assert.Equal(t, want, got)
+}
+
+func TestDBCache_ReportsWriteErrors(t *testing.T) {
+	t.Parallel()
+
+	db, _ := openTestDB(t)
+
+	type failure struct{ cache, op string }
+	var failures []failure
+	c, err := storage.NewDBCache[testItem](db, "stats", "", func(cache, op string, err error) {
+		assert.Error(t, err)
+		failures = append(failures, failure{cache, op})
+	})
+	require.NoError(t, err)
+
+	c.Set("a", testItem{Name: "alice"})
+	assert.Empty(t, failures)
+
+	// после закрытия базы каждая запись завершается ошибкой и передаётся в хук
+	require.NoError(t, db.Close())
+	c.Set("b", testItem{Name: "bob"})
+	c.ClearKey("a")
+	c.ClearAll()
+	c.FlushToDisk()
+
+	assert.Equal(t, []failure{{"stats", "set"}, {"stats", "delete"}, {"stats", "clear"}, {"stats", "flush"}}, failures)
+}
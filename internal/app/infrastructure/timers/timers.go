@@ -74,6 +74,15 @@ func (tw *TimingWheel) RemoveTimer(id string) {
 	}
 }
 
+func (tw *TimingWheel) RemoveAll() {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+
+	for _, s := range tw.slots {
+		clear(s.timers)
+	}
+}
+
 func (tw *TimingWheel) start() {
 	for range tw.ticker.C {
 		tw.mutex.Lock()
@@ -0,0 +1,23 @@
+package ports
+
+import (
+	"time"
+	"twitchspam/internal/app/domain/message"
+)
+
+// ChatLogEntry - сообщение в журнале чата.
+type ChatLogEntry struct {
+	Time     time.Time `json:"t"`
+	UserID   string    `json:"id"`
+	Login    string    `json:"l"`
+	Username string    `json:"u"`
+	Text     string    `json:"m"`
+}
+
+type ChatLogPort interface {
+	Add(msg *message.ChatMessage) error
+	User(login string, n int) ([]ChatLogEntry, error)
+	Grep(text string, limit int) ([]ChatLogEntry, error)
+	SetRetention(days int)
+	Close() error
+}
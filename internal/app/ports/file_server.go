@@ -1,6 +1,12 @@
 package ports
 
+import "errors"
+
+// ErrNotHaste - Download получил адрес, не относящийся к файловому серверу.
+var ErrNotHaste = errors.New("only haste keys or haste links are allowed")
+
 type FileServerPort interface {
 	UploadToHaste(text string) (string, error)
 	GetURL(key string) string
+	Download(keyOrURL string) (string, error)
 }
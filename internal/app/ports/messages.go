@@ -34,4 +34,7 @@ type CheckerAction struct {
 	ReasonMod  string
 	ReasonUser string
 	Duration   time.Duration
+	Module     string  // модуль, вынесший решение (nuke, mword, spam...)
+	Rule       string  // сработавшее правило внутри модуля
+	Similarity float64 // схожесть с предыдущими сообщениями для спам-модулей
 }
@@ -24,7 +24,9 @@ type StorePort[T any] interface {
 type CachePort[T any] interface {
 	Set(key string, val T)
 	Get(key string) (T, bool)
+	Keys(prefix string) []string
 	ClearKey(key string)
 	ClearAll()
 	FlushToDisk()
+	Close()
 }
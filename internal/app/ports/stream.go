@@ -9,16 +9,114 @@ type StreamPort interface {
 	Stats() StatsPort
 	IsLive() bool
 	SetIslive(isLive bool)
+	OnLiveChange(fn func(isLive bool))
 	ChannelID() string
 	SetChannelID(channelID string)
 	ChannelName() string
 	SetCategory(category string)
 	Category() string
+	OnCategoryChange(fn func(category string))
 	OnceStart() *sync.Once
 }
 
+// ActivityMoment - минута стрима, в которую активность чата резко выросла относительно предыдущих минут.
+type ActivityMoment struct {
+	Time     time.Time
+	Timecode time.Duration // от начала стрима
+	StreamID string
+	Kind     string // messages или emotes
+	Messages int
+	Emotes   int
+	Baseline float64 // среднее значение за предыдущие минуты
+}
+
+// StreamSummary - итоги стрима для выгрузки через HTTP API.
+type StreamSummary struct {
+	StreamID   string            `json:"stream_id"`
+	Start      time.Time         `json:"start"`
+	End        time.Time         `json:"end,omitzero"`
+	Duration   int64             `json:"duration_sec"`
+	MaxViewers int               `json:"max_viewers"`
+	AvgViewers float64           `json:"avg_viewers"`
+	Messages   int               `json:"messages"`
+	Chatters   int               `json:"chatters"`
+	Actions    ActionSummary     `json:"actions"`
+	Categories []CategorySummary `json:"categories"`
+	Viewers    ViewerMetrics     `json:"viewers"`
+}
+
+// ViewerPoint - онлайн за интервал временного ряда.
+type ViewerPoint struct {
+	Time    time.Time `json:"time"`
+	Min     int       `json:"min"`
+	Max     int       `json:"max"`
+	Avg     float64   `json:"avg"`
+	Samples int       `json:"samples"`
+}
+
+// ViewerMetrics - производные метрики онлайна за стрим.
+type ViewerMetrics struct {
+	PeakViewers       int              `json:"peak_viewers"`
+	PeakTime          time.Time        `json:"peak_time,omitzero"`
+	AvgFirstHour      float64          `json:"avg_first_hour"`
+	AvgAfterFirstHour float64          `json:"avg_after_first_hour"`
+	Retention         float64          `json:"retention"` // отношение среднего онлайна после первого часа к первому часу
+	CategorySwitches  []CategorySwitch `json:"category_switches"`
+}
+
+// CategorySwitch - изменение онлайна вокруг смены категории.
+type CategorySwitch struct {
+	Time   time.Time `json:"time"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Before float64   `json:"before"` // средний онлайн до смены
+	After  float64   `json:"after"`  // средний онлайн после смены
+}
+
+type CategorySummary struct {
+	Name       string  `json:"name"`
+	Duration   int64   `json:"duration_sec"`
+	Messages   int     `json:"messages"`
+	AvgViewers float64 `json:"avg_viewers"`
+}
+
+type ActionSummary struct {
+	Deletes  int `json:"deletes"`
+	Timeouts int `json:"timeouts"`
+	Warns    int `json:"warns"`
+	Bans     int `json:"bans"`
+}
+
+// ChatterSummary - статистика чаттера на канале за текущий стрим и за всё время.
+type ChatterSummary struct {
+	Login          string        `json:"login"`
+	StreamMessages int           `json:"stream_messages"`
+	Messages       int           `json:"messages"`
+	Streams        int           `json:"streams"`
+	FirstSeen      time.Time     `json:"first_seen,omitzero"`
+	LastSeen       time.Time     `json:"last_seen,omitzero"`
+	Punishments    ActionSummary `json:"punishments"`
+	ModActions     ActionSummary `json:"mod_actions"`
+}
+
+// ModeratorActions - действия модератора (или бота) за период.
+type ModeratorActions struct {
+	Login   string        `json:"login"`
+	Actions ActionSummary `json:"actions"`
+}
+
+// ModerationAction - отдельное действие модератора: кто, что, над кем и когда сделал.
+type ModerationAction struct {
+	Time      time.Time `json:"time"`
+	Moderator string    `json:"moderator"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+}
+
 type StatsPort interface {
 	Reset()
+	SetStreamID(id string)
+	StreamID() string
 	SetStartTime(t time.Time)
 	GetStartTime() time.Time
 	SetEndTime(t time.Time)
@@ -30,7 +128,29 @@ type StatsPort interface {
 	AddBan(username string)
 	AddTimeout(username string)
 	AddCategoryChange(category string, t time.Time)
+	AddPunishment(moderator, login, action string)
+	AddEmotes(count int)
+	OnSpike(fn func(moment ActivityMoment))
+	TopMoments(n int) []ActivityMoment
+	AddModuleAction(module, action string)
+	AddEvent(kind, text string)
+	OnStreamEnd(fn func())
+	Report(botLogin string, markers map[string][]time.Duration) string
 	GetStats() *AnswerType
+	GetEndStats(modReport bool, botLogin string) *AnswerType
 	GetUserStats(username string) *AnswerType
 	GetTopStats(count int) *AnswerType
+	GetLastStats() *AnswerType
+	GetStatsByDate(date time.Time) *AnswerType
+	GetCompareStats(dates ...time.Time) *AnswerType
+	GetUserLifetimeStats(username string) *AnswerType
+	GetTopLifetimeStats(count, days int) *AnswerType
+	GetGamesStats() *AnswerType
+	GetModStats(days int, botLogin string) *AnswerType
+	CurrentSummary() (StreamSummary, bool)
+	ViewerSeries(streamID string) ([]ViewerPoint, ViewerMetrics, bool)
+	HistorySummaries(from, to time.Time) []StreamSummary
+	ChatterSummary(login string) (ChatterSummary, bool)
+	ModeratorActions(days int) []ModeratorActions
+	ModerationLog(days int) []ModerationAction
 }
@@ -30,6 +30,7 @@ type PlaceholdersPort interface {
 }
 
 type BanwordsPort interface {
+	Update(banwords config.Banwords)
 	CheckMessage(wordsOriginal, wordsLower []string) bool
 }
 
@@ -71,8 +72,72 @@ type MwordPort interface {
 }
 
 type NukePort interface {
-	Start(punishment config.Punishment, duration time.Duration, containsWords, words []string, regexp *regexp.Regexp, startFn func(ctx context.Context))
-	Restart() error
-	Cancel()
-	Check(text *message.Text, ignoreNuke bool) *CheckerAction
+	Start(name string, rule NukeRule, startFn func(ctx context.Context, name string)) string
+	Restart() (string, error)
+	Cancel(name string) error
+	List() []NukeInfo
+	Check(text *message.Text, ignoreNuke bool) (string, *CheckerAction)
+	CheckOne(name string, text *message.Text, ignoreNuke bool) *CheckerAction
+	Record(name string, hit NukeHit)
+	Acquire(name string) (release func(), ok bool)
+	Targets() []NukeTarget
+	ClearTargets()
+	RemoveTargets(targets []NukeTarget)
+	Report(name string) (NukeReport, bool)
+	OnFinish(fn func(report NukeReport))
+}
+
+type NukeRule struct {
+	Punishment    config.Punishment
+	Duration      time.Duration
+	ContainsWords []string
+	Words         []string
+	Regexp        *regexp.Regexp
+	Sample        []string // слова сообщения-образца (!am nuke like)
+	Threshold     float64  // порог схожести с образцом
+}
+
+// NukeState - сохраняемое на диск состояние активного массбана.
+type NukeState struct {
+	Name          string
+	Punishment    config.Punishment
+	StartedAt     time.Time
+	ExpiresAt     time.Time
+	ContainsWords []string
+	Words         []string
+	Regexp        string
+	Sample        []string
+	Threshold     float64
+}
+
+type NukeInfo struct {
+	Name      string
+	Rule      NukeRule
+	ExpiresAt time.Time
+	Targets   int
+}
+
+type NukeHit struct {
+	UserID   string
+	Username string
+	Text     string
+	Action   string
+	Source   string // scrollback или live
+	Error    string // ошибка запроса к API
+	Time     time.Time
+}
+
+type NukeReport struct {
+	Name      string
+	Rule      NukeRule
+	StartedAt time.Time
+	EndedAt   time.Time // нулевое значение - массбан ещё активен
+	Hits      []NukeHit
+}
+
+type NukeTarget struct {
+	UserID   string
+	Username string
+	Action   string
+	Time     time.Time
 }
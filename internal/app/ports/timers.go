@@ -5,4 +5,5 @@ import "time"
 type TimersPort interface {
 	AddTimer(id string, interval time.Duration, repeat bool, args map[string]any, task func(map[string]any))
 	RemoveTimer(id string)
+	RemoveAll()
 }
@@ -1,8 +1,12 @@
 package ports
 
-import "twitchspam/internal/app/domain/trusts"
+import (
+	"twitchspam/internal/app/domain/trusts"
+	"twitchspam/internal/app/infrastructure/config"
+)
 
 type TrustsPort interface {
+	Reset(roles, globalRoles map[string][]string, users map[string]*config.Trust)
 	Update(user string, roles, scopes []string)
 	AddRole(role string, scopes []string)
 	DeleteRole(role string, scopes []string)
@@ -6,15 +6,19 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/net/proxy"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 	"twitchspam/internal/app/adapters/file_server"
 	router "twitchspam/internal/app/adapters/http"
 	"twitchspam/internal/app/adapters/message"
+	"twitchspam/internal/app/adapters/message/admin"
 	"twitchspam/internal/app/adapters/metrics"
 	"twitchspam/internal/app/adapters/platform/twitch"
 	"twitchspam/internal/app/domain/stream"
@@ -35,6 +39,7 @@ func New() error {
 	if err != nil {
 		log.Fatal("Error loading config", err)
 	}
+	log.SetRedactor(manager.Redact)
 
 	cfg := manager.Get()
 	if cfg.Proxy != nil && cfg.Proxy.Address != "" && cfg.Proxy.Port != 0 {
@@ -61,13 +66,55 @@ func New() error {
 	}
 
 	t := twitch.New(log, manager, client)
-	cacheStats := storage.NewCache[stream.SessionStats](0, 0, true, true, "cache/stats.json", 0)
+	cacheStats, err := newCache[stream.SessionStats](log, manager.DB(), "stats", "cache/stats.json")
+	if err != nil {
+		log.Error("Error opening stats cache", err)
+		return err
+	}
+
+	cacheStatsArchive, err := newCache[stream.SessionStats](log, manager.DB(), "stats_sessions", "cache/stats_sessions.json")
+	if err != nil {
+		log.Error("Error opening stats archive cache", err)
+		return err
+	}
+
+	// архив прежнего формата хранил все сессии канала одной записью
+	legacyArchive, err := newCache[[]stream.SessionStats](log, manager.DB(), "stats_archive", "cache/stats_archive.json")
+	if err != nil {
+		log.Error("Error opening legacy stats archive cache", err)
+		return err
+	}
+	for _, channel := range cfg.Channels {
+		if n := stream.MigrateArchive(channel.Name, legacyArchive, cacheStatsArchive); n > 0 {
+			log.Info("Stats archive migrated", slog.String("channel", channel.Name), slog.Int("sessions", n))
+		}
+	}
+	legacyArchive.Close()
+
+	cacheChatters, err := newCache[map[string]*stream.ChatterStats](log, manager.DB(), "chatters", "cache/chatters.json")
+	if err != nil {
+		log.Error("Error opening chatters cache", err)
+		return err
+	}
+
+	cacheNukes, err := newCache[[]ports.NukeState](log, manager.DB(), "nukes", "cache/nukes.json")
+	if err != nil {
+		log.Error("Error opening nukes cache", err)
+		return err
+	}
+
+	cacheCategories, err := newCache[admin.Category](log, manager.DB(), "categories", "cache/categories.json")
+	if err != nil {
+		log.Error("Error opening categories cache", err)
+		return err
+	}
 
 	streams := make(map[string]ports.StreamPort, len(cfg.Channels))
 	channelIDs := make([]string, 0, len(cfg.Channels))
 
 	var wg sync.WaitGroup
 	var mu sync.Mutex
+	var handlers []*message.Message
 	for _, channel := range cfg.Channels {
 		wg.Add(1)
 		go func(channel *config.Channel) {
@@ -81,36 +128,41 @@ func New() error {
 
 			metrics.MessagesPerStream.With(labels).Add(0)
 			for _, action := range []string{"delete", "timeout", "warn", "ban"} {
-				metrics.ModerationActions.With(prometheus.Labels{"channel": channel.Name, "action": action}).Set(0)
+				metrics.ModerationActions.With(prometheus.Labels{"channel": channel.Name, "action": action}).Add(0)
+			}
+			for _, method := range []string{"BanUser", "TimeoutUser", "WarnUser", "DeleteChatMessage"} {
+				metrics.ModerationAPIErrors.With(prometheus.Labels{"channel": channel.Name, "method": method}).Add(0)
 			}
 
 			prefixedLog := logger.NewPrefixedLogger(log, channel.Name)
-			st := stream.NewStream(channel.Name, fs, cacheStats)
+			st := stream.NewStream(channel.Name, fs, cacheStats, cacheStatsArchive, cacheChatters)
 
-			if channel.ID == "" {
+			channelID := channel.ID
+			if channelID == "" {
 				IDs, err := t.API().GetChannelIDs([]string{channel.Name})
 				if err != nil {
 					log.Error("Error getting live stream", err)
 					return
 				}
-				channel.ID = IDs[channel.Name]
+				channelID = IDs[channel.Name]
 
 				if err := manager.Update(func(cfg *config.Config) {
-					cfg.Channels[strings.ToLower(channel.Name)].ID = channel.ID
+					cfg.Channels[strings.ToLower(channel.Name)].ID = channelID
 				}); err != nil {
 					log.Error("Error updating live stream", err)
 					return
 				}
 			}
 
-			st.SetChannelID(channel.ID)
-			channelIDs = append(channelIDs, channel.ID)
+			st.SetChannelID(channelID)
+			channelIDs = append(channelIDs, channelID)
 
-			msg := message.New(prefixedLog, manager, st, t.API(), client)
+			msg := message.New(prefixedLog, manager, st, t.API(), cacheNukes, cacheCategories, client)
 			t.AddChannel(channel.Name, st, msg)
 
 			mu.Lock()
 			streams[channel.Name] = st
+			handlers = append(handlers, msg)
 			mu.Unlock()
 
 			log.Info(fmt.Sprintf("[%s] Chatbot started", channel.Name))
@@ -119,6 +171,41 @@ func New() error {
 
 	wg.Wait()
 
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		<-sig
+
+		// журналы чата и кэши дописывают отложенные изменения, база закрывается последней
+		log.Info("Shutting down")
+		for _, msg := range handlers {
+			if err := msg.Close(); err != nil {
+				log.Error("Error closing chat log", err)
+			}
+		}
+		for _, cache := range []interface{ Close() }{cacheStats, cacheStatsArchive, cacheChatters, cacheNukes, cacheCategories} {
+			cache.Close()
+		}
+		if err := manager.Close(); err != nil {
+			log.Error("Error closing storage", err)
+		}
+		os.Exit(0)
+	}()
+
+	manager.OnReload(func(cfg *config.Config) {
+		log.SetLogLevel(cfg.App.LogLevel)
+	})
+	go manager.Watch(context.Background(), 5*time.Second, func(diff []string, err error) {
+		if err != nil {
+			log.Error("Config changes on disk rejected", err, slog.String("diff", strings.Join(diff, "\n")))
+			return
+		}
+
+		if len(diff) != 0 {
+			log.Info("Config reloaded from disk", slog.String("diff", strings.Join(diff, "\n")))
+		}
+	})
+
 	go func() {
 		syncLiveStreams := func() {
 			data, err := t.API().GetLiveStreams(channelIDs)
@@ -142,6 +229,7 @@ func New() error {
 				s.Stats().SetOnline(d.ViewerCount)
 				s.OnceStart().Do(func() {
 					s.Stats().SetStartTime(d.StartedAt.In(time.Local))
+					s.Stats().SetStreamID(d.ID)
 				})
 			}
 
@@ -164,9 +252,33 @@ func New() error {
 		}
 	}()
 
-	r, err := router.NewRouter(log, manager, client)
+	r, err := router.NewRouter(log, manager, client, streams)
 	if err != nil {
 		return err
 	}
 	return r.Run()
 }
+
+// newCache создаёт персистентный кэш: во встроенной базе, если она включена, иначе в JSON-файле.
+// При первом запуске с базой содержимое файла переносится в неё.
+func newCache[T any](log logger.Logger, db *storage.DB, bucket, filePath string) (ports.CachePort[T], error) {
+	onError := func(cache, op string, err error) {
+		if db == nil {
+			metrics.CacheFlushErrors.With(prometheus.Labels{"cache": cache}).Inc()
+		} else {
+			metrics.CacheWriteErrors.With(prometheus.Labels{"cache": cache, "op": op}).Inc()
+		}
+		log.Error("Error writing cache", err, slog.String("cache", cache), slog.String("op", op))
+	}
+
+	if db == nil {
+		return storage.NewCache[T](0, 0, true, true, filePath, 0,
+			storage.WithFlushHook(func(cache string, d time.Duration, size int) {
+				metrics.CacheFlushDuration.With(prometheus.Labels{"cache": cache}).Observe(d.Seconds())
+				metrics.CacheFlushBytes.With(prometheus.Labels{"cache": cache}).Set(float64(size))
+			}),
+			storage.WithErrorHook(onError),
+		), nil
+	}
+	return storage.NewDBCache[T](db, bucket, filePath, onError)
+}
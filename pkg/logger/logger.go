@@ -9,6 +9,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync/atomic"
 )
 
 const (
@@ -32,6 +33,7 @@ type SlogLogger struct {
 	log        *slog.Logger
 	level      *slog.LevelVar
 	levelNames map[slog.Leveler]string
+	redact     atomic.Pointer[func(string) string]
 }
 
 func New() *SlogLogger {
@@ -63,6 +65,9 @@ func New() *SlogLogger {
 			if a.Key == "source" {
 				a.Value = slog.StringValue(callerOutsideLogger(10))
 			}
+			if redact := l.redact.Load(); redact != nil {
+				a.Value = redactValue(a.Value, *redact)
+			}
 
 			return a
 		},
@@ -106,6 +111,11 @@ func (l *SlogLogger) SetLogLevel(levelStr string) {
 	}
 }
 
+// SetRedactor задаёт функцию, которой обрабатываются все значения записей (в том числе сообщение и ошибки).
+func (l *SlogLogger) SetRedactor(fn func(string) string) {
+	l.redact.Store(&fn)
+}
+
 func (l *SlogLogger) GetLogLevel() string {
 	switch l.level.Level() {
 	case LevelTrace:
@@ -167,6 +177,27 @@ func (l *SlogLogger) Fatal(msg string, err error, args ...any) {
 	os.Exit(1)
 }
 
+// redactValue прогоняет значение через redact. Ошибки и прочие нестроковые значения проверяются
+// в текстовом виде и заменяются строкой, только если в них нашёлся секрет.
+func redactValue(v slog.Value, redact func(string) string) slog.Value {
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.StringValue(redact(v.String()))
+	case slog.KindAny:
+		var text string
+		if err, ok := v.Any().(error); ok {
+			text = err.Error()
+		} else {
+			text = fmt.Sprintf("%+v", v.Any())
+		}
+
+		if redacted := redact(text); redacted != text {
+			return slog.StringValue(redacted)
+		}
+	}
+	return v
+}
+
 func callerOutsideLogger(skip int) string {
 	for i := skip; ; i++ {
 		_, file, line, ok := runtime.Caller(i)